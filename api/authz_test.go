@@ -0,0 +1,420 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yosa/ocr-golang-back/authz"
+	"github.com/yosa/ocr-golang-back/db"
+	"github.com/yosa/ocr-golang-back/token"
+	"github.com/yosa/ocr-golang-back/util"
+)
+
+// authzQuerier knows a single document, owned by alice and shared with carol
+// as a viewer and dave as an editor, and a folder of alice and one of bob. Every other query
+// panics through the nil Querier, which gin turns into a 500: requests that
+// are refused must not get further than loading the document.
+type authzQuerier struct {
+	db.Querier
+
+	mu         sync.Mutex
+	listedUser string
+}
+
+var (
+	aliceDocument = db.Document{ID: "doc-1", UserID: "alice"}
+	aliceFolder   = db.Folder{ID: "folder-1", UserID: "alice"}
+	bobFolder     = db.Folder{ID: "folder-2", UserID: "bob"}
+)
+
+func (q *authzQuerier) GetDocumentByID(ctx context.Context, id string) (db.Document, error) {
+	if id != aliceDocument.ID {
+		return db.Document{}, pgx.ErrNoRows
+	}
+	return aliceDocument, nil
+}
+
+var documentGrants = map[string]string{"carol": "viewer", "dave": "editor"}
+
+func (q *authzQuerier) ListDocumentGrants(ctx context.Context, arg db.ListDocumentGrantsParams) ([]string, error) {
+	if role, ok := documentGrants[arg.Username]; ok && arg.DocumentID == aliceDocument.ID {
+		return []string{role}, nil
+	}
+	return nil, nil
+}
+
+func (q *authzQuerier) ListFolderGrants(ctx context.Context, arg db.ListFolderGrantsParams) ([]string, error) {
+	return nil, nil
+}
+
+func (q *authzQuerier) GetFolderByID(ctx context.Context, id string) (db.Folder, error) {
+	for _, folder := range []db.Folder{aliceFolder, bobFolder} {
+		if folder.ID == id {
+			return folder, nil
+		}
+	}
+	return db.Folder{}, pgx.ErrNoRows
+}
+
+func (q *authzQuerier) ListDocuments(ctx context.Context, arg db.ListDocumentsParams) ([]db.Document, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.listedUser = arg.UserID
+	return []db.Document{}, nil
+}
+
+func (q *authzQuerier) CountDocuments(ctx context.Context, arg db.CountDocumentsParams) (int64, error) {
+	return 0, nil
+}
+
+func newAuthzTestServer(t *testing.T) (*Server, *authzQuerier) {
+	gin.SetMode(gin.TestMode)
+	writer, errorWriter := gin.DefaultWriter, gin.DefaultErrorWriter
+	gin.DefaultWriter, gin.DefaultErrorWriter = io.Discard, io.Discard
+	t.Cleanup(func() { gin.DefaultWriter, gin.DefaultErrorWriter = writer, errorWriter })
+
+	queries := &authzQuerier{}
+	server, err := NewServer(util.Config{TokenSymmetricKey: util.RandomString(32)}, queries, nil, nil)
+	require.NoError(t, err)
+	return server, queries
+}
+
+func (s *Server) testRequest(t *testing.T, method, path, username string, body io.Reader) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, body)
+	if username != "" {
+		accessToken, _, err := s.tokenMaker.CreateToken(username, time.Minute)
+		require.NoError(t, err)
+		req.Header.Set(authorizationHeaderKey, "Bearer "+accessToken)
+	}
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func errorMessage(t *testing.T, recorder *httptest.ResponseRecorder) string {
+	var rsp struct {
+		Error string `json:"error"`
+	}
+	json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	return rsp.Error
+}
+
+// validInputs are the query strings and bodies document routes need to pass
+// validation, which may come before authorization.
+var validInputs = map[string]struct {
+	query string
+	body  string
+}{
+	"PATCH /documents/:id":       {body: `{"filename": "renamed.pdf"}`},
+	"GET /documents/:id/export":  {query: "?format=txt"},
+	"POST /documents/:id/move":   {body: `{"folder_id": null}`},
+	"POST /documents/:id/tags":   {body: `{"tags": ["invoice"]}`},
+	"POST /documents/:id/shares": {body: `{"username": "erin", "role": "viewer"}`},
+	"POST /documents/:id/links":  {body: `{"content": "text"}`},
+}
+
+// documentRoutePath fills the parameters of a document route in, for alice's
+// document.
+func documentRoutePath(route gin.RouteInfo) string {
+	return strings.NewReplacer(":id", aliceDocument.ID, ":n", "1", ":tag", "invoice", ":username", "carol", ":link_id", "00000000-0000-0000-0000-000000000001").Replace(route.Path)
+}
+
+// TestDocumentRoutesAuthorization tries every route on a single document as
+// its owner, as another user and without a token.
+func TestDocumentRoutesAuthorization(t *testing.T) {
+	server, _ := newAuthzTestServer(t)
+
+	var routes []gin.RouteInfo
+	for _, route := range server.router.Routes() {
+		if strings.HasPrefix(route.Path, "/documents/:id") {
+			routes = append(routes, route)
+		}
+	}
+	require.NotEmpty(t, routes)
+
+	for _, route := range routes {
+		name := fmt.Sprintf("%s %s", route.Method, route.Path)
+		input := validInputs[name]
+		path := documentRoutePath(route) + input.query
+
+		request := func(path, username string) *httptest.ResponseRecorder {
+			return server.testRequest(t, route.Method, path, username, strings.NewReader(input.body))
+		}
+
+		t.Run(name, func(t *testing.T) {
+			// Other users can't tell the document from a missing one.
+			recorder := request(path, "bob")
+			require.Equal(t, http.StatusNotFound, recorder.Code)
+			require.Equal(t, authz.ErrNoAccess.Error(), errorMessage(t, recorder))
+
+			missing := strings.Replace(path, aliceDocument.ID, "doc-2", 1)
+			recorder = request(missing, "bob")
+			require.Equal(t, http.StatusNotFound, recorder.Code)
+			require.Equal(t, authz.ErrNoAccess.Error(), errorMessage(t, recorder))
+
+			recorder = request(path, "")
+			require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+			// The owner gets past authorization, whatever happens next.
+			recorder = request(path, "alice")
+			require.NotEqual(t, http.StatusUnauthorized, recorder.Code)
+			require.NotEqual(t, http.StatusForbidden, recorder.Code)
+			require.NotEqual(t, authz.ErrNoAccess.Error(), errorMessage(t, recorder))
+		})
+	}
+}
+
+// TestSharedDocumentAuthorization checks shares give their role on every
+// document route: viewers only read, editors also edit, neither deletes,
+// shares nor makes links.
+func TestSharedDocumentAuthorization(t *testing.T) {
+	server, _ := newAuthzTestServer(t)
+
+	for _, route := range server.router.Routes() {
+		if !strings.HasPrefix(route.Path, "/documents/:id") {
+			continue
+		}
+		name := fmt.Sprintf("%s %s", route.Method, route.Path)
+		input := validInputs[name]
+		path := documentRoutePath(route) + input.query
+
+		var viewerAllowed, editorAllowed bool
+		switch {
+		case route.Method == http.MethodDelete && route.Path == "/documents/:id",
+			strings.HasPrefix(route.Path, "/documents/:id/shares"),
+			strings.HasPrefix(route.Path, "/documents/:id/links"):
+		case route.Method == http.MethodGet:
+			viewerAllowed, editorAllowed = true, true
+		default:
+			editorAllowed = true
+		}
+
+		t.Run(name, func(t *testing.T) {
+			for username, allowed := range map[string]bool{"carol": viewerAllowed, "dave": editorAllowed} {
+				recorder := server.testRequest(t, route.Method, path, username, strings.NewReader(input.body))
+				require.NotEqual(t, authz.ErrNoAccess.Error(), errorMessage(t, recorder), username)
+				if allowed {
+					require.NotEqual(t, http.StatusForbidden, recorder.Code, username)
+				} else {
+					require.Equal(t, http.StatusForbidden, recorder.Code, username)
+				}
+			}
+		})
+	}
+}
+
+func TestFolderRoutesAuthorization(t *testing.T) {
+	server, _ := newAuthzTestServer(t)
+
+	// Every request touches a folder of alice as bob, or a folder of bob as
+	// alice.
+	testCases := []struct {
+		name     string
+		username string
+		method   string
+		path     string
+		body     string
+	}{
+		{"Rename", "bob", http.MethodPatch, "/folders/folder-1", `{"name": "taxes"}`},
+		{"Move", "bob", http.MethodPost, "/folders/folder-1/move", `{"parent_id": null}`},
+		{"MoveInto", "bob", http.MethodPost, "/folders/folder-2/move", `{"parent_id": "folder-1"}`},
+		{"CreateIn", "bob", http.MethodPost, "/folders", `{"name": "taxes", "parent_id": "folder-1"}`},
+		{"MoveDocumentInto", "alice", http.MethodPost, "/documents/doc-1/move", `{"folder_id": "folder-2"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := server.testRequest(t, tc.method, tc.path, tc.username, strings.NewReader(tc.body))
+			require.Equal(t, http.StatusNotFound, recorder.Code)
+			require.Equal(t, authz.ErrNoFolderAccess.Error(), errorMessage(t, recorder))
+		})
+	}
+}
+
+// sharedFolderQuerier adds to authzQuerier a folder of alice shared with
+// dave as an editor, holding doc-3, folder-4 and folder-6, and another of
+// her folders shared with dave as an editor and erin as a viewer.
+type sharedFolderQuerier struct {
+	authzQuerier
+}
+
+var (
+	sharedDocument   = db.Document{ID: "doc-3", UserID: "alice", FolderID: pgtype.Text{String: "folder-3", Valid: true}}
+	sharedFolders    = map[string]string{"folder-3": "", "folder-4": "folder-3", "folder-5": "", "folder-6": "folder-3"}
+	sharedFolderRows = map[string][]db.ListInheritedFolderSharesRow{
+		"folder-3": {{Username: "dave", Role: "editor"}},
+		"folder-5": {{Username: "dave", Role: "editor"}, {Username: "erin", Role: "viewer"}},
+	}
+)
+
+func (q *sharedFolderQuerier) GetDocumentByID(ctx context.Context, id string) (db.Document, error) {
+	if id == sharedDocument.ID {
+		return sharedDocument, nil
+	}
+	return q.authzQuerier.GetDocumentByID(ctx, id)
+}
+
+func (q *sharedFolderQuerier) GetFolderByID(ctx context.Context, id string) (db.Folder, error) {
+	parent, ok := sharedFolders[id]
+	if !ok {
+		return q.authzQuerier.GetFolderByID(ctx, id)
+	}
+	return db.Folder{ID: id, UserID: "alice", ParentID: pgtype.Text{String: parent, Valid: parent != ""}}, nil
+}
+
+// ListInheritedFolderShares returns the shares of id and its ancestors.
+func (q *sharedFolderQuerier) ListInheritedFolderShares(ctx context.Context, id string) ([]db.ListInheritedFolderSharesRow, error) {
+	var rows []db.ListInheritedFolderSharesRow
+	for ; id != ""; id = sharedFolders[id] {
+		rows = append(rows, sharedFolderRows[id]...)
+	}
+	return rows, nil
+}
+
+func (q *sharedFolderQuerier) ListDocumentGrants(ctx context.Context, arg db.ListDocumentGrantsParams) ([]string, error) {
+	if arg.DocumentID != sharedDocument.ID {
+		return q.authzQuerier.ListDocumentGrants(ctx, arg)
+	}
+	return q.ListFolderGrants(ctx, db.ListFolderGrantsParams{FolderID: arg.FolderID.String, Username: arg.Username})
+}
+
+func (q *sharedFolderQuerier) ListFolderGrants(ctx context.Context, arg db.ListFolderGrantsParams) ([]string, error) {
+	rows, _ := q.ListInheritedFolderShares(ctx, arg.FolderID)
+	var roles []string
+	for _, row := range rows {
+		if row.Username == arg.Username {
+			roles = append(roles, row.Role)
+		}
+	}
+	return roles, nil
+}
+
+func (q *sharedFolderQuerier) IsFolderWithin(ctx context.Context, arg db.IsFolderWithinParams) (bool, error) {
+	for id := arg.FolderID; id != ""; id = sharedFolders[id] {
+		if id == arg.AncestorID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (q *sharedFolderQuerier) SetDocumentFolder(ctx context.Context, arg db.SetDocumentFolderParams) (db.Document, error) {
+	document := sharedDocument
+	document.FolderID = arg.FolderID
+	return document, nil
+}
+
+func (q *sharedFolderQuerier) MoveFolder(ctx context.Context, arg db.MoveFolderParams) (db.Folder, error) {
+	return db.Folder{ID: arg.ID, UserID: "alice", ParentID: arg.ParentID}, nil
+}
+
+// TestSharedMoveAuthorization checks editors given access by a folder share
+// only move documents and folders where the same users keep the same
+// access, while the owner moves them anywhere.
+func TestSharedMoveAuthorization(t *testing.T) {
+	server, _ := newAuthzTestServer(t)
+	server.queries = &sharedFolderQuerier{}
+	server.policy = authz.NewPolicy(server.queries)
+
+	testCases := []struct {
+		name     string
+		username string
+		path     string
+		body     string
+		status   int
+	}{
+		{"DocumentWithinShare", "dave", "/documents/doc-3/move", `{"folder_id": "folder-4"}`, http.StatusOK},
+		{"DocumentToTop", "dave", "/documents/doc-3/move", `{"folder_id": null}`, http.StatusForbidden},
+		{"DocumentToOtherShare", "dave", "/documents/doc-3/move", `{"folder_id": "folder-5"}`, http.StatusForbidden},
+		{"DocumentByOwner", "alice", "/documents/doc-3/move", `{"folder_id": null}`, http.StatusOK},
+		{"FolderWithinShare", "dave", "/folders/folder-6/move", `{"parent_id": "folder-4"}`, http.StatusOK},
+		{"FolderToTop", "dave", "/folders/folder-6/move", `{"parent_id": null}`, http.StatusForbidden},
+		{"FolderToOtherShare", "dave", "/folders/folder-6/move", `{"parent_id": "folder-5"}`, http.StatusForbidden},
+		{"FolderByOwner", "alice", "/folders/folder-6/move", `{"parent_id": "folder-5"}`, http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := server.testRequest(t, http.MethodPost, tc.path, tc.username, strings.NewReader(tc.body))
+			require.Equal(t, tc.status, recorder.Code, recorder.Body.String())
+			if tc.status == http.StatusForbidden {
+				require.Equal(t, errMoveChangesAccess.Error(), errorMessage(t, recorder))
+			}
+		})
+	}
+}
+
+func TestListDocumentsAuthorization(t *testing.T) {
+	server, queries := newAuthzTestServer(t)
+
+	// Users list their own documents, whatever they ask for.
+	recorder := server.testRequest(t, http.MethodGet, "/documents?user_id=alice&username=alice", "bob", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "bob", queries.listedUser)
+}
+
+// TestShareLinkTokenScope checks link tokens and access tokens can't stand
+// in for one another.
+func TestShareLinkTokenScope(t *testing.T) {
+	server, _ := newAuthzTestServer(t)
+
+	linkToken, _, err := server.tokenMaker.CreatePurposeToken("alice", token.PurposeShareLink, time.Minute)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodGet, "/documents/doc-1", nil)
+	req.Header.Set(authorizationHeaderKey, "Bearer "+linkToken)
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	accessToken, _, err := server.tokenMaker.CreateToken("alice", time.Minute)
+	require.NoError(t, err)
+	recorder = server.testRequest(t, http.MethodGet, "/s/"+accessToken, "", nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	require.Equal(t, errShareLinkNotFound.Error(), errorMessage(t, recorder))
+}
+
+// TestLiveTokenAuthentication checks browsers can open live result sockets
+// with a live token in the URL, and live tokens open nothing else.
+func TestLiveTokenAuthentication(t *testing.T) {
+	server, _ := newAuthzTestServer(t)
+
+	recorder := server.testRequest(t, http.MethodPost, "/tokens/live", "alice", nil)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var rsp liveTokenResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.WithinDuration(t, time.Now().Add(liveTokenDuration), rsp.ExpiresAt, 5*time.Second)
+
+	// The owner gets past authorization, whatever happens next.
+	recorder = server.testRequest(t, http.MethodGet, "/documents/doc-1/live?token="+rsp.Token, "", nil)
+	require.NotEqual(t, http.StatusUnauthorized, recorder.Code)
+	require.NotEqual(t, authz.ErrNoAccess.Error(), errorMessage(t, recorder))
+
+	bobToken, _, err := server.tokenMaker.CreatePurposeToken("bob", token.PurposeLive, time.Minute)
+	require.NoError(t, err)
+	recorder = server.testRequest(t, http.MethodGet, "/documents/doc-1/live?token="+bobToken, "", nil)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	accessToken, _, err := server.tokenMaker.CreateToken("alice", time.Minute)
+	require.NoError(t, err)
+	recorder = server.testRequest(t, http.MethodGet, "/documents/doc-1/live?token="+accessToken, "", nil)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/documents/doc-1", nil)
+	req.Header.Set(authorizationHeaderKey, "Bearer "+rsp.Token)
+	recorder = httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
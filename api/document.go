@@ -1,228 +1,596 @@
 package api
 
 import (
-	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"os"
-	"os/exec"
-	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/otiai10/gosseract"
 
+	"github.com/yosa/ocr-golang-back/authz"
 	"github.com/yosa/ocr-golang-back/db"
+	"github.com/yosa/ocr-golang-back/ocr"
+	"github.com/yosa/ocr-golang-back/preprocess"
 	"github.com/yosa/ocr-golang-back/token"
 )
 
-// Cleanup only .png files matching the doc ID (safe and specific)
-func cleanupDocumentPNGs(docID string) {
-	uploadDir := "uploads"
-	pattern := filepath.Join(uploadDir, fmt.Sprintf("%s_page-*.png", docID))
+func (s *Server) UploadDocument(ctx *gin.Context) {
+	// 1. Authenticated user
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	// 2. Upload file, no bigger than the configured limit
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, s.maxUploadSize())
+	file, header, err := ctx.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			err := fmt.Errorf("upload is larger than %d bytes", tooLarge.Limit)
+			ctx.JSON(http.StatusRequestEntityTooLarge, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	defer file.Close()
+
+	// 3. Detect the real file type from its content
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("failed to read file: %w", err)))
+		return
+	}
+	fileType := detectFileType(head[:n])
+	if fileType == "" {
+		err := fmt.Errorf(
+			"unsupported file type %s: upload a PDF, PNG, JPEG or TIFF file",
+			http.DetectContentType(head[:n]),
+		)
+		ctx.JSON(http.StatusUnsupportedMediaType, errorResponse(err))
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// 4. OCR languages, detected by the worker when not given, and image
+	// preprocessing
+	languages, ok := s.uploadLanguages(ctx)
+	if !ok {
+		return
+	}
+	steps, err := uploadPreprocessSteps(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	docID := uuid.New().String()
+	storageKey := originalKey(docID, fileType)
 
-	files, err := filepath.Glob(pattern)
+	// 5. Keep the original in blob storage
+	if err := s.blobs.Put(ctx, storageKey, file, header.Size, fileType); err != nil {
+		ctx.JSON(
+			http.StatusInternalServerError,
+			errorResponse(fmt.Errorf("failed to save file: %w", err)),
+		)
+		return
+	}
+
+	// 6. Create document record
+	_, err = s.queries.CreateDocument(ctx, db.CreateDocumentParams{
+		ID:         docID,
+		UserID:     authPayload.Username,
+		Filename:   pgtype.Text{String: header.Filename, Valid: true},
+		FileType:   pgtype.Text{String: fileType, Valid: true},
+		Languages:  languages,
+		Preprocess: steps,
+		StorageKey: pgtype.Text{String: storageKey, Valid: true},
+	})
 	if err != nil {
-		log.Printf("Error finding PNGs for cleanup: %v", err)
+		s.blobs.Delete(ctx, storageKey)
+		ctx.JSON(
+			http.StatusInternalServerError,
+			errorResponse(fmt.Errorf("failed to store document: %w", err)),
+		)
 		return
 	}
 
-	for _, file := range files {
-		if err := os.Remove(file); err != nil {
-			log.Printf("Failed to delete %s: %v", file, err)
-		} else {
-			log.Printf("Deleted: %s", file)
+	// 7. Queue OCR, the worker pool picks it up from here
+	job, err := s.enqueueOCRJob(ctx, docID)
+	if err != nil {
+		// Nothing would ever process the document, it goes like it came.
+		if err := s.queries.DeleteDocument(ctx, docID); err != nil {
+			log.Printf("Failed to delete unqueued document %s: %v", docID, err)
 		}
+		s.blobs.Delete(ctx, storageKey)
+		ctx.JSON(
+			http.StatusInternalServerError,
+			errorResponse(fmt.Errorf("failed to queue OCR job: %w", err)),
+		)
+		return
 	}
+
+	// 8. Return accepted
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"document_id": docID,
+		"job_id":      job.ID,
+		"status":      job.Status,
+		"languages":   languages,
+		"preprocess":  steps,
+		"message":     "Document uploaded and queued for text extraction",
+	})
 }
 
-func extractTextFromPDFWithOCR(ctx context.Context, pdfPath, docID string) (string, error) {
-	if _, err := os.Stat(pdfPath); err != nil {
-		return "", fmt.Errorf("PDF file not found: %w", err)
+// maxUploadSize is how large an upload request may be, 100 MiB when not set.
+func (s *Server) maxUploadSize() int64 {
+	if s.config.MaxUploadSize <= 0 {
+		return 100 << 20
 	}
+	return s.config.MaxUploadSize
+}
 
-	outputPrefix := filepath.Join("uploads", fmt.Sprintf("%s_page", docID))
+// uploadLanguages returns the languages named by the optional languages form
+// field, as "eng+fra". None means the worker detects them. On failure the
+// error response is already written and ok is false.
+func (s *Server) uploadLanguages(ctx *gin.Context) (languages []string, ok bool) {
+	languages = ocr.ParseLanguages(ctx.PostForm("languages"))
+	if len(languages) == 0 {
+		return languages, true
+	}
 
-	// Add timeout for PDF conversion
-	convertCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
-	defer cancel()
+	if err := ocr.ValidateLanguages(s.engine, languages); err != nil {
+		if errors.Is(err, ocr.ErrUnsupportedLanguage) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return nil, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return nil, false
+	}
+	return languages, true
+}
 
-	cmd := exec.CommandContext(convertCtx, "pdftoppm", "-png", pdfPath, outputPrefix)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
+// uploadPreprocessSteps reads the optional preprocess form field, steps as
+// "deskew,binarize" or "none". Uploads without it get the default steps.
+func uploadPreprocessSteps(ctx *gin.Context) ([]string, error) {
+	field, ok := ctx.GetPostForm("preprocess")
 
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf(
-			"failed to convert pdf to images: %w (stderr: %s)",
-			err,
-			stderr.String(),
-		)
+	steps := preprocess.DefaultSteps
+	if ok {
+		var err error
+		steps, err = preprocess.ParseSteps(field)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// Find all generated images
-	images, err := filepath.Glob(fmt.Sprintf("%s-*.png", outputPrefix))
+	names := make([]string, len(steps))
+	for i, step := range steps {
+		names[i] = string(step)
+	}
+	return names, nil
+}
+
+// authorizeDocument loads the document named by the :id route parameter and
+// checks the authenticated user may take action on it. On failure the error
+// response is already written and ok is false.
+func (s *Server) authorizeDocument(ctx *gin.Context, action authz.Action) (document db.Document, ok bool) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	document, err := s.queries.GetDocumentByID(ctx, ctx.Param("id"))
 	if err != nil {
-		return "", fmt.Errorf("failed to find images: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("document not found")))
+			return document, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return document, false
 	}
 
-	if len(images) == 0 {
-		return "", fmt.Errorf("no images generated from PDF")
+	if err := s.policy.AuthorizeDocument(ctx, authPayload.Username, document, action); err != nil {
+		ctx.JSON(authzStatus(err), errorResponse(err))
+		return document, false
 	}
+	return document, true
+}
 
-	client := gosseract.NewClient()
-	defer client.Close()
+// authzStatus is the status code of a refused authorization. Users without
+// access get 404, as if there was nothing there.
+func authzStatus(err error) int {
+	switch {
+	case errors.Is(err, authz.ErrNoAccess), errors.Is(err, authz.ErrNoFolderAccess):
+		return http.StatusNotFound
+	case errors.Is(err, authz.ErrForbidden):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
 
-	// Configure Tesseract for better results
-	client.SetLanguage("eng")
-	client.SetPageSegMode(gosseract.PSM_AUTO) // Add this
+type documentStatusResponse struct {
+	DocumentID     string       `json:"document_id"`
+	DocumentStatus string       `json:"document_status"`
+	FailedPages    []failedPage `json:"failed_pages,omitempty"`
+	JobID          string       `json:"job_id"`
+	PageNumber     *int32       `json:"page_number,omitempty"`
+	Status         string       `json:"status"`
+	Attempts       int32        `json:"attempts"`
+	Error          string       `json:"error,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	FinishedAt     *time.Time   `json:"finished_at,omitempty"`
+}
 
-	var allText bytes.Buffer
+type failedPage struct {
+	PageNumber int32  `json:"page_number"`
+	Error      string `json:"error"`
+}
 
-	for _, imgPath := range images {
-		// Clean up immediately after processing
-		defer os.Remove(imgPath)
+func (s *Server) GetDocumentStatus(ctx *gin.Context) {
+	document, ok := s.authorizeDocument(ctx, authz.ActionRead)
+	if !ok {
+		return
+	}
 
-		if err := client.SetImage(imgPath); err != nil {
-			return "", fmt.Errorf("failed to set image %s: %w", imgPath, err)
+	job, err := s.queries.GetLatestOCRJobByDocument(ctx, document.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("no OCR job for document")))
+			return
 		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
 
-		text, err := client.Text()
-		if err != nil {
-			// Consider: should one page failure fail the whole document?
-			// Or log and continue?
-			return "", fmt.Errorf("ocr error on %s: %w", imgPath, err)
-		}
+	failed, err := s.queries.ListFailedDocumentPages(ctx, document.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
 
-		allText.WriteString(text)
-		allText.WriteString("\n\n")
+	rsp := documentStatusResponse{
+		DocumentID:     document.ID,
+		DocumentStatus: document.Status,
+		JobID:          job.ID,
+		Status:         job.Status,
+		Attempts:       job.Attempts,
+		Error:          job.Error.String,
+		CreatedAt:      job.CreatedAt.Time,
+		UpdatedAt:      job.UpdatedAt.Time,
+	}
+	for _, page := range failed {
+		rsp.FailedPages = append(rsp.FailedPages, failedPage{PageNumber: page.PageNumber, Error: page.Error.String})
+	}
+	if job.PageNumber.Valid {
+		rsp.PageNumber = &job.PageNumber.Int32
+	}
+	if job.FinishedAt.Valid {
+		rsp.FinishedAt = &job.FinishedAt.Time
 	}
+	ctx.JSON(http.StatusOK, rsp)
+}
 
-	return strings.TrimSpace(allText.String()), nil
+type documentResponse struct {
+	db.Document
+	// Text is the extracted text, null until OCR is done.
+	Text *string  `json:"text"`
+	Tags []string `json:"tags"`
 }
 
-func (s *Server) UploadDocument(ctx *gin.Context) {
-	// 1. Authenticated user
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+// GetDocument returns a document along with its extracted text and tags.
+func (s *Server) GetDocument(ctx *gin.Context) {
+	document, ok := s.authorizeDocument(ctx, authz.ActionRead)
+	if !ok {
+		return
+	}
 
-	// 2. Upload file
-	file, header, err := ctx.Request.FormFile("file")
+	rsp := documentResponse{Document: document}
+	extracted, err := s.queries.GetLatestExtractedTextByDocument(ctx, document.ID)
+	switch {
+	case err == nil:
+		rsp.Text = &extracted.Content.String
+	case !errors.Is(err, pgx.ErrNoRows):
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp.Tags, err = s.queries.ListDocumentTags(ctx, document.ID)
 	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rsp.Tags == nil {
+		rsp.Tags = []string{}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// maxMetadataSize bounds the metadata a document can carry, in bytes of JSON.
+const maxMetadataSize = 16 << 10
+
+type updateDocumentRequest struct {
+	Filename *string `json:"filename" binding:"omitempty,min=1,max=255"`
+	// Metadata replaces the document metadata, it must be a JSON object.
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+// UpdateDocument renames a document and/or replaces its metadata. Fields
+// left out are kept.
+func (s *Server) UpdateDocument(ctx *gin.Context) {
+	var req updateDocumentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
-	defer file.Close()
+	if string(req.Metadata) == "null" {
+		req.Metadata = nil
+	}
+	if req.Filename == nil && req.Metadata == nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("nothing to update, give a filename or metadata")))
+		return
+	}
+	if req.Metadata != nil {
+		if err := validateMetadata(req.Metadata); err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
 
-	docID := uuid.New().String()
-	uploadDir := "uploads"
-	uploadPath := filepath.Join(uploadDir, fmt.Sprintf("%s.pdf", docID))
+	document, ok := s.authorizeDocument(ctx, authz.ActionEdit)
+	if !ok {
+		return
+	}
 
-	// 3. Ensure upload folder exists
-	if err := os.MkdirAll(uploadDir, 0o755); err != nil {
-		ctx.JSON(
-			http.StatusInternalServerError,
-			errorResponse(fmt.Errorf("failed to create upload dir: %w", err)),
-		)
+	arg := db.UpdateDocumentParams{ID: document.ID, Metadata: req.Metadata}
+	if req.Filename != nil {
+		arg.Filename = pgtype.Text{String: *req.Filename, Valid: true}
+	}
+	document, err := s.queries.UpdateDocument(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, document)
+}
+
+// validateMetadata checks metadata is a JSON object of reasonable size.
+func validateMetadata(metadata json.RawMessage) error {
+	if len(metadata) > maxMetadataSize {
+		return fmt.Errorf("metadata is larger than %d bytes", maxMetadataSize)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(metadata, &fields); err != nil || fields == nil {
+		return fmt.Errorf("metadata must be a JSON object")
+	}
+	return nil
+}
+
+// DeleteDocument deletes a document, everything stored for it in the
+// database and its files. Documents being processed can't be deleted, the
+// worker would write their files back.
+func (s *Server) DeleteDocument(ctx *gin.Context) {
+	document, ok := s.authorizeDocument(ctx, authz.ActionDelete)
+	if !ok {
 		return
 	}
 
-	// 4. Save PDF
-	outFile, err := os.Create(uploadPath)
+	pages, err := s.queries.ListDocumentPages(ctx, document.ID)
 	if err != nil {
-		ctx.JSON(
-			http.StatusInternalServerError,
-			errorResponse(fmt.Errorf("failed to save file: %w", err)),
-		)
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	defer outFile.Close()
 
-	if _, err := io.Copy(outFile, file); err != nil {
-		ctx.JSON(
-			http.StatusInternalServerError,
-			errorResponse(fmt.Errorf("failed to write file: %w", err)),
-		)
+	// Checked by the delete itself, a job queued since can't slip through.
+	deleted, err := s.queries.DeleteIdleDocument(ctx, document.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if deleted == 0 {
+		ctx.JSON(http.StatusConflict, errorResponse(errDocumentBusy))
 		return
 	}
 
-	// 5. Create document record
-	_, err = s.queries.CreateDocument(ctx, db.CreateDocumentParams{
-		ID:       docID,
-		UserID:   authPayload.Username,
-		Filename: pgtype.Text{String: header.Filename, Valid: true},
-		FileType: pgtype.Text{String: header.Header.Get("Content-Type"), Valid: true},
+	// The document is gone already, files left behind are only logged.
+	s.deleteDocumentFiles(ctx, document, pages)
+	ctx.Status(http.StatusNoContent)
+}
+
+// deleteDocumentFiles removes the original, previews and page images of a
+// document.
+func (s *Server) deleteDocumentFiles(ctx context.Context, document db.Document, pages []db.DocumentPage) {
+	keys := []string{thumbnailKey(document.ID)}
+	if document.StorageKey.Valid {
+		keys = append(keys, document.StorageKey.String)
+	}
+	for _, page := range pages {
+		keys = append(keys, previewKey(document.ID, int(page.PageNumber)))
+		if page.ImageKey.Valid && !legacyPageKey(page.ImageKey.String) {
+			keys = append(keys, page.ImageKey.String)
+		}
+	}
+	for _, key := range keys {
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete %s of document %s: %v", key, document.ID, err)
+		}
+	}
+
+	removeUpload(document.ID)
+	cleanupPageImages(document.ID)
+}
+
+type documentPageRequest struct {
+	PageNumber int32 `uri:"n" binding:"required,min=1"`
+}
+
+func (s *Server) GetDocumentPage(ctx *gin.Context) {
+	var req documentPageRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	document, ok := s.authorizeDocument(ctx, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	page, err := s.queries.GetDocumentPage(ctx, db.GetDocumentPageParams{
+		DocumentID: document.ID,
+		PageNumber: req.PageNumber,
 	})
 	if err != nil {
-		ctx.JSON(
-			http.StatusInternalServerError,
-			errorResponse(fmt.Errorf("failed to store document: %w", err)),
-		)
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("page %d not found", req.PageNumber)))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	ctx.JSON(http.StatusOK, page)
+}
 
-	// 6. Extract OCR text
-	content, err := extractTextFromPDFWithOCR(ctx.Request.Context(), uploadPath, docID)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(fmt.Errorf("OCR failed: %w", err)))
+type documentPageWordsResponse struct {
+	DocumentID string     `json:"document_id"`
+	PageNumber int32      `json:"page_number"`
+	Width      int32      `json:"width"`
+	Height     int32      `json:"height"`
+	Words      []ocr.Word `json:"words"`
+}
+
+// GetDocumentPageWords returns the word boxes of a page, in pixels of the page
+// image, so clients can overlay them on it.
+func (s *Server) GetDocumentPageWords(ctx *gin.Context) {
+	var req documentPageRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
-	if content == "" {
-		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "No extractable text found in PDF"})
+
+	document, ok := s.authorizeDocument(ctx, authz.ActionRead)
+	if !ok {
 		return
 	}
 
-	// 7. Store OCR result
-	_, err = s.queries.CreateExtractedText(ctx, db.CreateExtractedTextParams{
-		ID:         uuid.New().String(),
-		DocumentID: docID,
-		Content:    pgtype.Text{String: content, Valid: true},
+	page, err := s.queries.GetDocumentPage(ctx, db.GetDocumentPageParams{
+		DocumentID: document.ID,
+		PageNumber: req.PageNumber,
 	})
 	if err != nil {
-		ctx.JSON(
-			http.StatusInternalServerError,
-			errorResponse(fmt.Errorf("failed to save extracted text: %w", err)),
-		)
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("page %d not found", req.PageNumber)))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rows, err := s.queries.ListDocumentPageWords(ctx, db.ListDocumentPageWordsParams{
+		DocumentID: document.ID,
+		PageNumber: req.PageNumber,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	// 8. Optional cleanup
-	go cleanupDocumentPNGs(docID) // clean .png
-	go os.Remove(uploadPath)      // clean the .pdf
+	words := make([]ocr.Word, len(rows))
+	for i, row := range rows {
+		words[i] = newOCRWord(row)
+	}
 
-	// 9. Return success
-	ctx.JSON(http.StatusOK, gin.H{
-		"document_id": docID,
-		"content":     content,
-		"message":     "Document uploaded and text extracted successfully",
+	ctx.JSON(http.StatusOK, documentPageWordsResponse{
+		DocumentID: document.ID,
+		PageNumber: page.PageNumber,
+		Width:      page.Width,
+		Height:     page.Height,
+		Words:      words,
 	})
 }
 
-type fetchDocumentsRequest struct {
-	Username string `json:"username" binding:"required"`
-	Limit    int32  `json:"limit"    binding:"required"`
-	Offset   int32  `json:"offset"   binding:"required"`
+func newOCRWord(row db.DocumentWord) ocr.Word {
+	return ocr.Word{
+		Text:       row.Text,
+		Line:       int(row.LineNumber),
+		X:          int(row.X),
+		Y:          int(row.Y),
+		Width:      int(row.Width),
+		Height:     int(row.Height),
+		Confidence: row.Confidence,
+	}
 }
 
-func (s *Server) FetchDocuments(ctx *gin.Context) {
-	var req fetchDocumentsRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
+// RetryDocumentPage queues a failed page to be OCR'd again from its stored
+// image. The document's text and status are updated once it succeeds. Like
+// any job, it's refused while the document has another queued or running.
+func (s *Server) RetryDocumentPage(ctx *gin.Context) {
+	var req documentPageRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	documents, err := s.queries.ListDocumentsByUser(ctx, db.ListDocumentsByUserParams{
-		UserID: req.Username,
-		Limit:  req.Limit,
-		Offset: req.Offset,
+	document, ok := s.authorizeDocument(ctx, authz.ActionEdit)
+	if !ok {
+		return
+	}
+
+	page, err := s.queries.GetDocumentPage(ctx, db.GetDocumentPageParams{
+		DocumentID: document.ID,
+		PageNumber: req.PageNumber,
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to Fetch Documents"})
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("page %d not found", req.PageNumber)))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if page.Status != pageStatusFailed {
+		ctx.JSON(http.StatusConflict, errorResponse(fmt.Errorf("page %d didn't fail", req.PageNumber)))
+		return
+	}
+	available := page.ImageKey.Valid
+	if available {
+		available, err = s.pageImageExists(ctx, page.ImageKey.String)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+	if !available {
+		err := fmt.Errorf("the image of page %d is no longer available", req.PageNumber)
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	job, err := s.enqueuePageOCRJob(ctx, document.ID, req.PageNumber)
+	if err != nil {
+		if errors.Is(err, errDocumentBusy) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(
+			http.StatusInternalServerError,
+			errorResponse(fmt.Errorf("failed to queue OCR job: %w", err)),
+		)
 		return
 	}
-	ctx.JSON(http.StatusOK, documents)
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"document_id": document.ID,
+		"page_number": req.PageNumber,
+		"job_id":      job.ID,
+		"status":      job.Status,
+	})
 }
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yosa/ocr-golang-back/db"
+	"github.com/yosa/ocr-golang-back/storage"
+)
+
+func TestValidateMetadata(t *testing.T) {
+	require.NoError(t, validateMetadata(json.RawMessage(`{}`)))
+	require.NoError(t, validateMetadata(json.RawMessage(`{"client": "acme", "tags": ["q1"], "year": 2024}`)))
+
+	for _, metadata := range []string{`[]`, `"text"`, `42`, `null`, `{"broken"`} {
+		require.Error(t, validateMetadata(json.RawMessage(metadata)), metadata)
+	}
+
+	large := `{"notes": "` + strings.Repeat("x", maxMetadataSize) + `"}`
+	require.Error(t, validateMetadata(json.RawMessage(large)))
+}
+
+// uploadTestFile uploads content as alice.
+func uploadTestFile(t *testing.T, server *Server, content string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "scan.pdf")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/documents/upload", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	accessToken, _, err := server.tokenMaker.CreateToken("alice", time.Minute)
+	require.NoError(t, err)
+	req.Header.Set(authorizationHeaderKey, "Bearer "+accessToken)
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestUploadDocumentTooLarge(t *testing.T) {
+	server, _ := newAuthzTestServer(t)
+	server.config.MaxUploadSize = 1024
+
+	recorder := uploadTestFile(t, server, "%PDF-1.4 "+strings.Repeat("x", 4096))
+	require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	require.Equal(t, "upload is larger than 1024 bytes", errorMessage(t, recorder))
+}
+
+// unqueuedQuerier stores documents but fails to queue their OCR job.
+type unqueuedQuerier struct {
+	authzQuerier
+	created, deleted []string
+}
+
+func (q *unqueuedQuerier) CreateDocument(ctx context.Context, arg db.CreateDocumentParams) (db.Document, error) {
+	q.created = append(q.created, arg.ID)
+	return db.Document{ID: arg.ID, UserID: arg.UserID, StorageKey: arg.StorageKey}, nil
+}
+
+func (q *unqueuedQuerier) CreateOCRJob(ctx context.Context, arg db.CreateOCRJobParams) (db.OcrJob, error) {
+	return db.OcrJob{}, errors.New("connection reset")
+}
+
+func (q *unqueuedQuerier) DeleteDocument(ctx context.Context, id string) error {
+	q.deleted = append(q.deleted, id)
+	return nil
+}
+
+func TestUploadDocumentQueueFailure(t *testing.T) {
+	server, _ := newAuthzTestServer(t)
+	queries := &unqueuedQuerier{}
+	server.queries = queries
+	dir := t.TempDir()
+	blobs, err := storage.NewLocal(dir)
+	require.NoError(t, err)
+	server.blobs = blobs
+
+	recorder := uploadTestFile(t, server, "%PDF-1.4 scanned invoice")
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	// Neither the document nor its original are left behind.
+	require.Len(t, queries.created, 1)
+	require.Equal(t, queries.created, queries.deleted)
+	_, err = blobs.Get(context.Background(), originalKey(queries.created[0], fileTypePDF))
+	require.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+// busyQuerier knows aliceDocument with a failed page 2 whose image is stored,
+// while another job of the document is queued.
+type busyQuerier struct {
+	authzQuerier
+}
+
+func (q *busyQuerier) GetDocumentPage(ctx context.Context, arg db.GetDocumentPageParams) (db.DocumentPage, error) {
+	return db.DocumentPage{
+		DocumentID: arg.DocumentID,
+		PageNumber: arg.PageNumber,
+		Status:     pageStatusFailed,
+		ImageKey:   pgtype.Text{String: pageKey(arg.DocumentID, int(arg.PageNumber), ".png"), Valid: true},
+	}, nil
+}
+
+func (q *busyQuerier) CreateOCRJob(ctx context.Context, arg db.CreateOCRJobParams) (db.OcrJob, error) {
+	return db.OcrJob{}, pgx.ErrNoRows
+}
+
+func (q *busyQuerier) ListDocumentPages(ctx context.Context, documentID string) ([]db.DocumentPage, error) {
+	return nil, nil
+}
+
+func (q *busyQuerier) DeleteIdleDocument(ctx context.Context, id string) (int64, error) {
+	return 0, nil
+}
+
+func TestDocumentBusy(t *testing.T) {
+	server, _ := newAuthzTestServer(t)
+	server.queries = &busyQuerier{}
+	blobs, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	server.blobs = blobs
+	image := "png"
+	err = blobs.Put(context.Background(), pageKey(aliceDocument.ID, 2, ".png"), strings.NewReader(image), int64(len(image)), "image/png")
+	require.NoError(t, err)
+
+	recorder := server.testRequest(t, http.MethodPost, "/documents/doc-1/pages/2/retry", "alice", nil)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+	require.Equal(t, errDocumentBusy.Error(), errorMessage(t, recorder))
+
+	recorder = server.testRequest(t, http.MethodDelete, "/documents/doc-1", "alice", nil)
+	require.Equal(t, http.StatusConflict, recorder.Code)
+	require.Equal(t, errDocumentBusy.Error(), errorMessage(t, recorder))
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+
+	"github.com/yosa/ocr-golang-back/db"
+)
+
+// Resolutions outside this range are taken for placeholders rather than the
+// scan's: many tools write 72 DPI whatever the image.
+const (
+	minPlausibleDPI = 100
+	maxPlausibleDPI = 2400
+)
+
+// uploadDPI returns the resolution the page images of an upload have: the
+// rendering resolution for PDFs, the one in their metadata for images, or 0
+// when they don't say.
+func uploadDPI(uploadPath, fileType string) int {
+	if fileType == fileTypePDF || fileType == "" {
+		return renderDPI
+	}
+
+	file, err := os.Open(uploadPath)
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	var dpi float64
+	switch fileType {
+	case fileTypePNG:
+		dpi = pngDPI(bufio.NewReader(file))
+	case fileTypeJPEG:
+		dpi = jpegDPI(bufio.NewReader(file))
+	case fileTypeTIFF:
+		dpi = tiffDPI(file)
+	}
+
+	dpi = math.Round(dpi)
+	if dpi < minPlausibleDPI || dpi > maxPlausibleDPI {
+		return 0
+	}
+	return int(dpi)
+}
+
+// documentDPI returns the resolution of the page images of document, 0 when
+// unknown. Documents read before it was stored only know it for PDFs.
+func documentDPI(document db.Document) float64 {
+	if document.Dpi.Valid {
+		return float64(document.Dpi.Int32)
+	}
+	if fileType := document.FileType.String; fileType == fileTypePDF || fileType == "" {
+		return renderDPI
+	}
+	return 0
+}
+
+// pngDPI reads the pHYs chunk of a PNG, which gives pixels per meter.
+func pngDPI(r io.Reader) float64 {
+	var signature [8]byte
+	if _, err := io.ReadFull(r, signature[:]); err != nil {
+		return 0
+	}
+
+	// pHYs comes before the image data, when there is one.
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return 0
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		switch string(header[4:]) {
+		case "pHYs":
+			var phys [9]byte
+			if length != 9 {
+				return 0
+			}
+			if _, err := io.ReadFull(r, phys[:]); err != nil {
+				return 0
+			}
+			const unitMeter = 1
+			if phys[8] != unitMeter {
+				return 0
+			}
+			return float64(binary.BigEndian.Uint32(phys[:4])) * 0.0254
+		case "IDAT", "IEND":
+			return 0
+		}
+		// Skip the data and the CRC.
+		if _, err := io.CopyN(io.Discard, r, int64(length)+4); err != nil {
+			return 0
+		}
+	}
+}
+
+// jpegDPI reads the density of the JFIF segment of a JPEG.
+func jpegDPI(r io.Reader) float64 {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil || soi != [2]byte{0xff, 0xd8} {
+		return 0
+	}
+
+	for {
+		var marker [4]byte
+		if _, err := io.ReadFull(r, marker[:]); err != nil || marker[0] != 0xff {
+			return 0
+		}
+		length := int64(binary.BigEndian.Uint16(marker[2:])) - 2
+		if length < 0 {
+			return 0
+		}
+
+		switch marker[1] {
+		case 0xe0: // APP0
+			segment := make([]byte, length)
+			if _, err := io.ReadFull(r, segment); err != nil {
+				return 0
+			}
+			// "JFIF\0", version, units, X density, Y density.
+			if len(segment) < 12 || !bytes.HasPrefix(segment, []byte("JFIF\x00")) {
+				continue
+			}
+			density := float64(binary.BigEndian.Uint16(segment[8:10]))
+			switch segment[7] {
+			case 1: // dots per inch
+				return density
+			case 2: // dots per cm
+				return density * 2.54
+			}
+			return 0
+		case 0xda: // start of scan, no more headers
+			return 0
+		}
+		if _, err := io.CopyN(io.Discard, r, length); err != nil {
+			return 0
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yosa/ocr-golang-back/db"
+)
+
+// pngWithDPI encodes a PNG with a pHYs chunk giving dpi.
+func pngWithDPI(t *testing.T, dpi float64) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, grayPage(4, 4, 255)))
+	data := buf.Bytes()
+
+	var chunk bytes.Buffer
+	binary.Write(&chunk, binary.BigEndian, uint32(9))
+	chunk.WriteString("pHYs")
+	perMeter := uint32(dpi/0.0254 + 0.5)
+	binary.Write(&chunk, binary.BigEndian, perMeter)
+	binary.Write(&chunk, binary.BigEndian, perMeter)
+	chunk.WriteByte(1) // meters
+	binary.Write(&chunk, binary.BigEndian, crc32.ChecksumIEEE(chunk.Bytes()[4:]))
+
+	// After the signature and IHDR, which is 25 bytes long.
+	end := 8 + 25
+	return append(append(append([]byte{}, data[:end]...), chunk.Bytes()...), data[end:]...)
+}
+
+// jpegWithDPI encodes a JPEG with a JFIF segment giving density in unit.
+func jpegWithDPI(t *testing.T, unit byte, density uint16) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, grayPage(4, 4, 255), nil))
+	data := buf.Bytes()
+
+	var app0 bytes.Buffer
+	app0.Write([]byte{0xff, 0xe0, 0x00, 0x10})
+	app0.WriteString("JFIF\x00")
+	app0.Write([]byte{1, 2, unit})
+	binary.Write(&app0, binary.BigEndian, density)
+	binary.Write(&app0, binary.BigEndian, density)
+	app0.Write([]byte{0, 0})
+
+	return append(append(append([]byte{}, data[:2]...), app0.Bytes()...), data[2:]...)
+}
+
+// tiffWithDPI writes the IFD of a TIFF giving its resolution and unit.
+func tiffWithDPI(order binary.ByteOrder, num, den uint32, unit uint16) []byte {
+	var buf bytes.Buffer
+	if order == binary.LittleEndian {
+		buf.WriteString("II*\x00")
+	} else {
+		buf.WriteString("MM\x00*")
+	}
+	binary.Write(&buf, order, uint32(8))
+
+	// Two entries and the next IFD offset, then the rational.
+	rational := uint32(8 + 2 + 2*12 + 4)
+	binary.Write(&buf, order, uint16(2))
+	binary.Write(&buf, order, []uint16{tiffTagXResolution, tiffTypeRational})
+	binary.Write(&buf, order, []uint32{1, rational})
+	binary.Write(&buf, order, []uint16{tiffTagResolutionUnit, tiffTypeShort})
+	binary.Write(&buf, order, uint32(1))
+	binary.Write(&buf, order, []uint16{unit, 0})
+	binary.Write(&buf, order, uint32(0))
+	binary.Write(&buf, order, []uint32{num, den})
+	return buf.Bytes()
+}
+
+func TestUploadDPI(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fileType string
+		data     []byte
+		want     int
+	}{
+		{"pdf", fileTypePDF, nil, renderDPI},
+		{"png", fileTypePNG, pngWithDPI(t, 300), 300},
+		{"png without pHYs", fileTypePNG, func() []byte {
+			var buf bytes.Buffer
+			require.NoError(t, png.Encode(&buf, grayPage(4, 4, 255)))
+			return buf.Bytes()
+		}(), 0},
+		{"png at 72 DPI", fileTypePNG, pngWithDPI(t, 72), 0},
+		{"jpeg", fileTypeJPEG, jpegWithDPI(t, 1, 200), 200},
+		{"jpeg in dots per cm", fileTypeJPEG, jpegWithDPI(t, 2, 118), 300},
+		{"jpeg aspect ratio only", fileTypeJPEG, jpegWithDPI(t, 0, 1), 0},
+		{"tiff little endian", fileTypeTIFF, tiffWithDPI(binary.LittleEndian, 600, 1, 2), 600},
+		{"tiff big endian", fileTypeTIFF, tiffWithDPI(binary.BigEndian, 300, 1, 2), 300},
+		{"tiff in centimeters", fileTypeTIFF, tiffWithDPI(binary.LittleEndian, 236220, 2000, 3), 300},
+		{"tiff without unit", fileTypeTIFF, tiffWithDPI(binary.LittleEndian, 300, 1, 1), 0},
+		{"tiff zero denominator", fileTypeTIFF, tiffWithDPI(binary.LittleEndian, 300, 0, 2), 0},
+		{"truncated", fileTypeTIFF, []byte("II*\x00"), 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "upload")
+			require.NoError(t, os.WriteFile(path, tc.data, 0o644))
+			require.Equal(t, tc.want, uploadDPI(path, tc.fileType))
+		})
+	}
+}
+
+func TestUploadDPIDecodes(t *testing.T) {
+	// The inserted segments must leave the images readable.
+	for _, data := range [][]byte{pngWithDPI(t, 300), jpegWithDPI(t, 1, 300)} {
+		_, _, err := image.Decode(bytes.NewReader(data))
+		require.NoError(t, err)
+	}
+}
+
+func TestDocumentDPI(t *testing.T) {
+	fileType := func(s string) pgtype.Text { return pgtype.Text{String: s, Valid: s != ""} }
+
+	testCases := []struct {
+		name     string
+		document db.Document
+		want     float64
+	}{
+		{"stored", db.Document{FileType: fileType(fileTypePNG), Dpi: pgtype.Int4{Int32: 300, Valid: true}}, 300},
+		{"pdf", db.Document{FileType: fileType(fileTypePDF)}, renderDPI},
+		{"before file types", db.Document{}, renderDPI},
+		{"unknown", db.Document{FileType: fileType(fileTypeJPEG)}, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, documentDPI(tc.document))
+			require.Equal(t, tc.want, preprocessOptions(tc.document).SourceDPI)
+		})
+	}
+}
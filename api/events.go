@@ -0,0 +1,93 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/yosa/ocr-golang-back/authz"
+	"github.com/yosa/ocr-golang-back/db"
+)
+
+// eventsKeepAlive is how often an idle event stream gets a comment line, so
+// proxies don't close it while a long page is being read.
+const eventsKeepAlive = 15 * time.Second
+
+// StreamDocumentEvents follows the OCR of a document as Server-Sent Events:
+// converted, one page event per page read, then finished or failed, after
+// which the stream ends. Clients connecting mid-job first get the events
+// they missed, clients connecting once the job is over only get its outcome.
+// A client that lags too far behind is disconnected and may reconnect.
+func (s *Server) StreamDocumentEvents(ctx *gin.Context) {
+	document, ok := s.authorizeDocument(ctx, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	// Subscribing before reading the job means an event published in
+	// between isn't lost.
+	past, events, unsubscribe := s.progress.subscribe(document.ID)
+	defer unsubscribe()
+
+	job, err := s.queries.GetLatestOCRJobByDocument(ctx, document.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("no OCR job for document")))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	if len(past) == 0 && job.Status != "queued" && job.Status != "running" {
+		event := jobOutcomeEvent(document, job)
+		ctx.SSEvent(event.Type, event)
+		return
+	}
+	for _, event := range past {
+		ctx.SSEvent(event.Type, event)
+	}
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			ctx.SSEvent(event.Type, event)
+			return !event.final()
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case <-ctx.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// jobOutcomeEvent is the final event of a job that is over.
+func jobOutcomeEvent(document db.Document, job db.OcrJob) progressEvent {
+	event := progressEvent{
+		Type:       eventFinished,
+		DocumentID: document.ID,
+		Page:       int(job.PageNumber.Int32),
+		Status:     document.Status,
+	}
+	if job.Status == "failed" {
+		event.Type = eventFailed
+		event.Status = ""
+		event.Error = job.Error.String
+	}
+	return event
+}
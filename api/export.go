@@ -0,0 +1,202 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/yosa/ocr-golang-back/authz"
+	"github.com/yosa/ocr-golang-back/db"
+	"github.com/yosa/ocr-golang-back/export"
+	"github.com/yosa/ocr-golang-back/ocr"
+	"github.com/yosa/ocr-golang-back/searchablepdf"
+	"github.com/yosa/ocr-golang-back/storage"
+)
+
+type exportFormat struct {
+	contentType string
+	extension   string
+	write       func(w io.Writer, doc export.Document) error
+}
+
+var exportFormats = map[string]exportFormat{
+	"hocr": {"application/xhtml+xml; charset=utf-8", ".hocr", export.HOCR},
+	"alto": {"application/xml; charset=utf-8", ".alto.xml", export.ALTO},
+	"txt":  {"text/plain; charset=utf-8", ".txt", export.Text},
+	"json": {"application/json; charset=utf-8", ".json", export.JSON},
+}
+
+type exportDocumentRequest struct {
+	Format string `form:"format" binding:"required,oneof=hocr alto txt json"`
+}
+
+// listWordsByPage returns the stored words of a document grouped by page number.
+func (s *Server) listWordsByPage(ctx context.Context, docID string) (map[int32][]ocr.Word, error) {
+	words, err := s.queries.ListDocumentWords(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+
+	pageWords := make(map[int32][]ocr.Word)
+	for _, word := range words {
+		pageWords[word.PageNumber] = append(pageWords[word.PageNumber], newOCRWord(word))
+	}
+	return pageWords, nil
+}
+
+// attachmentDisposition names a download after the uploaded file, with its
+// extension swapped for ext.
+func attachmentDisposition(document db.Document, ext string) string {
+	name := strings.TrimSuffix(document.Filename.String, filepath.Ext(document.Filename.String))
+	if name == "" {
+		name = document.ID
+	}
+	return attachment(name + ext)
+}
+
+// loadExportDocument gathers everything stored for a processed document.
+func (s *Server) loadExportDocument(ctx *gin.Context, document db.Document) (export.Document, error) {
+	doc := export.Document{
+		ID:        document.ID,
+		Filename:  document.Filename.String,
+		Languages: document.Languages,
+	}
+
+	extracted, err := s.queries.GetLatestExtractedTextByDocument(ctx, document.ID)
+	if err != nil {
+		return doc, err
+	}
+	doc.Text = extracted.Content.String
+
+	pages, err := s.queries.ListDocumentPages(ctx, document.ID)
+	if err != nil {
+		return doc, err
+	}
+
+	pageWords, err := s.listWordsByPage(ctx, document.ID)
+	if err != nil {
+		return doc, err
+	}
+
+	for _, page := range pages {
+		doc.Pages = append(doc.Pages, export.Page{
+			Number:     int(page.PageNumber),
+			Width:      int(page.Width),
+			Height:     int(page.Height),
+			Text:       page.Text,
+			Confidence: page.Confidence,
+			Words:      pageWords[page.PageNumber],
+		})
+	}
+	return doc, nil
+}
+
+func (s *Server) ExportDocument(ctx *gin.Context) {
+	var req exportDocumentRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	document, ok := s.authorizeDocument(ctx, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	doc, err := s.loadExportDocument(ctx, document)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("document has no OCR results yet")))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	format := exportFormats[req.Format]
+	var out bytes.Buffer
+	if err := format.write(&out, doc); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Header("Content-Disposition", attachmentDisposition(document, format.extension))
+	ctx.Data(http.StatusOK, format.contentType, out.Bytes())
+}
+
+// GetSearchablePDF builds a PDF of the page images with the OCR text laid
+// invisibly over them.
+func (s *Server) GetSearchablePDF(ctx *gin.Context) {
+	document, ok := s.authorizeDocument(ctx, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	rows, err := s.queries.ListDocumentPages(ctx, document.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if len(rows) == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("document has no OCR results yet")))
+		return
+	}
+
+	pageWords, err := s.listWordsByPage(ctx, document.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// The PDF writer reads page images from disk, they are fetched from
+	// storage for the request.
+	dir, err := s.scratchDir()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	pages := make([]searchablepdf.Page, len(rows))
+	for i, row := range rows {
+		var imagePath string
+		err := storage.ErrNotFound
+		if row.ImageKey.Valid {
+			imagePath, err = s.fetchPageImage(ctx, row.ImageKey.String, dir)
+		}
+		if errors.Is(err, storage.ErrNotFound) {
+			err := fmt.Errorf("page images are not available for this document")
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		pages[i] = searchablepdf.Page{
+			ImagePath: imagePath,
+			Words:     pageWords[row.PageNumber],
+		}
+	}
+
+	ctx.Header("Content-Disposition", attachmentDisposition(document, ".searchable.pdf"))
+	ctx.Header("Content-Type", "application/pdf")
+	ctx.Status(http.StatusOK)
+
+	// Headers are gone by the time a page fails, all we can do is cut the
+	// response short.
+	if err := searchablepdf.Generate(ctx.Writer, pages, searchablepdf.Options{DPI: documentDPI(document)}); err != nil {
+		log.Printf("Failed to generate searchable PDF for %s: %v", document.ID, err)
+		ctx.Abort()
+	}
+}
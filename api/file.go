@@ -0,0 +1,85 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yosa/ocr-golang-back/authz"
+	"github.com/yosa/ocr-golang-back/db"
+	"github.com/yosa/ocr-golang-back/storage"
+)
+
+// DownloadDocumentFile sends the original upload of a document under its
+// uploaded filename. Range requests are supported, so large files can be
+// resumed or read in parts.
+func (s *Server) DownloadDocumentFile(ctx *gin.Context) {
+	document, ok := s.authorizeDocument(ctx, authz.ActionRead)
+	if !ok {
+		return
+	}
+	s.serveOriginal(ctx, document)
+}
+
+// serveOriginal sends the uploaded file of document as an attachment.
+func (s *Server) serveOriginal(ctx *gin.Context, document db.Document) {
+	file, ok := s.openOriginal(ctx, document)
+	if !ok {
+		return
+	}
+	defer file.Close()
+	sendOriginal(ctx, document, file)
+}
+
+// openOriginal opens the uploaded file of document. On failure the error
+// response is already written and ok is false.
+func (s *Server) openOriginal(ctx *gin.Context, document db.Document) (file io.ReadSeekCloser, ok bool) {
+	if !document.StorageKey.Valid {
+		err := fmt.Errorf("the original of this document wasn't kept")
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+		return nil, false
+	}
+
+	file, err := s.blobs.Get(ctx, document.StorageKey.String)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("original file not found")))
+			return nil, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return nil, false
+	}
+	return file, true
+}
+
+// sendOriginal sends file, the original of document, as an attachment.
+func sendOriginal(ctx *gin.Context, document db.Document, file io.ReadSeeker) {
+	filename := document.Filename.String
+	if filename == "" {
+		filename = document.ID + uploadExtension(document.FileType.String)
+	}
+	fileType := document.FileType.String
+	if fileType == "" {
+		fileType = fileTypePDF
+	}
+	serveFile(ctx, file, filename, fileType, document.UploadedAt.Time)
+}
+
+// attachment is the Content-Disposition of a download named filename. Names
+// that aren't plain ASCII are encoded as RFC 2231 says.
+func attachment(filename string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+}
+
+// serveFile sends content as a download named filename, answering range and
+// conditional requests.
+func serveFile(ctx *gin.Context, content io.ReadSeeker, filename, contentType string, modified time.Time) {
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", attachment(filename))
+	http.ServeContent(ctx.Writer, ctx.Request, filename, modified, content)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yosa/ocr-golang-back/db"
+)
+
+func serveTestFile(t *testing.T, filename string, header http.Header) *http.Response {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/documents/doc-1/file", nil)
+	for name, values := range header {
+		ctx.Request.Header[name] = values
+	}
+
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	serveFile(ctx, strings.NewReader("%PDF-1.4 scanned invoice"), filename, fileTypePDF, modified)
+	// gin writes bodiless responses once the handler returns.
+	ctx.Writer.WriteHeaderNow()
+	return recorder.Result()
+}
+
+func TestServeFile(t *testing.T) {
+	rsp := serveTestFile(t, "invoice.pdf", nil)
+	require.Equal(t, http.StatusOK, rsp.StatusCode)
+	require.Equal(t, fileTypePDF, rsp.Header.Get("Content-Type"))
+	require.Equal(t, `attachment; filename=invoice.pdf`, rsp.Header.Get("Content-Disposition"))
+	require.Equal(t, "bytes", rsp.Header.Get("Accept-Ranges"))
+	require.Equal(t, "24", rsp.Header.Get("Content-Length"))
+
+	body, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "%PDF-1.4 scanned invoice", string(body))
+}
+
+func TestServeFileRange(t *testing.T) {
+	rsp := serveTestFile(t, "invoice.pdf", http.Header{"Range": {"bytes=9-15"}})
+	require.Equal(t, http.StatusPartialContent, rsp.StatusCode)
+	require.Equal(t, "bytes 9-15/24", rsp.Header.Get("Content-Range"))
+
+	body, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "scanned", string(body))
+
+	rsp = serveTestFile(t, "invoice.pdf", http.Header{"Range": {"bytes=100-"}})
+	require.Equal(t, http.StatusRequestedRangeNotSatisfiable, rsp.StatusCode)
+}
+
+func TestAttachmentDisposition(t *testing.T) {
+	document := db.Document{ID: "doc-1", Filename: pgtype.Text{String: `réçu "mars".pdf`, Valid: true}}
+	require.Equal(t, `attachment; filename*=utf-8''r%C3%A9%C3%A7u%20%22mars%22.txt`, attachmentDisposition(document, ".txt"))
+
+	document.Filename = pgtype.Text{String: "scan 1.pdf", Valid: true}
+	require.Equal(t, `attachment; filename="scan 1.searchable.pdf"`, attachmentDisposition(document, ".searchable.pdf"))
+
+	document.Filename = pgtype.Text{}
+	require.Equal(t, `attachment; filename=doc-1.json`, attachmentDisposition(document, ".json"))
+}
+
+func TestServeFileNotModified(t *testing.T) {
+	rsp := serveTestFile(t, "invoice.pdf", http.Header{"If-Modified-Since": {"Wed, 01 May 2024 12:00:00 GMT"}})
+	require.Equal(t, http.StatusNotModified, rsp.StatusCode)
+}
+
+func TestServeFileFilename(t *testing.T) {
+	// Names that aren't plain tokens are quoted, non-ASCII ones encoded.
+	rsp := serveTestFile(t, "scan 1.pdf", nil)
+	require.Equal(t, `attachment; filename="scan 1.pdf"`, rsp.Header.Get("Content-Disposition"))
+
+	rsp = serveTestFile(t, "reçu.pdf", nil)
+	require.Equal(t, `attachment; filename*=utf-8''re%C3%A7u.pdf`, rsp.Header.Get("Content-Disposition"))
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Content types uploads are accepted as. They are detected from the file's
+// magic bytes, the Content-Type sent by the client is not trusted.
+const (
+	fileTypePDF  = "application/pdf"
+	fileTypePNG  = "image/png"
+	fileTypeJPEG = "image/jpeg"
+	fileTypeTIFF = "image/tiff"
+)
+
+// uploadExtensions maps supported content types to the extension uploads are
+// saved with.
+var uploadExtensions = map[string]string{
+	fileTypePDF:  ".pdf",
+	fileTypePNG:  ".png",
+	fileTypeJPEG: ".jpg",
+	fileTypeTIFF: ".tiff",
+}
+
+// sniffLen is how many leading bytes detectFileType needs at most.
+const sniffLen = 512
+
+// detectFileType returns the supported content type head starts with, or ""
+// if it isn't one we can OCR.
+func detectFileType(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return fileTypePDF
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return fileTypePNG
+	case bytes.HasPrefix(head, []byte("\xff\xd8\xff")):
+		return fileTypeJPEG
+	case bytes.HasPrefix(head, []byte("II*\x00")), bytes.HasPrefix(head, []byte("MM\x00*")):
+		return fileTypeTIFF
+	}
+	return ""
+}
+
+// copyImageToPage copies an uploaded image to dir as its only page image,
+// named like rendered PDF pages are.
+func copyImageToPage(imagePath, dir string) ([]string, error) {
+	src, err := os.Open(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("image file not found: %w", err)
+	}
+	defer src.Close()
+
+	pagePath := filepath.Join(dir, "page-1"+filepath.Ext(imagePath))
+	dst, err := os.Create(pagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy image: %w", err)
+	}
+	return []string{pagePath}, nil
+}
+
+// convertTIFFToImages writes every page of a TIFF to a PNG in dir and returns
+// the image paths in page order.
+func convertTIFFToImages(tiffPath, dir string) ([]string, error) {
+	file, err := os.Open(tiffPath)
+	if err != nil {
+		return nil, fmt.Errorf("TIFF file not found: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// Pages are written as they are decoded, a long scan is never in memory
+	// as a whole.
+	var images []string
+	err = splitTIFF(file, info.Size(), func(number int, page image.Image) error {
+		imgPath := filepath.Join(dir, fmt.Sprintf("page-%d.png", number))
+		out, err := os.Create(imgPath)
+		if err != nil {
+			return err
+		}
+		err = png.Encode(out, page)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write TIFF page %d: %w", number, err)
+		}
+		images = append(images, imgPath)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return images, nil
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFileType(t *testing.T) {
+	testCases := []struct {
+		name string
+		head string
+		want string
+	}{
+		{"pdf", "%PDF-1.7\n%\xe2\xe3", fileTypePDF},
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00", fileTypePNG},
+		{"jpeg", "\xff\xd8\xff\xe0\x00\x10JFIF", fileTypeJPEG},
+		{"tiff little endian", "II*\x00\x08\x00\x00\x00", fileTypeTIFF},
+		{"tiff big endian", "MM\x00*\x00\x00\x00\x08", fileTypeTIFF},
+		{"gif", "GIF89a", ""},
+		{"text", "hello world", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, detectFileType([]byte(tc.head)))
+		})
+	}
+}
+
+// encodeTIFF writes uncompressed 8-bit grayscale pages as one multi-page TIFF.
+func encodeTIFF(order binary.ByteOrder, pages ...*image.Gray) []byte {
+	var buf bytes.Buffer
+	if order == binary.LittleEndian {
+		buf.WriteString("II*\x00")
+	} else {
+		buf.WriteString("MM\x00*")
+	}
+	binary.Write(&buf, order, uint32(8))
+
+	for i, page := range pages {
+		w, h := page.Bounds().Dx(), page.Bounds().Dy()
+		entries := []struct {
+			tag, kind uint16
+			value     uint32
+		}{
+			{256, 4, uint32(w)}, // ImageWidth
+			{257, 4, uint32(h)}, // ImageLength
+			{258, 3, 8},         // BitsPerSample
+			{259, 3, 1},         // Compression: none
+			{262, 3, 1},         // PhotometricInterpretation: BlackIsZero
+			{273, 4, 0},         // StripOffsets, patched below
+			{277, 3, 1},         // SamplesPerPixel
+			{278, 4, uint32(h)}, // RowsPerStrip
+			{279, 4, uint32(w * h)},
+		}
+		ifdSize := 2 + len(entries)*12 + 4
+		entries[5].value = uint32(buf.Len() + ifdSize)
+
+		binary.Write(&buf, order, uint16(len(entries)))
+		for _, e := range entries {
+			binary.Write(&buf, order, e.tag)
+			binary.Write(&buf, order, e.kind)
+			binary.Write(&buf, order, uint32(1))
+			if e.kind == 3 {
+				binary.Write(&buf, order, uint16(e.value))
+				binary.Write(&buf, order, uint16(0))
+			} else {
+				binary.Write(&buf, order, e.value)
+			}
+		}
+
+		next := uint32(0)
+		if i < len(pages)-1 {
+			next = uint32(buf.Len() + 4 + w*h)
+		}
+		binary.Write(&buf, order, next)
+		buf.Write(page.Pix)
+	}
+	return buf.Bytes()
+}
+
+func grayPage(w, h int, shade uint8) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = shade
+	}
+	return img
+}
+
+// splitTIFFPages collects the pages splitTIFF decodes from data.
+func splitTIFFPages(data []byte) ([]image.Image, error) {
+	var pages []image.Image
+	err := splitTIFF(bytes.NewReader(data), int64(len(data)), func(number int, page image.Image) error {
+		pages = append(pages, page)
+		return nil
+	})
+	return pages, err
+}
+
+func TestSplitTIFF(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		t.Run(order.String(), func(t *testing.T) {
+			data := encodeTIFF(order, grayPage(30, 20, 10), grayPage(40, 50, 128), grayPage(8, 8, 250))
+
+			pages, err := splitTIFFPages(data)
+			require.NoError(t, err)
+			require.Len(t, pages, 3)
+
+			require.Equal(t, image.Rect(0, 0, 30, 20), pages[0].Bounds())
+			require.Equal(t, image.Rect(0, 0, 40, 50), pages[1].Bounds())
+			require.Equal(t, image.Rect(0, 0, 8, 8), pages[2].Bounds())
+			require.Equal(t, color.Gray{128}, color.GrayModel.Convert(pages[1].At(5, 5)))
+		})
+	}
+}
+
+func TestSplitTIFFLoop(t *testing.T) {
+	data := encodeTIFF(binary.LittleEndian, grayPage(4, 4, 0))
+	// Point the only IFD's next offset back at itself.
+	binary.LittleEndian.PutUint32(data[8+2+9*12:], 8)
+
+	_, err := splitTIFFPages(data)
+	require.Error(t, err)
+}
+
+func TestSplitTIFFStops(t *testing.T) {
+	data := encodeTIFF(binary.LittleEndian, grayPage(4, 4, 0), grayPage(4, 4, 0), grayPage(4, 4, 0))
+	errFull := errors.New("disk full")
+
+	var numbers []int
+	err := splitTIFF(bytes.NewReader(data), int64(len(data)), func(number int, page image.Image) error {
+		numbers = append(numbers, number)
+		if number == 2 {
+			return errFull
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errFull)
+	require.Equal(t, []int{1, 2}, numbers)
+}
+
+func TestSplitTIFFInvalid(t *testing.T) {
+	data := []byte("%PDF-1.4 not a tiff")
+	_, err := splitTIFFPages(data)
+	require.Error(t, err)
+}
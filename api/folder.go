@@ -0,0 +1,338 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/yosa/ocr-golang-back/authz"
+	"github.com/yosa/ocr-golang-back/db"
+	"github.com/yosa/ocr-golang-back/token"
+)
+
+var (
+	errFolderExists      = errors.New("a folder with this name already exists here")
+	errMoveChangesAccess = errors.New("only the owner can move this where other users would gain or lose access to it")
+)
+
+// isUniqueViolation reports whether err is a unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// authorizeFolder loads folder id and checks the authenticated user may take
+// action on it. On failure the error response is already written and ok is
+// false.
+func (s *Server) authorizeFolder(ctx *gin.Context, id string, action authz.Action) (folder db.Folder, ok bool) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	folder, err := s.queries.GetFolderByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(authz.ErrNoFolderAccess))
+			return folder, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return folder, false
+	}
+
+	if err := s.policy.AuthorizeFolder(ctx, authPayload.Username, folder, action); err != nil {
+		ctx.JSON(authzStatus(err), errorResponse(err))
+		return folder, false
+	}
+	return folder, true
+}
+
+// inheritedRoles returns the strongest role each user gets through folder
+// shares on what is in folderID, nothing at the top level.
+func (s *Server) inheritedRoles(ctx context.Context, folderID pgtype.Text) (map[string]authz.Role, error) {
+	roles := make(map[string]authz.Role)
+	if !folderID.Valid {
+		return roles, nil
+	}
+
+	shares, err := s.queries.ListInheritedFolderShares(ctx, folderID.String)
+	if err != nil {
+		return nil, err
+	}
+	for _, share := range shares {
+		if role := authz.Role(share.Role); role.Outranks(roles[share.Username]) {
+			roles[share.Username] = role
+		}
+	}
+	return roles, nil
+}
+
+// checkMoveKeepsAccess checks a move from folder from to folder to leaves
+// everyone with the access folder shares gave them, unless the user moving
+// can share, i.e. owns, what is moved: editors could otherwise revoke other
+// users' access or widen it. shareErr is the outcome of authorizing
+// authz.ActionShare on it. On failure the error response is already written
+// and it returns false.
+func (s *Server) checkMoveKeepsAccess(ctx *gin.Context, shareErr error, from, to pgtype.Text) bool {
+	if shareErr == nil || from == to {
+		return true
+	}
+	if !errors.Is(shareErr, authz.ErrForbidden) {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(shareErr))
+		return false
+	}
+
+	before, err := s.inheritedRoles(ctx, from)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	after, err := s.inheritedRoles(ctx, to)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	if !maps.Equal(before, after) {
+		ctx.JSON(http.StatusForbidden, errorResponse(errMoveChangesAccess))
+		return false
+	}
+	return true
+}
+
+type createFolderRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+	// ParentID is the folder to create the folder in, null for the top level.
+	ParentID *string `json:"parent_id"`
+}
+
+func (s *Server) CreateFolder(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	var req createFolderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.CreateFolderParams{
+		ID:     uuid.New().String(),
+		UserID: authPayload.Username,
+		Name:   req.Name,
+	}
+	if req.ParentID != nil {
+		parent, ok := s.authorizeFolder(ctx, *req.ParentID, authz.ActionEdit)
+		if !ok {
+			return
+		}
+		// Folders of a tree all belong to the same user.
+		arg.UserID = parent.UserID
+		arg.ParentID = pgtype.Text{String: parent.ID, Valid: true}
+	}
+
+	folder, err := s.queries.CreateFolder(ctx, arg)
+	if err != nil {
+		if isUniqueViolation(err) {
+			ctx.JSON(http.StatusConflict, errorResponse(errFolderExists))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusCreated, folder)
+}
+
+// listingOwner returns whose documents or folders a listing narrowed to
+// folder id goes through: the folder owner's, once the authenticated user is
+// checked to be able to read the folder, which may be shared with them.
+// Without a folder, users list their own. On failure the error response is
+// already written and ok is false.
+func (s *Server) listingOwner(ctx *gin.Context, id string) (userID string, ok bool) {
+	if id == "" {
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		return authPayload.Username, true
+	}
+	folder, ok := s.authorizeFolder(ctx, id, authz.ActionRead)
+	return folder.UserID, ok
+}
+
+type listFoldersRequest struct {
+	ParentID string `form:"parent_id"`
+}
+
+// ListFolders returns every folder of the authenticated user, clients build
+// the tree from their parent_id. With parent_id, it returns the subfolders
+// of that folder, which may be shared with the user.
+func (s *Server) ListFolders(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	var req listFoldersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var folders []db.Folder
+	var err error
+	if req.ParentID != "" {
+		parent, ok := s.authorizeFolder(ctx, req.ParentID, authz.ActionRead)
+		if !ok {
+			return
+		}
+		folders, err = s.queries.ListFolderChildren(ctx, pgtype.Text{String: parent.ID, Valid: true})
+	} else {
+		folders, err = s.queries.ListFoldersByUser(ctx, authPayload.Username)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if folders == nil {
+		folders = []db.Folder{}
+	}
+	ctx.JSON(http.StatusOK, folders)
+}
+
+type renameFolderRequest struct {
+	Name string `json:"name" binding:"required,max=255"`
+}
+
+func (s *Server) RenameFolder(ctx *gin.Context) {
+	folder, ok := s.authorizeFolder(ctx, ctx.Param("id"), authz.ActionEdit)
+	if !ok {
+		return
+	}
+
+	var req renameFolderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	folder, err := s.queries.RenameFolder(ctx, db.RenameFolderParams{ID: folder.ID, Name: req.Name})
+	if err != nil {
+		if isUniqueViolation(err) {
+			ctx.JSON(http.StatusConflict, errorResponse(errFolderExists))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, folder)
+}
+
+type moveFolderRequest struct {
+	// ParentID is the folder to move the folder to, null for the top level.
+	ParentID *string `json:"parent_id"`
+}
+
+// MoveFolder moves a folder, with its documents and subfolders, into another
+// folder of the same user. Only its owner can move it where folder shares
+// give other users a different access to it.
+func (s *Server) MoveFolder(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	folder, ok := s.authorizeFolder(ctx, ctx.Param("id"), authz.ActionEdit)
+	if !ok {
+		return
+	}
+
+	var req moveFolderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var parentID pgtype.Text
+	if req.ParentID != nil {
+		parent, ok := s.authorizeFolder(ctx, *req.ParentID, authz.ActionEdit)
+		if !ok {
+			return
+		}
+		if parent.UserID != folder.UserID {
+			ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("folders can only be moved within their owner's folders")))
+			return
+		}
+
+		within, err := s.queries.IsFolderWithin(ctx, db.IsFolderWithinParams{
+			FolderID:   parent.ID,
+			AncestorID: folder.ID,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if within {
+			ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("a folder can't be moved into itself or its subfolders")))
+			return
+		}
+		parentID = pgtype.Text{String: parent.ID, Valid: true}
+	}
+
+	shareErr := s.policy.AuthorizeFolder(ctx, authPayload.Username, folder, authz.ActionShare)
+	if !s.checkMoveKeepsAccess(ctx, shareErr, folder.ParentID, parentID) {
+		return
+	}
+
+	folder, err := s.queries.MoveFolder(ctx, db.MoveFolderParams{ID: folder.ID, ParentID: parentID})
+	if err != nil {
+		if isUniqueViolation(err) {
+			ctx.JSON(http.StatusConflict, errorResponse(errFolderExists))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, folder)
+}
+
+type moveDocumentRequest struct {
+	// FolderID is the folder to move the document to, null for the top level.
+	FolderID *string `json:"folder_id"`
+}
+
+// MoveDocument moves a document into a folder of its owner. Only its owner
+// can move it where folder shares give other users a different access to it.
+func (s *Server) MoveDocument(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	document, ok := s.authorizeDocument(ctx, authz.ActionEdit)
+	if !ok {
+		return
+	}
+
+	var req moveDocumentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var folderID pgtype.Text
+	if req.FolderID != nil {
+		folder, ok := s.authorizeFolder(ctx, *req.FolderID, authz.ActionEdit)
+		if !ok {
+			return
+		}
+		if folder.UserID != document.UserID {
+			ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("documents can only be moved to their owner's folders")))
+			return
+		}
+		folderID = pgtype.Text{String: folder.ID, Valid: true}
+	}
+
+	shareErr := s.policy.AuthorizeDocument(ctx, authPayload.Username, document, authz.ActionShare)
+	if !s.checkMoveKeepsAccess(ctx, shareErr, document.FolderID, folderID) {
+		return
+	}
+
+	document, err := s.queries.SetDocumentFolder(ctx, db.SetDocumentFolderParams{ID: document.ID, FolderID: folderID})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, document)
+}
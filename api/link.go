@@ -0,0 +1,314 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/yosa/ocr-golang-back/authz"
+	"github.com/yosa/ocr-golang-back/db"
+	"github.com/yosa/ocr-golang-back/token"
+	"github.com/yosa/ocr-golang-back/util"
+)
+
+const (
+	// What a share link serves: the extracted text or the original file.
+	linkContentText = "text"
+	linkContentFile = "file"
+
+	defaultLinkDuration = 7 * 24 * time.Hour
+	// linkPasswordHeader carries the password of links having one, so that
+	// it stays out of URLs and access logs.
+	linkPasswordHeader = "X-Link-Password"
+
+	// maxLinkPasswordFailures wrong passwords in a row lock a link for
+	// linkLockout, so its password can't be guessed by trying them all.
+	maxLinkPasswordFailures = 5
+	linkLockout             = 15 * time.Minute
+)
+
+var (
+	errShareLinkNotFound = errors.New("share link not found")
+	errShareLinkGone     = errors.New("share link was revoked or has no downloads left")
+	errShareLinkLocked   = errors.New("too many wrong passwords, try again later")
+)
+
+type createShareLinkRequest struct {
+	Content string `json:"content"    binding:"required,oneof=text file"`
+	// ExpiresIn is the lifetime of the link in seconds, a week by default
+	// and 30 days at most.
+	ExpiresIn int64  `json:"expires_in" binding:"omitempty,min=60,max=2592000"`
+	Password  string `json:"password"   binding:"omitempty,min=6,max=72"`
+	// MaxDownloads is how many times the link may be opened, unlimited when
+	// null.
+	MaxDownloads *int32 `json:"max_downloads" binding:"omitempty,min=1"`
+}
+
+type shareLinkResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	DocumentID   string     `json:"document_id"`
+	CreatedBy    string     `json:"created_by"`
+	Content      string     `json:"content"`
+	HasPassword  bool       `json:"has_password"`
+	MaxDownloads *int32     `json:"max_downloads"`
+	Downloads    int32      `json:"downloads"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	// Token and Path are only known when the link is created.
+	Token string `json:"token,omitempty"`
+	Path  string `json:"path,omitempty"`
+}
+
+func newShareLinkResponse(link db.ShareLink) shareLinkResponse {
+	rsp := shareLinkResponse{
+		ID:          link.ID.Bytes,
+		DocumentID:  link.DocumentID,
+		CreatedBy:   link.CreatedBy,
+		Content:     link.Content,
+		HasPassword: link.PasswordHash.Valid,
+		Downloads:   link.Downloads,
+		ExpiresAt:   link.ExpiresAt.Time,
+		CreatedAt:   link.CreatedAt.Time,
+	}
+	if link.MaxDownloads.Valid {
+		rsp.MaxDownloads = &link.MaxDownloads.Int32
+	}
+	if link.RevokedAt.Valid {
+		rsp.RevokedAt = &link.RevokedAt.Time
+	}
+	return rsp
+}
+
+// CreateShareLink makes a link anyone can open without an account, until it
+// expires, is revoked or has been opened max_downloads times. The link is
+// GET /s/:token, with the token returned only now.
+func (s *Server) CreateShareLink(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	document, ok := s.authorizeDocument(ctx, authz.ActionShare)
+	if !ok {
+		return
+	}
+
+	var req createShareLinkRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.Content == linkContentFile && !document.StorageKey.Valid {
+		ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("the original of this document wasn't kept")))
+		return
+	}
+
+	duration := defaultLinkDuration
+	if req.ExpiresIn > 0 {
+		duration = time.Duration(req.ExpiresIn) * time.Second
+	}
+	linkToken, payload, err := s.tokenMaker.CreatePurposeToken(authPayload.Username, token.PurposeShareLink, duration)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.CreateShareLinkParams{
+		ID:         pgtype.UUID{Bytes: payload.ID, Valid: true},
+		DocumentID: document.ID,
+		CreatedBy:  authPayload.Username,
+		Content:    req.Content,
+		ExpiresAt:  pgtype.Timestamp{Time: payload.ExpiresAt.Time, Valid: true},
+	}
+	if req.Password != "" {
+		arg.PasswordHash = pgtype.Text{String: util.HashPassword(req.Password), Valid: true}
+	}
+	if req.MaxDownloads != nil {
+		arg.MaxDownloads = pgtype.Int4{Int32: *req.MaxDownloads, Valid: true}
+	}
+
+	link, err := s.queries.CreateShareLink(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := newShareLinkResponse(link)
+	rsp.Token = linkToken
+	rsp.Path = "/s/" + linkToken
+	ctx.JSON(http.StatusCreated, rsp)
+}
+
+func (s *Server) ListShareLinks(ctx *gin.Context) {
+	document, ok := s.authorizeDocument(ctx, authz.ActionShare)
+	if !ok {
+		return
+	}
+
+	links, err := s.queries.ListShareLinks(ctx, document.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	rsp := make([]shareLinkResponse, len(links))
+	for i, link := range links {
+		rsp[i] = newShareLinkResponse(link)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// RevokeShareLink stops a link from working before it expires.
+func (s *Server) RevokeShareLink(ctx *gin.Context) {
+	document, ok := s.authorizeDocument(ctx, authz.ActionShare)
+	if !ok {
+		return
+	}
+
+	linkID, err := uuid.Parse(ctx.Param("link_id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, errorResponse(errShareLinkNotFound))
+		return
+	}
+
+	_, err = s.queries.RevokeShareLink(ctx, db.RevokeShareLinkParams{
+		ID:         pgtype.UUID{Bytes: linkID, Valid: true},
+		DocumentID: document.ID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errShareLinkNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// OpenShareLink serves what a share link shares, to anyone holding its
+// token and, for links having one, sending the password in the
+// X-Link-Password header. Every request serving something counts as a
+// download, range requests included.
+func (s *Server) OpenShareLink(ctx *gin.Context) {
+	payload, err := s.tokenMaker.VerifyPurposeToken(ctx.Param("token"), token.PurposeShareLink)
+	if err != nil {
+		// Expired links can't be told from made up ones.
+		ctx.JSON(http.StatusNotFound, errorResponse(errShareLinkNotFound))
+		return
+	}
+
+	linkID := pgtype.UUID{Bytes: payload.ID, Valid: true}
+	link, err := s.queries.GetShareLink(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errShareLinkNotFound))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if link.RevokedAt.Valid {
+		ctx.JSON(http.StatusGone, errorResponse(errShareLinkGone))
+		return
+	}
+	if link.PasswordHash.Valid && !s.checkLinkPassword(ctx, link) {
+		return
+	}
+
+	document, err := s.queries.GetDocumentByID(ctx, link.DocumentID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// The content is looked up first, a link to nothing doesn't lose a
+	// download.
+	if link.Content == linkContentFile {
+		file, ok := s.openOriginal(ctx, document)
+		if !ok {
+			return
+		}
+		defer file.Close()
+
+		if !s.countLinkDownload(ctx, linkID) {
+			return
+		}
+		ctx.Header("Cache-Control", "no-store")
+		sendOriginal(ctx, document, file)
+		return
+	}
+
+	extracted, err := s.queries.GetLatestExtractedTextByDocument(ctx, document.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("document has no OCR results yet")))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !s.countLinkDownload(ctx, linkID) {
+		return
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(extracted.Content.String))
+}
+
+// checkLinkPassword checks the request sends the password of link, counting
+// wrong ones towards locking the link. On failure the error response is
+// already written and it returns false.
+func (s *Server) checkLinkPassword(ctx *gin.Context, link db.ShareLink) bool {
+	if link.LockedUntil.Valid && time.Now().Before(link.LockedUntil.Time) {
+		retryAfter := int(time.Until(link.LockedUntil.Time).Seconds()) + 1
+		ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(errShareLinkLocked))
+		return false
+	}
+
+	password := ctx.GetHeader(linkPasswordHeader)
+	if password != "" && util.CheckPassword(password, link.PasswordHash.String) == nil {
+		if link.PasswordFailures > 0 {
+			if err := s.queries.ResetShareLinkPasswordFailures(ctx, link.ID); err != nil {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+				return false
+			}
+		}
+		return true
+	}
+
+	// Requests that don't try a password don't count.
+	if password != "" {
+		_, err := s.queries.FailShareLinkPassword(ctx, db.FailShareLinkPasswordParams{
+			ID:             link.ID,
+			MaxFailures:    maxLinkPasswordFailures,
+			LockoutSeconds: linkLockout.Seconds(),
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return false
+		}
+	}
+	ctx.JSON(http.StatusUnauthorized, errorResponse(fmt.Errorf("wrong or missing link password")))
+	return false
+}
+
+// countLinkDownload counts a download of the link, unless it has none left.
+// On failure the error response is already written and it returns false.
+func (s *Server) countLinkDownload(ctx *gin.Context, linkID pgtype.UUID) bool {
+	_, err := s.queries.UseShareLink(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusGone, errorResponse(errShareLinkGone))
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yosa/ocr-golang-back/db"
+	"github.com/yosa/ocr-golang-back/storage"
+	"github.com/yosa/ocr-golang-back/token"
+	"github.com/yosa/ocr-golang-back/util"
+)
+
+// linkQuerier knows a single share link to aliceDocument, or to document
+// when set, whose text is extracted unless noText is set.
+type linkQuerier struct {
+	authzQuerier
+	link     db.ShareLink
+	document *db.Document
+	noText   bool
+}
+
+func (q *linkQuerier) GetDocumentByID(ctx context.Context, id string) (db.Document, error) {
+	if q.document != nil && id == q.document.ID {
+		return *q.document, nil
+	}
+	return q.authzQuerier.GetDocumentByID(ctx, id)
+}
+
+func (q *linkQuerier) GetShareLink(ctx context.Context, id pgtype.UUID) (db.ShareLink, error) {
+	if id != q.link.ID {
+		return db.ShareLink{}, pgx.ErrNoRows
+	}
+	return q.link, nil
+}
+
+func (q *linkQuerier) UseShareLink(ctx context.Context, id pgtype.UUID) (db.ShareLink, error) {
+	if id != q.link.ID || q.link.RevokedAt.Valid ||
+		(q.link.MaxDownloads.Valid && q.link.Downloads >= q.link.MaxDownloads.Int32) {
+		return db.ShareLink{}, pgx.ErrNoRows
+	}
+	q.link.Downloads++
+	return q.link, nil
+}
+
+func (q *linkQuerier) FailShareLinkPassword(ctx context.Context, arg db.FailShareLinkPasswordParams) (db.ShareLink, error) {
+	q.link.PasswordFailures++
+	if q.link.PasswordFailures >= arg.MaxFailures {
+		q.link.PasswordFailures = 0
+		lockout := time.Duration(arg.LockoutSeconds * float64(time.Second))
+		q.link.LockedUntil = pgtype.Timestamp{Time: time.Now().Add(lockout), Valid: true}
+	}
+	return q.link, nil
+}
+
+func (q *linkQuerier) ResetShareLinkPasswordFailures(ctx context.Context, id pgtype.UUID) error {
+	q.link.PasswordFailures = 0
+	return nil
+}
+
+func (q *linkQuerier) GetLatestExtractedTextByDocument(ctx context.Context, documentID string) (db.ExtractedText, error) {
+	if q.noText {
+		return db.ExtractedText{}, pgx.ErrNoRows
+	}
+	return db.ExtractedText{DocumentID: documentID, Content: pgtype.Text{String: "Invoice 42", Valid: true}}, nil
+}
+
+// newLinkTestServer returns a server knowing one share link to content of
+// aliceDocument, and a function opening it with the given request headers.
+func newLinkTestServer(t *testing.T, content string) (*Server, *linkQuerier, func(header http.Header) *httptest.ResponseRecorder) {
+	server, _ := newAuthzTestServer(t)
+
+	linkToken, payload, err := server.tokenMaker.CreatePurposeToken("alice", token.PurposeShareLink, time.Minute)
+	require.NoError(t, err)
+	queries := &linkQuerier{link: db.ShareLink{
+		ID:         pgtype.UUID{Bytes: payload.ID, Valid: true},
+		DocumentID: aliceDocument.ID,
+		Content:    content,
+	}}
+	server.queries = queries
+
+	open := func(header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/s/"+linkToken, nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	return server, queries, open
+}
+
+func TestOpenShareLink(t *testing.T) {
+	_, queries, openLink := newLinkTestServer(t, linkContentText)
+	queries.link.PasswordHash = pgtype.Text{String: util.HashPassword("secret123"), Valid: true}
+	queries.link.MaxDownloads = pgtype.Int4{Int32: 1, Valid: true}
+
+	open := func(password string) *httptest.ResponseRecorder {
+		header := http.Header{}
+		if password != "" {
+			header.Set(linkPasswordHeader, password)
+		}
+		return openLink(header)
+	}
+
+	require.Equal(t, http.StatusUnauthorized, open("").Code)
+	require.Equal(t, http.StatusUnauthorized, open("wrong-password").Code)
+	require.Zero(t, queries.link.Downloads)
+
+	recorder := open("secret123")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "Invoice 42", recorder.Body.String())
+	require.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+
+	// The only download is used up.
+	recorder = open("secret123")
+	require.Equal(t, http.StatusGone, recorder.Code)
+	require.Equal(t, errShareLinkGone.Error(), errorMessage(t, recorder))
+
+	queries.link.MaxDownloads = pgtype.Int4{}
+	queries.link.RevokedAt = pgtype.Timestamp{Time: time.Now(), Valid: true}
+	require.Equal(t, http.StatusGone, open("secret123").Code)
+}
+
+func TestOpenShareLinkPasswordLockout(t *testing.T) {
+	_, queries, openLink := newLinkTestServer(t, linkContentText)
+	queries.link.PasswordHash = pgtype.Text{String: util.HashPassword("secret123"), Valid: true}
+	open := func(password string) *httptest.ResponseRecorder {
+		return openLink(http.Header{linkPasswordHeader: {password}})
+	}
+
+	// A right password starts the count over.
+	for i := 0; i < maxLinkPasswordFailures-1; i++ {
+		require.Equal(t, http.StatusUnauthorized, open("guess").Code)
+	}
+	require.Equal(t, http.StatusOK, open("secret123").Code)
+	require.Zero(t, queries.link.PasswordFailures)
+
+	for i := 0; i < maxLinkPasswordFailures; i++ {
+		require.Equal(t, http.StatusUnauthorized, open("guess").Code)
+	}
+
+	// Locked, even the right password is turned away.
+	recorder := open("secret123")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+
+	queries.link.LockedUntil.Time = time.Now().Add(-time.Second)
+	require.Equal(t, http.StatusOK, open("secret123").Code)
+}
+
+func TestOpenShareLinkNothingToServe(t *testing.T) {
+	_, queries, open := newLinkTestServer(t, linkContentText)
+	queries.link.MaxDownloads = pgtype.Int4{Int32: 1, Valid: true}
+	queries.noText = true
+
+	// Not OCR'd yet, the download isn't used up.
+	require.Equal(t, http.StatusNotFound, open(nil).Code)
+	require.Zero(t, queries.link.Downloads)
+
+	queries.noText = false
+	require.Equal(t, http.StatusOK, open(nil).Code)
+	require.Equal(t, int32(1), queries.link.Downloads)
+}
+
+func TestOpenShareLinkRange(t *testing.T) {
+	server, queries, open := newLinkTestServer(t, linkContentFile)
+	queries.link.MaxDownloads = pgtype.Int4{Int32: 2, Valid: true}
+
+	blobs, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	server.blobs = blobs
+
+	// The original isn't there, nothing is counted.
+	document := aliceDocument
+	document.StorageKey = pgtype.Text{String: originalKey(document.ID, fileTypePDF), Valid: true}
+	document.FileType = pgtype.Text{String: fileTypePDF, Valid: true}
+	queries.document = &document
+	require.Equal(t, http.StatusNotFound, open(nil).Code)
+	require.Zero(t, queries.link.Downloads)
+
+	content := "%PDF-1.4 scanned invoice"
+	err = blobs.Put(context.Background(), document.StorageKey.String, strings.NewReader(content), int64(len(content)), fileTypePDF)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusOK, open(nil).Code)
+	require.Equal(t, int32(1), queries.link.Downloads)
+
+	// Ranges are downloads too, whatever part of the file they ask for.
+	recorder := open(http.Header{"Range": {"bytes=9-15"}})
+	require.Equal(t, http.StatusPartialContent, recorder.Code)
+	require.Equal(t, "scanned", recorder.Body.String())
+	require.Equal(t, int32(2), queries.link.Downloads)
+
+	// A used up link serves no part of the file.
+	for _, ranges := range []string{"bytes=1-", "bytes=-999999999", "bytes=0-99"} {
+		recorder := open(http.Header{"Range": {ranges}})
+		require.Equal(t, http.StatusGone, recorder.Code, ranges)
+		require.Equal(t, errShareLinkGone.Error(), errorMessage(t, recorder))
+	}
+	require.Equal(t, int32(2), queries.link.Downloads)
+}
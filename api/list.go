@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/yosa/ocr-golang-back/db"
+)
+
+const (
+	sortUploadedAt = "uploaded_at"
+	sortFilename   = "filename"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+type listDocumentsRequest struct {
+	FileType     string    `form:"file_type"`
+	Status       string    `form:"status"        binding:"omitempty,oneof=processing ready partial failed"`
+	UploadedFrom time.Time `form:"uploaded_from"`
+	UploadedTo   time.Time `form:"uploaded_to"   binding:"omitempty,gtfield=UploadedFrom"`
+	Language     string    `form:"language"`
+	// Filename matches documents whose filename contains it, ignoring case.
+	Filename string `form:"filename"`
+	FolderID string `form:"folder_id"`
+	// Tags match documents having all of them.
+	Tags   []string `form:"tag"`
+	Sort   string   `form:"sort"   binding:"omitempty,oneof=uploaded_at filename"`
+	Order  string   `form:"order"  binding:"omitempty,oneof=asc desc"`
+	Limit  int32    `form:"limit"  binding:"omitempty,min=1,max=100"`
+	Cursor string   `form:"cursor"`
+}
+
+type listDocumentsResponse struct {
+	Documents []db.Document `json:"documents"`
+	// NextCursor fetches the next page, null on the last one.
+	NextCursor *string `json:"next_cursor"`
+	// Total counts the documents matching the filters, on every page.
+	Total int64 `json:"total"`
+}
+
+// documentCursor is the position of the last document of a page. Clients get
+// it base64 encoded and pass it back untouched.
+type documentCursor struct {
+	Sort       string    `json:"s"`
+	Descending bool      `json:"d"`
+	ID         string    `json:"id"`
+	Filename   string    `json:"f,omitempty"`
+	UploadedAt time.Time `json:"u"`
+}
+
+// newDocumentCursor is the cursor of the page following document.
+func newDocumentCursor(sort string, descending bool, document db.Document) documentCursor {
+	cursor := documentCursor{Sort: sort, Descending: descending, ID: document.ID}
+	if sort == sortFilename {
+		cursor.Filename = document.Filename.String
+	} else {
+		cursor.UploadedAt = document.UploadedAt.Time
+	}
+	return cursor
+}
+
+func (c documentCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeDocumentCursor(s string) (documentCursor, error) {
+	var cursor documentCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(data, &cursor) != nil || cursor.ID == "" {
+		return cursor, errInvalidCursor
+	}
+	return cursor, nil
+}
+
+// likeContains is a LIKE pattern matching strings containing s.
+func likeContains(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + s + "%"
+}
+
+func optionalText(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
+
+func optionalTimestamp(t time.Time) pgtype.Timestamp {
+	return pgtype.Timestamp{Time: t.UTC(), Valid: !t.IsZero()}
+}
+
+// listParams turns req into the arguments of ListDocuments, without the page
+// size.
+func (req listDocumentsRequest) listParams(userID string) (db.ListDocumentsParams, error) {
+	arg := db.ListDocumentsParams{
+		UserID:       userID,
+		FileType:     optionalText(req.FileType),
+		Status:       optionalText(req.Status),
+		UploadedFrom: optionalTimestamp(req.UploadedFrom),
+		UploadedTo:   optionalTimestamp(req.UploadedTo),
+		Language:     optionalText(req.Language),
+		FolderID:     optionalText(req.FolderID),
+		Tags:         req.Tags,
+		SortBy:       req.Sort,
+	}
+	if req.Filename != "" {
+		arg.FilenamePattern = optionalText(likeContains(req.Filename))
+	}
+
+	// Newest first, filenames in alphabetical order.
+	if arg.SortBy == "" {
+		arg.SortBy = sortUploadedAt
+	}
+	switch req.Order {
+	case "asc":
+		arg.Descending = false
+	case "desc":
+		arg.Descending = true
+	default:
+		arg.Descending = arg.SortBy == sortUploadedAt
+	}
+
+	if req.Cursor != "" {
+		cursor, err := decodeDocumentCursor(req.Cursor)
+		if err != nil {
+			return arg, err
+		}
+		if cursor.Sort != arg.SortBy || cursor.Descending != arg.Descending {
+			return arg, fmt.Errorf("cursor was made for another sort order")
+		}
+		arg.AfterID = optionalText(cursor.ID)
+		arg.AfterFilename = pgtype.Text{String: cursor.Filename, Valid: true}
+		arg.AfterUploadedAt = pgtype.Timestamp{Time: cursor.UploadedAt, Valid: true}
+	}
+	return arg, nil
+}
+
+// ListDocuments lists the documents of the authenticated user, or those of a
+// folder shared with them, a page at a time. Filters, sort and order are
+// query parameters, the next page is fetched with the same ones and the
+// next_cursor of the page before.
+func (s *Server) ListDocuments(ctx *gin.Context) {
+	var req listDocumentsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 20
+	}
+
+	owner, ok := s.listingOwner(ctx, req.FolderID)
+	if !ok {
+		return
+	}
+	arg, err := req.listParams(owner)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	// One more document than asked tells whether there is a next page.
+	arg.LimitCount = req.Limit + 1
+
+	documents, err := s.queries.ListDocuments(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	total, err := s.queries.CountDocuments(ctx, db.CountDocumentsParams{
+		UserID:          arg.UserID,
+		FileType:        arg.FileType,
+		Status:          arg.Status,
+		UploadedFrom:    arg.UploadedFrom,
+		UploadedTo:      arg.UploadedTo,
+		Language:        arg.Language,
+		FilenamePattern: arg.FilenamePattern,
+		FolderID:        arg.FolderID,
+		Tags:            arg.Tags,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listDocumentsResponse{Documents: documents, Total: total}
+	if len(documents) > int(req.Limit) {
+		rsp.Documents = documents[:req.Limit]
+		next := newDocumentCursor(arg.SortBy, arg.Descending, rsp.Documents[req.Limit-1]).encode()
+		rsp.NextCursor = &next
+	}
+	if rsp.Documents == nil {
+		rsp.Documents = []db.Document{}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yosa/ocr-golang-back/db"
+)
+
+func TestDocumentCursor(t *testing.T) {
+	document := db.Document{
+		ID:         "doc-1",
+		Filename:   pgtype.Text{String: "invoice.pdf", Valid: true},
+		UploadedAt: pgtype.Timestamp{Time: time.Date(2024, 3, 1, 10, 30, 0, 123000, time.UTC), Valid: true},
+	}
+
+	cursor := newDocumentCursor(sortUploadedAt, true, document)
+	decoded, err := decodeDocumentCursor(cursor.encode())
+	require.NoError(t, err)
+	require.Equal(t, cursor, decoded)
+	require.Equal(t, document.UploadedAt.Time, decoded.UploadedAt)
+
+	cursor = newDocumentCursor(sortFilename, false, document)
+	decoded, err = decodeDocumentCursor(cursor.encode())
+	require.NoError(t, err)
+	require.Equal(t, "invoice.pdf", decoded.Filename)
+
+	for _, s := range []string{"", "not base64!", "bm90IGpzb24", "e30"} {
+		_, err := decodeDocumentCursor(s)
+		require.ErrorIs(t, err, errInvalidCursor, s)
+	}
+}
+
+func TestLikeContains(t *testing.T) {
+	require.Equal(t, "%invoice%", likeContains("invoice"))
+	require.Equal(t, `%50\%\_off\\%`, likeContains(`50%_off\`))
+}
+
+func TestListDocumentsRequestParams(t *testing.T) {
+	arg, err := listDocumentsRequest{}.listParams("alice")
+	require.NoError(t, err)
+	require.Equal(t, db.ListDocumentsParams{UserID: "alice", SortBy: sortUploadedAt, Descending: true}, arg)
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.FixedZone("CET", 3600))
+	arg, err = listDocumentsRequest{
+		Status:       documentStatusReady,
+		UploadedFrom: from,
+		Filename:     "report",
+		FolderID:     "folder-1",
+		Tags:         []string{"invoice"},
+		Sort:         sortFilename,
+	}.listParams("alice")
+	require.NoError(t, err)
+	require.Equal(t, pgtype.Text{String: documentStatusReady, Valid: true}, arg.Status)
+	require.Equal(t, pgtype.Timestamp{Time: from.UTC(), Valid: true}, arg.UploadedFrom)
+	require.False(t, arg.UploadedTo.Valid)
+	require.Equal(t, pgtype.Text{String: "%report%", Valid: true}, arg.FilenamePattern)
+	require.Equal(t, pgtype.Text{String: "folder-1", Valid: true}, arg.FolderID)
+	require.Equal(t, []string{"invoice"}, arg.Tags)
+	require.False(t, arg.Descending)
+	require.False(t, arg.AfterID.Valid)
+
+	// A cursor continues the sort it was made for.
+	document := db.Document{ID: "doc-1", Filename: pgtype.Text{String: "b.pdf", Valid: true}}
+	cursor := newDocumentCursor(sortFilename, false, document).encode()
+	arg, err = listDocumentsRequest{Sort: sortFilename, Cursor: cursor}.listParams("alice")
+	require.NoError(t, err)
+	require.Equal(t, pgtype.Text{String: "doc-1", Valid: true}, arg.AfterID)
+	require.Equal(t, pgtype.Text{String: "b.pdf", Valid: true}, arg.AfterFilename)
+
+	_, err = listDocumentsRequest{Sort: sortFilename, Order: "desc", Cursor: cursor}.listParams("alice")
+	require.Error(t, err)
+	_, err = listDocumentsRequest{Cursor: "garbage"}.listParams("alice")
+	require.ErrorIs(t, err, errInvalidCursor)
+}
@@ -0,0 +1,293 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/yosa/ocr-golang-back/authz"
+	"github.com/yosa/ocr-golang-back/db"
+	"github.com/yosa/ocr-golang-back/ocr"
+)
+
+const (
+	// liveWriteTimeout bounds every write to a live results socket.
+	liveWriteTimeout = 10 * time.Second
+	// livePongTimeout is how long a client may stay silent, it has to
+	// answer pings sent every livePingInterval.
+	livePongTimeout  = 60 * time.Second
+	livePingInterval = livePongTimeout * 9 / 10
+)
+
+// parseOrigins splits a list of origins separated by commas or spaces.
+func parseOrigins(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// checkLiveOrigin lets a socket be opened from the server's own origin, from
+// the configured live origins, and by clients that aren't browsers, which
+// send no Origin.
+func (s *Server) checkLiveOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return slices.Contains(parseOrigins(s.config.LiveAllowedOrigins), origin)
+}
+
+// liveMessage is what live results sockets send: the progress event, and for
+// page events the text and word boxes read from the page.
+type liveMessage struct {
+	progressEvent
+	Method     string     `json:"method,omitempty"`
+	Text       string     `json:"text,omitempty"`
+	Confidence float64    `json:"confidence,omitempty"`
+	Width      int        `json:"width,omitempty"`
+	Height     int        `json:"height,omitempty"`
+	Words      []ocr.Word `json:"words,omitempty"`
+}
+
+func newLiveMessage(event progressEvent) liveMessage {
+	msg := liveMessage{progressEvent: event}
+	if page := event.result; page != nil {
+		msg.Method = page.Method
+		msg.Width = page.Width
+		msg.Height = page.Height
+		if page.Result != nil {
+			msg.Text = page.Text
+			msg.Confidence = page.Confidence
+			msg.Words = page.Words
+		}
+	}
+	return msg
+}
+
+// storedPageMessage is the page message of a page read by an earlier job.
+func storedPageMessage(page db.DocumentPage, words []db.DocumentWord) liveMessage {
+	msg := liveMessage{
+		progressEvent: progressEvent{
+			Type:       eventPage,
+			DocumentID: page.DocumentID,
+			Page:       int(page.PageNumber),
+			Status:     page.Status,
+			Error:      page.Error.String,
+		},
+		Method:     page.Method,
+		Text:       page.Text,
+		Confidence: page.Confidence,
+		Width:      int(page.Width),
+		Height:     int(page.Height),
+	}
+	for _, word := range words {
+		msg.Words = append(msg.Words, newOCRWord(word))
+	}
+	return msg
+}
+
+// pageSequencer holds back the pages of a document job read out of order, so
+// that a client having page n has every page before it and can resume after
+// n. Pages read again by page retries go through as they come.
+type pageSequencer struct {
+	ordered bool
+	next    int
+	pending map[int]progressEvent
+}
+
+func newPageSequencer(after int) *pageSequencer {
+	return &pageSequencer{next: after + 1, pending: make(map[int]progressEvent)}
+}
+
+// restart is for a new attempt at the document, which reads every page again.
+func (q *pageSequencer) restart() {
+	q.next = 1
+	clear(q.pending)
+}
+
+// push returns the events ready to be sent once event happened, in order.
+func (q *pageSequencer) push(event progressEvent) []progressEvent {
+	switch {
+	case event.Type == eventConverted:
+		q.ordered = true
+	case event.final():
+		q.ordered = false
+		clear(q.pending)
+	case event.Type == eventPage && q.ordered:
+		if event.Page < q.next {
+			return nil
+		}
+		q.pending[event.Page] = event
+
+		var ready []progressEvent
+		for {
+			page, ok := q.pending[q.next]
+			if !ok {
+				return ready
+			}
+			delete(q.pending, q.next)
+			ready = append(ready, page)
+			q.next++
+		}
+	}
+	return []progressEvent{event}
+}
+
+type liveResultsRequest struct {
+	// After is the highest page number the client already has.
+	After int `form:"after" binding:"min=0"`
+}
+
+// StreamLiveResults sends the text and word boxes of every page of a
+// document over a WebSocket as soon as it is read, in page order, along with
+// the progress events of GET /documents/:id/events. Pages already stored
+// are sent first. A client reconnecting passes the highest page number it
+// got as after, and only gets the pages past it. Messages of page retries
+// come whenever the page is read again. The server closes the socket after
+// the finished or failed message. Browsers, which can't authenticate a socket
+// with a header, pass a token from POST /tokens/live as token.
+func (s *Server) StreamLiveResults(ctx *gin.Context) {
+	var req liveResultsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	document, ok := s.authorizeDocument(ctx, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	past, events, unsubscribe := s.progress.subscribe(document.ID)
+	defer unsubscribe()
+
+	job, err := s.queries.GetLatestOCRJobByDocument(ctx, document.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("no OCR job for document")))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	stored, err := s.queries.ListDocumentPages(ctx, document.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	words, err := s.queries.ListDocumentWords(ctx, document.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 4096,
+		CheckOrigin:     s.checkLiveOrigin,
+	}
+	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		// The upgrader already replied.
+		return
+	}
+	defer conn.Close()
+
+	send := func(msg liveMessage) error {
+		conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+		return conn.WriteJSON(msg)
+	}
+	closeWith := func(code int, text string) {
+		deadline := time.Now().Add(liveWriteTimeout)
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), deadline)
+	}
+
+	sequencer := newPageSequencer(req.After)
+	for _, page := range stored {
+		if int(page.PageNumber) <= req.After {
+			continue
+		}
+		var pageWords []db.DocumentWord
+		for len(words) > 0 && words[0].PageNumber <= page.PageNumber {
+			if words[0].PageNumber == page.PageNumber {
+				pageWords = append(pageWords, words[0])
+			}
+			words = words[1:]
+		}
+		if err := send(storedPageMessage(page, pageWords)); err != nil {
+			return
+		}
+		sequencer.next = int(page.PageNumber) + 1
+	}
+
+	if len(past) == 0 && job.Status != "queued" && job.Status != "running" {
+		send(newLiveMessage(jobOutcomeEvent(document, job)))
+		closeWith(websocket.CloseNormalClosure, "")
+		return
+	}
+	for _, event := range past {
+		for _, ready := range sequencer.push(event) {
+			if err := send(newLiveMessage(ready)); err != nil {
+				return
+			}
+		}
+	}
+
+	// Clients only send control messages, reading handles them and tells
+	// when the client is gone.
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		conn.SetReadDeadline(time.Now().Add(livePongTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(livePongTimeout))
+		})
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(livePingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				closeWith(websocket.CloseTryAgainLater, "fell behind, reconnect with after")
+				return
+			}
+			if event.Type == eventConverted {
+				sequencer.restart()
+			}
+			for _, ready := range sequencer.push(event) {
+				if err := send(newLiveMessage(ready)); err != nil {
+					return
+				}
+			}
+			if event.final() {
+				closeWith(websocket.CloseNormalClosure, "")
+				return
+			}
+		case <-ping.C:
+			deadline := time.Now().Add(liveWriteTimeout)
+			if err := conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yosa/ocr-golang-back/ocr"
+	"github.com/yosa/ocr-golang-back/util"
+)
+
+func pageEvent(n int) progressEvent {
+	return progressEvent{Type: eventPage, DocumentID: "doc-1", Page: n}
+}
+
+func pageNumbers(events []progressEvent) []int {
+	numbers := []int{}
+	for _, event := range events {
+		numbers = append(numbers, event.Page)
+	}
+	return numbers
+}
+
+func TestPageSequencer(t *testing.T) {
+	q := newPageSequencer(0)
+	require.Len(t, q.push(progressEvent{Type: eventConverted, DocumentID: "doc-1", Pages: 4}), 1)
+
+	// Page 3 waits for page 2.
+	require.Equal(t, []int{}, pageNumbers(q.push(pageEvent(3))))
+	require.Equal(t, []int{1}, pageNumbers(q.push(pageEvent(1))))
+	require.Equal(t, []int{2, 3}, pageNumbers(q.push(pageEvent(2))))
+	require.Equal(t, []int{4}, pageNumbers(q.push(pageEvent(4))))
+
+	require.Len(t, q.push(progressEvent{Type: eventFinished, DocumentID: "doc-1"}), 1)
+
+	// Page retries aren't held back, even when the client had the page.
+	require.Equal(t, []int{2}, pageNumbers(q.push(pageEvent(2))))
+}
+
+func TestPageSequencerAfter(t *testing.T) {
+	q := newPageSequencer(2)
+	q.push(progressEvent{Type: eventConverted, DocumentID: "doc-1", Pages: 4})
+
+	// The client has the first two pages already.
+	require.Equal(t, []int{}, pageNumbers(q.push(pageEvent(1))))
+	require.Equal(t, []int{}, pageNumbers(q.push(pageEvent(4))))
+	require.Equal(t, []int{3, 4}, pageNumbers(q.push(pageEvent(3))))
+
+	// A new attempt reads every page again.
+	q.restart()
+	q.push(progressEvent{Type: eventConverted, DocumentID: "doc-1", Pages: 4})
+	require.Equal(t, []int{1}, pageNumbers(q.push(pageEvent(1))))
+}
+
+func TestNewLiveMessage(t *testing.T) {
+	page := pageResult{
+		Number: 1,
+		Width:  200,
+		Height: 100,
+		Method: pageMethodOCR,
+		Result: &ocr.Result{
+			Text:       "hello",
+			Confidence: 91,
+			Words:      []ocr.Word{{Text: "hello", X: 1, Y: 2, Width: 30, Height: 10, Confidence: 91}},
+		},
+	}
+	event := progressEvent{Type: eventPage, DocumentID: "doc-1", Page: 1, Done: 1, Pages: 2, Status: pageStatusSucceeded, result: &page}
+
+	data, err := json.Marshal(newLiveMessage(event))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"type": "page", "document_id": "doc-1", "page": 1, "done": 1, "pages": 2, "status": "succeeded",
+		"method": "ocr", "text": "hello", "confidence": 91, "width": 200, "height": 100,
+		"words": [{"text": "hello", "line": 0, "x": 1, "y": 2, "width": 30, "height": 10, "confidence": 91}]
+	}`, string(data))
+
+	failed := pageResult{Number: 2, Method: pageMethodOCR, Err: errors.New("boom"), Result: &ocr.Result{}}
+	event = progressEvent{Type: eventPage, DocumentID: "doc-1", Page: 2, Status: pageStatusFailed, Error: "boom", result: &failed}
+	data, err = json.Marshal(newLiveMessage(event))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type": "page", "document_id": "doc-1", "page": 2, "status": "failed", "error": "boom", "method": "ocr"}`, string(data))
+}
+
+func TestCheckLiveOrigin(t *testing.T) {
+	s := &Server{config: util.Config{LiveAllowedOrigins: "https://editor.example.com, https://admin.example.com"}}
+
+	testCases := map[string]bool{
+		"":                                    true, // not a browser
+		"http://api.example.com":              true, // the server's own origin
+		"https://editor.example.com":          true,
+		"https://admin.example.com":           true,
+		"https://evil.example.com":            false,
+		"https://editor.example.com.evil.com": false,
+	}
+	for origin, want := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "http://api.example.com/documents/doc-1/live", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		require.Equal(t, want, s.checkLiveOrigin(req), origin)
+	}
+}
@@ -52,3 +52,26 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// liveAuthMiddleware authenticates WebSocket handshakes. Browsers can't set
+// headers on those, so a live token from POST /tokens/live is also accepted
+// as the token query parameter. Other clients send the Authorization header.
+func liveAuthMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+	headerAuth := authMiddleware(tokenMaker)
+	return func(ctx *gin.Context) {
+		liveToken := ctx.Query("token")
+		if liveToken == "" {
+			headerAuth(ctx)
+			return
+		}
+
+		payload, err := tokenMaker.VerifyPurposeToken(liveToken, token.PurposeLive)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		ctx.Set(authorizationPayloadKey, payload)
+		ctx.Next()
+	}
+}
@@ -0,0 +1,399 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/yosa/ocr-golang-back/ocr"
+	"github.com/yosa/ocr-golang-back/preprocess"
+)
+
+// uploadDir is where documents uploaded before blob storage keep their upload
+// and page images. Everything else only touches local disk in a scratch dir
+// that is removed once the job is done.
+const uploadDir = "uploads"
+
+// renderDPI is the resolution PDF pages are rendered at for OCR.
+const renderDPI = 150
+
+// uploadPathFor returns where the upload for docID is kept when it predates
+// blob storage.
+func uploadPathFor(docID, fileType string) string {
+	return filepath.Join(uploadDir, docID+uploadExtension(fileType))
+}
+
+// originalKey returns the blob storage key the original upload of docID is
+// kept under.
+func originalKey(docID, fileType string) string {
+	return "originals/" + docID + uploadExtension(fileType)
+}
+
+// pageKey returns the blob storage key the image of a page is kept under,
+// read again by page retries and the searchable PDF. ext is the extension of
+// the rendered image.
+func pageKey(docID string, pageNumber int, ext string) string {
+	return "pages/" + docID + "/page-" + strconv.Itoa(pageNumber) + ext
+}
+
+// legacyPageKey reports whether imageKey names a page image in the upload dir,
+// where pages read before their images were kept in storage have them.
+func legacyPageKey(imageKey string) bool {
+	return !strings.Contains(imageKey, "/")
+}
+
+// uploadExtension returns the extension uploads of fileType are saved with.
+// Documents uploaded before file types were detected are all PDFs.
+func uploadExtension(fileType string) string {
+	ext, ok := uploadExtensions[fileType]
+	if !ok {
+		ext = uploadExtensions[fileTypePDF]
+	}
+	return ext
+}
+
+func removeUpload(docID string) {
+	removeFiles(filepath.Join(uploadDir, docID+".*"))
+}
+
+// cleanupPageImages deletes the page images of docID, whatever their format.
+func cleanupPageImages(docID string) {
+	removeFiles(filepath.Join(uploadDir, fmt.Sprintf("%s_page-*", docID)))
+}
+
+func removeFiles(pattern string) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		log.Printf("Error finding files for cleanup: %v", err)
+		return
+	}
+
+	for _, file := range files {
+		if err := os.Remove(file); err != nil {
+			log.Printf("Failed to delete %s: %v", file, err)
+		} else {
+			log.Printf("Deleted: %s", file)
+		}
+	}
+}
+
+// pageImages turns an upload into one image per page in dir, in page order.
+func pageImages(ctx context.Context, uploadPath, fileType, dir string) ([]string, error) {
+	switch fileType {
+	case fileTypePNG, fileTypeJPEG:
+		return copyImageToPage(uploadPath, dir)
+	case fileTypeTIFF:
+		return convertTIFFToImages(uploadPath, dir)
+	default:
+		return convertPDFToImages(ctx, uploadPath, dir)
+	}
+}
+
+// convertPDFToImages renders every page of the PDF to a PNG in dir and
+// returns the image paths in page order.
+func convertPDFToImages(ctx context.Context, pdfPath, dir string) ([]string, error) {
+	if _, err := os.Stat(pdfPath); err != nil {
+		return nil, fmt.Errorf("PDF file not found: %w", err)
+	}
+
+	outputPrefix := filepath.Join(dir, "page")
+
+	// Add timeout for PDF conversion
+	convertCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	cmd := exec.CommandContext(
+		convertCtx,
+		"pdftoppm", "-png", "-r", strconv.Itoa(renderDPI),
+		pdfPath, outputPrefix,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf(
+			"failed to convert pdf to images: %w (stderr: %s)",
+			err,
+			stderr.String(),
+		)
+	}
+
+	// Find all generated images, pdftoppm zero-pads page numbers so the
+	// lexical order of Glob is the page order.
+	images, err := filepath.Glob(fmt.Sprintf("%s-*.png", outputPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find images: %w", err)
+	}
+
+	if len(images) == 0 {
+		return nil, fmt.Errorf("no images generated from PDF")
+	}
+	return images, nil
+}
+
+// pageResult is the text of one page, numbered from 1, and how it was
+// obtained: pageMethodOCR or pageMethodTextLayer. Pages that couldn't be read
+// have Err set and an empty Result.
+type pageResult struct {
+	Number    int
+	ImagePath string
+	Width     int
+	Height    int
+	Method    string
+	Err       error
+	*ocr.Result
+}
+
+// imageSize returns the pixel dimensions of an image file.
+func imageSize(path string) (width, height int, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return config.Width, config.Height, nil
+}
+
+// pageOptions is how the pages of a document are OCR'd.
+type pageOptions struct {
+	OCR ocr.Options
+	// Preprocess cleans up page images before OCR, no step means none.
+	Preprocess preprocess.Options
+	// Workers is how many pages are read at once, 1 when not set.
+	Workers int
+	// TempDir is where preprocessed page images are written, the job's
+	// scratch dir. Empty means the system temp dir.
+	TempDir string
+	// OnPage, when set, is called as each page is read, failed pages
+	// included, with how many pages are done so far. Pages are read
+	// concurrently, so are its calls.
+	OnPage func(page pageResult, done int)
+}
+
+// recognizePages reads every page, up to opts.Workers at once, and returns
+// them in page order. Pages whose entry in textLayer is usable take their
+// text from it, the others are run through the engine. textLayer may be
+// shorter than images or nil. A page that fails doesn't stop the others, it
+// is returned with its error. Only ctx being done fails the whole call.
+func recognizePages(ctx context.Context, engine ocr.Engine, images []string, textLayer []textLayerPage, opts pageOptions) ([]pageResult, error) {
+	pages := make([]pageResult, len(images))
+	var done atomic.Int32
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(max(opts.Workers, 1))
+	for i, imgPath := range images {
+		if groupCtx.Err() != nil {
+			break
+		}
+
+		var layer *textLayerPage
+		if i < len(textLayer) {
+			layer = &textLayer[i]
+		}
+		group.Go(func() error {
+			page, err := readPage(groupCtx, engine, imgPath, layer, opts)
+			if err != nil {
+				if ctxErr := groupCtx.Err(); ctxErr != nil {
+					return ctxErr
+				}
+				log.Printf("Page %d of %s failed: %v", i+1, imgPath, err)
+				page.ImagePath = imgPath
+				page.Method = pageMethodOCR
+				page.Err = err
+				page.Result = &ocr.Result{}
+			}
+			page.Number = i + 1
+			pages[i] = page
+			if opts.OnPage != nil {
+				opts.OnPage(page, int(done.Add(1)))
+			}
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	// A cancellation between pages stops the loop without failing a page.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// readPage reads one page, from its text layer when usable or with OCR.
+func readPage(ctx context.Context, engine ocr.Engine, imgPath string, textLayer *textLayerPage, opts pageOptions) (pageResult, error) {
+	width, height, err := imageSize(imgPath)
+	if err != nil {
+		return pageResult{}, err
+	}
+
+	page := pageResult{
+		ImagePath: imgPath,
+		Width:     width,
+		Height:    height,
+	}
+	if textLayer != nil && textLayer.usable() {
+		page.Method = pageMethodTextLayer
+		page.Result = textLayer.result(width, height)
+		return page, nil
+	}
+
+	page.Method = pageMethodOCR
+	page.Result, err = recognizePage(ctx, engine, imgPath, opts)
+	return page, err
+}
+
+// recognizePage runs the engine over a page image, preprocessed first when
+// opts asks for it. Word boxes are always in pixels of the page image.
+func recognizePage(ctx context.Context, engine ocr.Engine, imgPath string, opts pageOptions) (*ocr.Result, error) {
+	prep := opts.Preprocess
+	if len(prep.Steps) == 0 {
+		return engine.Recognize(ctx, imgPath, opts.OCR)
+	}
+
+	if slices.Contains(prep.Steps, preprocess.Orient) {
+		if detector, ok := engine.(ocr.OSDetector); ok {
+			osd, err := detector.DetectOSD(ctx, imgPath)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, err
+				}
+				// Not fatal, the page is OCR'd the way it is.
+				log.Printf("Detecting orientation of %s: %v", imgPath, err)
+			} else {
+				prep.Rotate = osd.Rotate
+			}
+		}
+	}
+
+	file, err := os.Open(imgPath)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(file)
+	file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", imgPath, err)
+	}
+
+	cleaned, err := preprocess.Apply(img, prep)
+	if err != nil {
+		return nil, fmt.Errorf("failed to preprocess %s: %w", imgPath, err)
+	}
+
+	cleanedPath, err := writeTempPNG(opts.TempDir, cleaned.Image)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(cleanedPath)
+
+	result, err := engine.Recognize(ctx, cleanedPath, opts.OCR)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds().Sub(img.Bounds().Min)
+	for i, word := range result.Words {
+		box := image.Rect(word.X, word.Y, word.X+word.Width, word.Y+word.Height)
+		box = cleaned.SourceRect(box).Intersect(bounds)
+		result.Words[i].X, result.Words[i].Y = box.Min.X, box.Min.Y
+		result.Words[i].Width, result.Words[i].Height = box.Dx(), box.Dy()
+	}
+	return result, nil
+}
+
+func writeTempPNG(dir string, img image.Image) (string, error) {
+	file, err := os.CreateTemp(dir, "ocr-page-*.png")
+	if err != nil {
+		return "", err
+	}
+
+	err = png.Encode(file, img)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write preprocessed image: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// joinPageText concatenates page texts the way extracted_texts.content stores
+// them.
+func joinPageText(pages []pageResult) string {
+	texts := make([]string, len(pages))
+	for i, page := range pages {
+		texts[i] = page.Text
+	}
+	return joinTexts(texts)
+}
+
+// joinTexts separates page texts by a blank line. Pages without text, failed
+// ones included, take no room.
+func joinTexts(texts []string) string {
+	var allText bytes.Buffer
+	for _, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		allText.WriteString(text)
+		allText.WriteString("\n\n")
+	}
+	return strings.TrimSpace(allText.String())
+}
+
+// preparePages renders every page of an upload to dir and, for PDFs, reads
+// its embedded text layer. Pages with a usable text layer skip OCR. They are
+// still rendered, the page images back previews and the searchable PDF.
+func preparePages(ctx context.Context, uploadPath, fileType, dir string) (images []string, textLayer []textLayerPage, err error) {
+	images, err = pageImages(ctx, uploadPath, fileType, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if fileType == fileTypePDF || fileType == "" {
+		textLayer, err = readTextLayer(ctx, uploadPath)
+		if err != nil {
+			// Not fatal, every page just goes through OCR.
+			log.Printf("Reading text layer of %s: %v", uploadPath, err)
+			textLayer = nil
+		} else if len(textLayer) != len(images) {
+			log.Printf("Text layer of %s has %d pages, expected %d, ignoring it", uploadPath, len(textLayer), len(images))
+			textLayer = nil
+		}
+	}
+	return images, textLayer, nil
+}
+
+// firstOCRPage returns the first page image that needs OCR, the sample the
+// document language is detected on, or false when the text layer covers
+// every page.
+func firstOCRPage(images []string, textLayer []textLayerPage) (string, bool) {
+	for i, imgPath := range images {
+		if i >= len(textLayer) || !textLayer[i].usable() {
+			return imgPath, true
+		}
+	}
+	return "", false
+}
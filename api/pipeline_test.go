@@ -0,0 +1,289 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yosa/ocr-golang-back/ocr"
+	"github.com/yosa/ocr-golang-back/preprocess"
+	"github.com/yosa/ocr-golang-back/storage"
+)
+
+func TestRecognizePages(t *testing.T) {
+	images := []string{"testdata/page-1.png", "testdata/page-2.png"}
+
+	pages, err := recognizePages(context.Background(), &ocr.Fake{}, images, nil, pageOptions{})
+	require.NoError(t, err)
+	require.Len(t, pages, 2)
+
+	require.Equal(t, 1, pages[0].Number)
+	require.Equal(t, pageMethodOCR, pages[0].Method)
+	require.Equal(t, "page-1", pages[0].Text)
+	require.Equal(t, 200, pages[0].Width)
+	require.Equal(t, 100, pages[0].Height)
+
+	require.Equal(t, 2, pages[1].Number)
+	require.Equal(t, "page-2", pages[1].Text)
+	require.Equal(t, 120, pages[1].Width)
+	require.Equal(t, 160, pages[1].Height)
+
+	require.Equal(t, "page-1\n\npage-2", joinPageText(pages))
+}
+
+func TestRecognizePagesPartialFailure(t *testing.T) {
+	images := []string{"testdata/page-1.png", "testdata/missing.png", "testdata/page-2.png"}
+
+	pages, err := recognizePages(context.Background(), &ocr.Fake{}, images, nil, pageOptions{Workers: 2})
+	require.NoError(t, err)
+	require.Len(t, pages, 3)
+
+	require.NoError(t, pages[0].Err)
+	require.Error(t, pages[1].Err)
+	require.NoError(t, pages[2].Err)
+
+	require.Equal(t, 2, pages[1].Number)
+	require.Equal(t, "testdata/missing.png", pages[1].ImagePath)
+	require.Empty(t, pages[1].Text)
+	require.Empty(t, pages[1].Words)
+
+	// The failed page leaves no gap in the document text.
+	require.Equal(t, "page-1\n\npage-2", joinPageText(pages))
+}
+
+// pathEngine is a Fake that records the image it last read.
+type pathEngine struct {
+	ocr.Fake
+	path string
+}
+
+func (e *pathEngine) Recognize(ctx context.Context, imagePath string, opts ocr.Options) (*ocr.Result, error) {
+	e.path = imagePath
+	return e.Fake.Recognize(ctx, imagePath, opts)
+}
+
+func TestRecognizePagePreprocessed(t *testing.T) {
+	opts := pageOptions{
+		Preprocess: preprocess.Options{
+			Steps:     []preprocess.Step{preprocess.Grayscale, preprocess.Normalize},
+			SourceDPI: 150,
+			TargetDPI: 300,
+		},
+		TempDir: t.TempDir(),
+	}
+
+	engine := &pathEngine{Fake: ocr.Fake{Text: "one two"}}
+	result, err := recognizePage(context.Background(), engine, "testdata/page-1.png", opts)
+	require.NoError(t, err)
+	require.Equal(t, "one two", result.Text)
+
+	// The preprocessed image is read from the scratch dir and removed after.
+	require.Equal(t, opts.TempDir, filepath.Dir(engine.path))
+	entries, err := os.ReadDir(opts.TempDir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	// Fake read a page twice as large, its boxes are scaled back to the
+	// 200x100 page image.
+	require.Equal(t, []ocr.Word{
+		{Text: "one", X: 0, Y: 0, Width: 100, Height: 16, Confidence: ocr.FakeConfidence},
+		{Text: "two", X: 100, Y: 0, Width: 100, Height: 16, Confidence: ocr.FakeConfidence},
+	}, result.Words)
+}
+
+// gatedEngine is a Fake whose recognitions wait for release, so tests can
+// see how many run at once. Each one sends on started when it begins and on
+// canceled when its context ends it.
+type gatedEngine struct {
+	ocr.Fake
+	started  chan struct{}
+	canceled chan struct{}
+	release  chan struct{}
+
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func newGatedEngine(pages int) *gatedEngine {
+	return &gatedEngine{
+		started:  make(chan struct{}, pages),
+		canceled: make(chan struct{}, pages),
+		release:  make(chan struct{}),
+	}
+}
+
+func (e *gatedEngine) Recognize(ctx context.Context, imagePath string, opts ocr.Options) (*ocr.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	n := e.inFlight.Add(1)
+	defer e.inFlight.Add(-1)
+	for peak := e.peak.Load(); n > peak; peak = e.peak.Load() {
+		if e.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	e.started <- struct{}{}
+
+	select {
+	case <-e.release:
+	case <-ctx.Done():
+		e.canceled <- struct{}{}
+		return nil, ctx.Err()
+	}
+	return e.Fake.Recognize(ctx, imagePath, opts)
+}
+
+func TestRecognizePagesParallel(t *testing.T) {
+	images := make([]string, 12)
+	for i := range images {
+		images[i] = []string{"testdata/page-1.png", "testdata/page-2.png"}[i%2]
+	}
+	engine := newGatedEngine(len(images))
+
+	type outcome struct {
+		pages []pageResult
+		err   error
+	}
+	result := make(chan outcome, 1)
+	go func() {
+		pages, err := recognizePages(context.Background(), engine, images, nil, pageOptions{Workers: 4})
+		result <- outcome{pages, err}
+	}()
+
+	// Four pages are read at once, none finishing before the others start.
+	for range 4 {
+		<-engine.started
+	}
+	require.EqualValues(t, 4, engine.inFlight.Load())
+	close(engine.release)
+
+	got := <-result
+	require.NoError(t, got.err)
+	require.EqualValues(t, 4, engine.peak.Load())
+
+	pages := got.pages
+	require.Len(t, pages, len(images))
+	for i, page := range pages {
+		require.Equal(t, i+1, page.Number)
+		require.Equal(t, images[i], page.ImagePath)
+		require.Equal(t, []string{"page-1", "page-2"}[i%2], page.Text)
+	}
+}
+
+func TestRecognizePagesOnPage(t *testing.T) {
+	images := []string{"testdata/page-1.png", "testdata/page-2.png", "testdata/missing.png"}
+
+	var mu sync.Mutex
+	var numbers, done []int
+	opts := pageOptions{
+		Workers: 2,
+		OnPage: func(page pageResult, n int) {
+			mu.Lock()
+			defer mu.Unlock()
+			numbers = append(numbers, page.Number)
+			done = append(done, n)
+		},
+	}
+	_, err := recognizePages(context.Background(), &ocr.Fake{}, images, nil, opts)
+	require.NoError(t, err)
+
+	// Failed pages are reported too, the count goes up once per page.
+	require.ElementsMatch(t, []int{1, 2, 3}, numbers)
+	require.ElementsMatch(t, []int{1, 2, 3}, done)
+}
+
+func TestRecognizePagesCanceled(t *testing.T) {
+	images := make([]string, 20)
+	for i := range images {
+		images[i] = "testdata/page-1.png"
+	}
+	engine := newGatedEngine(len(images))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := recognizePages(ctx, engine, images, nil, pageOptions{Workers: 2})
+		result <- err
+	}()
+
+	for range 2 {
+		<-engine.started
+	}
+	cancel()
+
+	// Both pages being read are stopped, and no other is started.
+	for range 2 {
+		<-engine.canceled
+	}
+	require.ErrorIs(t, <-result, context.Canceled)
+	require.Empty(t, engine.started)
+	require.EqualValues(t, 2, engine.peak.Load())
+}
+
+func TestPageImageStorage(t *testing.T) {
+	blobs, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	s := &Server{blobs: blobs}
+	ctx := context.Background()
+
+	page := pageResult{Number: 2, ImagePath: "testdata/page-2.png"}
+	require.NoError(t, s.storePageImage(ctx, "doc-1", page))
+
+	key := pageKey("doc-1", 2, ".png")
+	require.Equal(t, "pages/doc-1/page-2.png", key)
+	exists, err := s.pageImageExists(ctx, key)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	imgPath, err := s.fetchPageImage(ctx, key, t.TempDir())
+	require.NoError(t, err)
+	width, height, err := imageSize(imgPath)
+	require.NoError(t, err)
+	require.Equal(t, 120, width)
+	require.Equal(t, 160, height)
+
+	missing := pageKey("doc-1", 3, ".png")
+	exists, err = s.pageImageExists(ctx, missing)
+	require.NoError(t, err)
+	require.False(t, exists)
+	_, err = s.fetchPageImage(ctx, missing, t.TempDir())
+	require.ErrorIs(t, err, storage.ErrNotFound)
+
+	// Keys without a directory name page images in the upload dir.
+	exists, err = s.pageImageExists(ctx, "doc-1_page-3.png")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+// BenchmarkRecognizePages compares the serial loop, one worker, with the pool
+// on a 16 page document, each page taking 10ms to recognize.
+func BenchmarkRecognizePages(b *testing.B) {
+	images := make([]string, 16)
+	for i := range images {
+		images[i] = "testdata/page-1.png"
+	}
+	engine := &ocr.Fake{Delay: 10 * time.Millisecond}
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers-%d", workers), func(b *testing.B) {
+			opts := pageOptions{Workers: workers}
+			for i := 0; i < b.N; i++ {
+				if _, err := recognizePages(context.Background(), engine, images, nil, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.N*len(images))/b.Elapsed().Seconds(), "pages/s")
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	xdraw "golang.org/x/image/draw"
+
+	"github.com/yosa/ocr-golang-back/authz"
+	"github.com/yosa/ocr-golang-back/db"
+	"github.com/yosa/ocr-golang-back/storage"
+)
+
+// Page image sizes, the longest side of thumbnails and previews in pixels.
+// Only the first page gets a thumbnail, for document lists.
+const (
+	thumbnailSize = 256
+	previewSize   = 1024
+)
+
+const previewQuality = 80
+
+// Values of the size query parameter of GET /documents/:id/pages/:n/image.
+const (
+	imageSizeThumb   = "thumb"
+	imageSizePreview = "preview"
+)
+
+// previewKey returns the blob storage key of the preview of a page.
+func previewKey(docID string, pageNumber int) string {
+	return "previews/" + docID + "/page-" + strconv.Itoa(pageNumber) + ".jpg"
+}
+
+// thumbnailKey returns the blob storage key of the thumbnail of a document.
+func thumbnailKey(docID string) string {
+	return "thumbnails/" + docID + ".jpg"
+}
+
+// storePreviews renders a preview of every page image, and a thumbnail of the
+// first, to blob storage. Pages without one are served without, so failures
+// are logged rather than failing OCR.
+func (s *Server) storePreviews(ctx context.Context, docID string, images []string) {
+	for i, imgPath := range images {
+		if ctx.Err() != nil {
+			return
+		}
+
+		img, err := decodeImageFile(imgPath)
+		if err != nil {
+			log.Printf("Previewing page %d of %s: %v", i+1, docID, err)
+			continue
+		}
+
+		if err := s.putJPEG(ctx, previewKey(docID, i+1), fitImage(img, previewSize)); err != nil {
+			log.Printf("Storing preview of page %d of %s: %v", i+1, docID, err)
+		}
+		if i == 0 {
+			if err := s.putJPEG(ctx, thumbnailKey(docID), fitImage(img, thumbnailSize)); err != nil {
+				log.Printf("Storing thumbnail of %s: %v", docID, err)
+			}
+		}
+	}
+}
+
+func (s *Server) putJPEG(ctx context.Context, key string, img image.Image) error {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: previewQuality}); err != nil {
+		return err
+	}
+	return s.blobs.Put(ctx, key, &buf, int64(buf.Len()), "image/jpeg")
+}
+
+func decodeImageFile(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return img, nil
+}
+
+// fitImage scales img down to fit a size by size square, keeping its aspect
+// ratio. Images already small enough are returned as they are.
+func fitImage(img image.Image, size int) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w <= size && h <= size {
+		return img
+	}
+
+	if w >= h {
+		w, h = size, max(1, h*size/w)
+	} else {
+		w, h = max(1, w*size/h), size
+	}
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(out, out.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+	return out
+}
+
+type pageImageRequest struct {
+	Size string `form:"size" binding:"omitempty,oneof=thumb preview"`
+}
+
+// GetDocumentPageImage sends the preview of a page, or with size=thumb the
+// thumbnail of the document, which only page 1 has.
+func (s *Server) GetDocumentPageImage(ctx *gin.Context) {
+	var uri documentPageRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req pageImageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	document, ok := s.authorizeDocument(ctx, authz.ActionRead)
+	if !ok {
+		return
+	}
+
+	key, err := pageImageKey(document, uri.PageNumber, req.Size)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+		return
+	}
+
+	file, err := s.blobs.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			err := fmt.Errorf("no image of page %d yet", uri.PageNumber)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer file.Close()
+
+	ctx.Header("Content-Type", "image/jpeg")
+	ctx.Header("Cache-Control", "private, max-age=86400")
+	http.ServeContent(ctx.Writer, ctx.Request, "", document.UploadedAt.Time, file)
+}
+
+// pageImageKey returns the key of the image of a page in the given size,
+// previews by default.
+func pageImageKey(document db.Document, pageNumber int32, size string) (string, error) {
+	if size == imageSizeThumb {
+		if pageNumber != 1 {
+			return "", fmt.Errorf("only page 1 has a thumbnail")
+		}
+		return thumbnailKey(document.ID), nil
+	}
+	return previewKey(document.ID, int(pageNumber)), nil
+}
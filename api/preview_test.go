@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"image"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yosa/ocr-golang-back/db"
+	"github.com/yosa/ocr-golang-back/storage"
+)
+
+func TestFitImage(t *testing.T) {
+	wide := image.NewGray(image.Rect(0, 0, 2000, 500))
+	require.Equal(t, image.Rect(0, 0, 1000, 250), fitImage(wide, 1000).Bounds())
+
+	tall := image.NewGray(image.Rect(0, 0, 300, 1200))
+	require.Equal(t, image.Rect(0, 0, 64, 256), fitImage(tall, 256).Bounds())
+
+	// Small images aren't blown up.
+	small := image.NewGray(image.Rect(0, 0, 120, 160))
+	require.Same(t, small, fitImage(small, 256))
+}
+
+func TestStorePreviews(t *testing.T) {
+	blobs, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	s := &Server{blobs: blobs}
+
+	images := []string{"testdata/page-1.png", "testdata/missing.png", "testdata/page-2.png"}
+	s.storePreviews(context.Background(), "doc-1", images)
+
+	decode := func(key string) image.Image {
+		file, err := blobs.Get(context.Background(), key)
+		require.NoError(t, err)
+		defer file.Close()
+		img, err := jpeg.Decode(file)
+		require.NoError(t, err)
+		return img
+	}
+
+	// The test pages are smaller than previews, they keep their size.
+	require.Equal(t, image.Rect(0, 0, 200, 100), decode(previewKey("doc-1", 1)).Bounds())
+	require.Equal(t, image.Rect(0, 0, 120, 160), decode(previewKey("doc-1", 3)).Bounds())
+	require.Equal(t, image.Rect(0, 0, 200, 100), decode(thumbnailKey("doc-1")).Bounds())
+
+	// A page that can't be read is skipped, the others still get previews.
+	_, err = blobs.Get(context.Background(), previewKey("doc-1", 2))
+	require.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestPageImageKey(t *testing.T) {
+	document := db.Document{ID: "doc-1"}
+
+	key, err := pageImageKey(document, 3, "")
+	require.NoError(t, err)
+	require.Equal(t, "previews/doc-1/page-3.jpg", key)
+
+	key, err = pageImageKey(document, 3, imageSizePreview)
+	require.NoError(t, err)
+	require.Equal(t, "previews/doc-1/page-3.jpg", key)
+
+	key, err = pageImageKey(document, 1, imageSizeThumb)
+	require.NoError(t, err)
+	require.Equal(t, "thumbnails/doc-1.jpg", key)
+
+	_, err = pageImageKey(document, 2, imageSizeThumb)
+	require.Error(t, err)
+}
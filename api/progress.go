@@ -0,0 +1,122 @@
+package api
+
+import (
+	"slices"
+	"sync"
+)
+
+// Progress event types, also the SSE event names.
+const (
+	// eventConverted: the upload was turned into page images.
+	eventConverted = "converted"
+	// eventPage: a page was read, or failed to be.
+	eventPage = "page"
+	// eventFinished: the document is ready or partial.
+	eventFinished = "finished"
+	// eventFailed: the job failed for good.
+	eventFailed = "failed"
+)
+
+// progressEvent reports how the OCR of a document is going.
+type progressEvent struct {
+	Type       string `json:"type"`
+	DocumentID string `json:"document_id"`
+	// Page is the page number of page events and of page retry jobs.
+	Page int `json:"page,omitempty"`
+	// Done counts the pages read so far out of Pages.
+	Done  int `json:"done,omitempty"`
+	Pages int `json:"pages,omitempty"`
+	// Status is the page status of page events, the document status of
+	// finished events.
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	// result is the page read, for subscribers that want its text.
+	result *pageResult
+}
+
+// final reports whether no event follows e for the current job.
+func (e progressEvent) final() bool {
+	return e.Type == eventFinished || e.Type == eventFailed
+}
+
+// progressBuffer is how many events a subscriber may lag behind.
+const progressBuffer = 64
+
+// progressHub fans out the progress events of the workers to the clients
+// following a document. It lives in memory: clients have to be connected to
+// the instance running the job.
+type progressHub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan progressEvent]struct{}
+	// running holds the events of the jobs in progress, so clients joining
+	// late can catch up. Pages aren't stored until the whole document is read.
+	running map[string][]progressEvent
+}
+
+func newProgressHub() *progressHub {
+	return &progressHub{
+		subscribers: make(map[string]map[chan progressEvent]struct{}),
+		running:     make(map[string][]progressEvent),
+	}
+}
+
+// subscribe returns the events published so far by the running job of docID,
+// if any, and a channel of the events to come. The channel is closed by
+// unsubscribe, or by the hub when the subscriber falls too far behind.
+func (h *progressHub) subscribe(docID string) (past []progressEvent, events <-chan progressEvent, unsubscribe func()) {
+	ch := make(chan progressEvent, progressBuffer)
+
+	h.mu.Lock()
+	past = slices.Clone(h.running[docID])
+	if h.subscribers[docID] == nil {
+		h.subscribers[docID] = make(map[chan progressEvent]struct{})
+	}
+	h.subscribers[docID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return past, ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(docID, ch)
+	}
+}
+
+// publish sends event to the subscribers of its document without waiting. A
+// subscriber whose buffer is full is dropped rather than slowing OCR down,
+// its channel is closed so it can tell and have its client reconnect.
+func (h *progressHub) publish(event progressEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch {
+	case event.final():
+		delete(h.running, event.DocumentID)
+	case event.Type == eventConverted:
+		// A new attempt at the document starts over.
+		h.running[event.DocumentID] = []progressEvent{event}
+	default:
+		h.running[event.DocumentID] = append(h.running[event.DocumentID], event)
+	}
+
+	for ch := range h.subscribers[event.DocumentID] {
+		select {
+		case ch <- event:
+		default:
+			h.remove(event.DocumentID, ch)
+		}
+	}
+}
+
+// remove must be called with h.mu held.
+func (h *progressHub) remove(docID string, ch chan progressEvent) {
+	subscribers := h.subscribers[docID]
+	if _, ok := subscribers[ch]; !ok {
+		return
+	}
+	delete(subscribers, ch)
+	close(ch)
+	if len(subscribers) == 0 {
+		delete(h.subscribers, docID)
+	}
+}
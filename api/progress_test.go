@@ -0,0 +1,90 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yosa/ocr-golang-back/db"
+)
+
+func TestProgressHub(t *testing.T) {
+	hub := newProgressHub()
+
+	_, events1, unsubscribe1 := hub.subscribe("doc-1")
+	_, events2, unsubscribe2 := hub.subscribe("doc-1")
+	_, other, unsubscribeOther := hub.subscribe("doc-2")
+	defer unsubscribeOther()
+
+	hub.publish(progressEvent{Type: eventConverted, DocumentID: "doc-1", Pages: 2})
+	require.Equal(t, progressEvent{Type: eventConverted, DocumentID: "doc-1", Pages: 2}, <-events1)
+	require.Equal(t, progressEvent{Type: eventConverted, DocumentID: "doc-1", Pages: 2}, <-events2)
+	require.Empty(t, other)
+
+	unsubscribe1()
+	_, ok := <-events1
+	require.False(t, ok)
+	// Unsubscribing twice is harmless.
+	unsubscribe1()
+
+	hub.publish(progressEvent{Type: eventFinished, DocumentID: "doc-1", Status: documentStatusReady})
+	event := <-events2
+	require.True(t, event.final())
+
+	unsubscribe2()
+	require.NotContains(t, hub.subscribers, "doc-1")
+}
+
+func TestProgressHubSlowSubscriber(t *testing.T) {
+	hub := newProgressHub()
+	_, events, unsubscribe := hub.subscribe("doc-1")
+	defer unsubscribe()
+
+	for i := 0; i <= progressBuffer; i++ {
+		hub.publish(progressEvent{Type: eventPage, DocumentID: "doc-1", Page: i + 1})
+	}
+
+	// The buffered events are still delivered, then the channel is closed.
+	for i := 0; i < progressBuffer; i++ {
+		event, ok := <-events
+		require.True(t, ok)
+		require.Equal(t, i+1, event.Page)
+	}
+	_, ok := <-events
+	require.False(t, ok)
+}
+
+func TestProgressHubRunning(t *testing.T) {
+	hub := newProgressHub()
+
+	converted := progressEvent{Type: eventConverted, DocumentID: "doc-1", Pages: 2}
+	page := progressEvent{Type: eventPage, DocumentID: "doc-1", Page: 2, Done: 1, Pages: 2}
+	hub.publish(progressEvent{Type: eventPage, DocumentID: "doc-1", Page: 1})
+	hub.publish(converted)
+	hub.publish(page)
+
+	// A late subscriber gets what the running attempt published.
+	past, _, unsubscribe := hub.subscribe("doc-1")
+	unsubscribe()
+	require.Equal(t, []progressEvent{converted, page}, past)
+
+	hub.publish(progressEvent{Type: eventFinished, DocumentID: "doc-1", Status: documentStatusReady})
+	past, _, unsubscribe = hub.subscribe("doc-1")
+	unsubscribe()
+	require.Empty(t, past)
+}
+
+func TestJobOutcomeEvent(t *testing.T) {
+	document := db.Document{ID: "doc-1", Status: documentStatusPartial}
+
+	event := jobOutcomeEvent(document, db.OcrJob{Status: "succeeded"})
+	require.Equal(t, progressEvent{Type: eventFinished, DocumentID: "doc-1", Status: documentStatusPartial}, event)
+
+	event = jobOutcomeEvent(document, db.OcrJob{
+		Status:     "failed",
+		PageNumber: pgtype.Int4{Int32: 3, Valid: true},
+		Error:      pgtype.Text{String: "page 3: boom", Valid: true},
+	})
+	require.Equal(t, progressEvent{Type: eventFailed, DocumentID: "doc-1", Page: 3, Error: "page 3: boom"}, event)
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yosa/ocr-golang-back/db"
+)
+
+type searchDocumentsRequest struct {
+	Query  string `form:"q"      binding:"required"`
+	Limit  int32  `form:"limit"  binding:"omitempty,min=1,max=100"`
+	Offset int32  `form:"offset" binding:"omitempty,min=0"`
+	// FolderID and Tags narrow the search like they narrow GET /documents.
+	FolderID string   `form:"folder_id"`
+	Tags     []string `form:"tag"`
+}
+
+// SearchDocuments runs a full-text search over the authenticated user's
+// extracted texts, or those of a folder shared with them. q takes web search
+// syntax: quoted phrases, OR and -word.
+func (s *Server) SearchDocuments(ctx *gin.Context) {
+	var req searchDocumentsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 20
+	}
+
+	owner, ok := s.listingOwner(ctx, req.FolderID)
+	if !ok {
+		return
+	}
+
+	results, err := s.queries.SearchDocuments(ctx, db.SearchDocumentsParams{
+		UserID:      owner,
+		Query:       req.Query,
+		LimitCount:  req.Limit,
+		OffsetCount: req.Offset,
+		FolderID:    optionalText(req.FolderID),
+		Tags:        req.Tags,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if results == nil {
+		results = []db.SearchDocumentsRow{}
+	}
+	ctx.JSON(http.StatusOK, results)
+}
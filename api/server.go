@@ -1,23 +1,32 @@
 package api
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/yosa/ocr-golang-back/authz"
 	"github.com/yosa/ocr-golang-back/db"
+	"github.com/yosa/ocr-golang-back/ocr"
+	"github.com/yosa/ocr-golang-back/storage"
 	"github.com/yosa/ocr-golang-back/token"
 	"github.com/yosa/ocr-golang-back/util"
 )
 
 type Server struct {
-	queries    *db.Queries
+	queries    db.Querier
 	config     util.Config
 	tokenMaker token.Maker
 	router     *gin.Engine
+	engine     ocr.Engine
+	blobs      storage.Blob
+	jobQueued  chan struct{}
+	progress   *progressHub
+	policy     *authz.Policy
 }
 
-func NewServer(config util.Config, queries *db.Queries) (*Server, error) {
+func NewServer(config util.Config, queries db.Querier, engine ocr.Engine, blobs storage.Blob) (*Server, error) {
 	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
 	if err != nil {
 		return nil, fmt.Errorf("Cannot create token maker: %w", err)
@@ -26,21 +35,64 @@ func NewServer(config util.Config, queries *db.Queries) (*Server, error) {
 		config:     config,
 		queries:    queries,
 		tokenMaker: tokenMaker,
+		engine:     engine,
+		blobs:      blobs,
+		jobQueued:  make(chan struct{}, 1),
+		progress:   newProgressHub(),
+		policy:     authz.NewPolicy(queries),
 	}
 	router := gin.Default()
 	// Users Endpoints
 	router.POST("/users", server.CreateUserHandler)
 	router.POST("/users/login", server.LoginUser)
 	router.POST("/tokens/renew_access", server.renewAccessToken)
+	router.GET("/s/:token", server.OpenShareLink)
+	router.GET("/documents/:id/live", liveAuthMiddleware(server.tokenMaker), server.StreamLiveResults)
 	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	authRoutes.PATCH("/users/me", server.UpdateCurrentUser)
+	authRoutes.POST("/tokens/live", server.CreateLiveToken)
+	authRoutes.GET("/ocr/languages", server.ListOCRLanguages)
 	// Documents endpoint
 	authRoutes.POST("/documents/upload", server.UploadDocument)
-	authRoutes.GET("/documents", server.FetchDocuments)
+	authRoutes.GET("/documents", server.ListDocuments)
+	authRoutes.GET("/documents/search", server.SearchDocuments)
+	authRoutes.GET("/documents/:id", server.GetDocument)
+	authRoutes.PATCH("/documents/:id", server.UpdateDocument)
+	authRoutes.DELETE("/documents/:id", server.DeleteDocument)
+	authRoutes.POST("/documents/:id/move", server.MoveDocument)
+	authRoutes.POST("/documents/:id/tags", server.AddDocumentTags)
+	authRoutes.DELETE("/documents/:id/tags/:tag", server.RemoveDocumentTag)
+	authRoutes.POST("/documents/:id/shares", server.ShareDocument)
+	authRoutes.GET("/documents/:id/shares", server.ListDocumentShares)
+	authRoutes.DELETE("/documents/:id/shares/:username", server.RevokeDocumentShare)
+	authRoutes.POST("/documents/:id/links", server.CreateShareLink)
+	authRoutes.GET("/documents/:id/links", server.ListShareLinks)
+	authRoutes.DELETE("/documents/:id/links/:link_id", server.RevokeShareLink)
+	authRoutes.GET("/documents/:id/status", server.GetDocumentStatus)
+	authRoutes.GET("/documents/:id/events", server.StreamDocumentEvents)
+	authRoutes.GET("/documents/:id/pages/:n", server.GetDocumentPage)
+	authRoutes.GET("/documents/:id/pages/:n/words", server.GetDocumentPageWords)
+	authRoutes.GET("/documents/:id/pages/:n/image", server.GetDocumentPageImage)
+	authRoutes.POST("/documents/:id/pages/:n/retry", server.RetryDocumentPage)
+	authRoutes.GET("/documents/:id/file", server.DownloadDocumentFile)
+	authRoutes.GET("/documents/:id/export", server.ExportDocument)
+	authRoutes.GET("/documents/:id/searchable.pdf", server.GetSearchablePDF)
+	// Folders endpoint
+	authRoutes.POST("/folders", server.CreateFolder)
+	authRoutes.GET("/folders", server.ListFolders)
+	authRoutes.PATCH("/folders/:id", server.RenameFolder)
+	authRoutes.POST("/folders/:id/move", server.MoveFolder)
+	authRoutes.POST("/folders/:id/shares", server.ShareFolder)
+	authRoutes.GET("/folders/:id/shares", server.ListFolderShares)
+	authRoutes.DELETE("/folders/:id/shares/:username", server.RevokeFolderShare)
+	authRoutes.GET("/shared-with-me", server.ListSharedWithMe)
 	server.router = router
 	return server, nil
 }
 
+// Start launches the OCR worker pool and then serves HTTP on address.
 func (s *Server) Start(address string) error {
+	s.startWorkers(context.Background())
 	return s.router.Run(address)
 }
 
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/yosa/ocr-golang-back/authz"
+	"github.com/yosa/ocr-golang-back/db"
+	"github.com/yosa/ocr-golang-back/token"
+)
+
+var errShareNotFound = errors.New("share not found")
+
+type shareRequest struct {
+	Username string     `json:"username" binding:"required"`
+	Role     authz.Role `json:"role"     binding:"required,oneof=viewer editor"`
+}
+
+// checkShareTarget checks req shares with a registered user other than
+// owner. On failure the error response is already written and ok is false.
+func (s *Server) checkShareTarget(ctx *gin.Context, req shareRequest, owner string) (ok bool) {
+	if req.Username == owner {
+		ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("the owner can't be given a share")))
+		return false
+	}
+	if _, err := s.queries.GetUserByUsername(ctx, req.Username); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("user not found")))
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	return true
+}
+
+// ShareDocument gives a user the viewer or editor role on a document, or
+// changes the role given before.
+func (s *Server) ShareDocument(ctx *gin.Context) {
+	document, ok := s.authorizeDocument(ctx, authz.ActionShare)
+	if !ok {
+		return
+	}
+
+	var req shareRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !s.checkShareTarget(ctx, req, document.UserID) {
+		return
+	}
+
+	share, err := s.queries.UpsertDocumentShare(ctx, db.UpsertDocumentShareParams{
+		DocumentID: document.ID,
+		Username:   req.Username,
+		Role:       string(req.Role),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, share)
+}
+
+func (s *Server) ListDocumentShares(ctx *gin.Context) {
+	document, ok := s.authorizeDocument(ctx, authz.ActionShare)
+	if !ok {
+		return
+	}
+
+	shares, err := s.queries.ListDocumentShares(ctx, document.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if shares == nil {
+		shares = []db.DocumentShare{}
+	}
+	ctx.JSON(http.StatusOK, shares)
+}
+
+// RevokeDocumentShare takes back the role given to a user on a document. The
+// user keeps the roles given by shares of folders the document is in.
+func (s *Server) RevokeDocumentShare(ctx *gin.Context) {
+	document, ok := s.authorizeDocument(ctx, authz.ActionShare)
+	if !ok {
+		return
+	}
+
+	deleted, err := s.queries.DeleteDocumentShare(ctx, db.DeleteDocumentShareParams{
+		DocumentID: document.ID,
+		Username:   ctx.Param("username"),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if deleted == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(errShareNotFound))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// ShareFolder gives a user the viewer or editor role on a folder, its
+// documents and its subfolders, or changes the role given before.
+func (s *Server) ShareFolder(ctx *gin.Context) {
+	folder, ok := s.authorizeFolder(ctx, ctx.Param("id"), authz.ActionShare)
+	if !ok {
+		return
+	}
+
+	var req shareRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !s.checkShareTarget(ctx, req, folder.UserID) {
+		return
+	}
+
+	share, err := s.queries.UpsertFolderShare(ctx, db.UpsertFolderShareParams{
+		FolderID: folder.ID,
+		Username: req.Username,
+		Role:     string(req.Role),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, share)
+}
+
+func (s *Server) ListFolderShares(ctx *gin.Context) {
+	folder, ok := s.authorizeFolder(ctx, ctx.Param("id"), authz.ActionShare)
+	if !ok {
+		return
+	}
+
+	shares, err := s.queries.ListFolderShares(ctx, folder.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if shares == nil {
+		shares = []db.FolderShare{}
+	}
+	ctx.JSON(http.StatusOK, shares)
+}
+
+// RevokeFolderShare takes back the role given to a user on a folder. The user
+// keeps the roles given by shares of its ancestors.
+func (s *Server) RevokeFolderShare(ctx *gin.Context) {
+	folder, ok := s.authorizeFolder(ctx, ctx.Param("id"), authz.ActionShare)
+	if !ok {
+		return
+	}
+
+	deleted, err := s.queries.DeleteFolderShare(ctx, db.DeleteFolderShareParams{
+		FolderID: folder.ID,
+		Username: ctx.Param("username"),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if deleted == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(errShareNotFound))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+type sharedWithMeResponse struct {
+	Documents []db.ListDocumentsSharedWithUserRow `json:"documents"`
+	Folders   []db.ListFoldersSharedWithUserRow   `json:"folders"`
+}
+
+// ListSharedWithMe returns the documents and folders shared with the
+// authenticated user, with the role given. The content of shared folders is
+// listed through GET /folders and GET /documents with their folder id.
+func (s *Server) ListSharedWithMe(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	documents, err := s.queries.ListDocumentsSharedWithUser(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	folders, err := s.queries.ListFoldersSharedWithUser(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := sharedWithMeResponse{Documents: documents, Folders: folders}
+	if rsp.Documents == nil {
+		rsp.Documents = []db.ListDocumentsSharedWithUserRow{}
+	}
+	if rsp.Folders == nil {
+		rsp.Folders = []db.ListFoldersSharedWithUserRow{}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yosa/ocr-golang-back/authz"
+	"github.com/yosa/ocr-golang-back/db"
+)
+
+type addDocumentTagsRequest struct {
+	// Tags are free-form, without slashes so they fit in a URL path.
+	Tags []string `json:"tags" binding:"required,min=1,max=20,dive,required,max=64,excludes=/"`
+}
+
+// AddDocumentTags attaches tags to a document and returns all of its tags.
+// Attaching a tag the document already has does nothing.
+func (s *Server) AddDocumentTags(ctx *gin.Context) {
+	document, ok := s.authorizeDocument(ctx, authz.ActionEdit)
+	if !ok {
+		return
+	}
+
+	var req addDocumentTagsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	for _, tag := range req.Tags {
+		err := s.queries.AddDocumentTag(ctx, db.AddDocumentTagParams{DocumentID: document.ID, Tag: tag})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	tags, err := s.queries.ListDocumentTags(ctx, document.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// RemoveDocumentTag detaches a tag from a document, whether it had it or not.
+func (s *Server) RemoveDocumentTag(ctx *gin.Context) {
+	document, ok := s.authorizeDocument(ctx, authz.ActionEdit)
+	if !ok {
+		return
+	}
+
+	err := s.queries.RemoveDocumentTag(ctx, db.RemoveDocumentTagParams{DocumentID: document.ID, Tag: ctx.Param("tag")})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/yosa/ocr-golang-back/ocr"
+)
+
+// How a page's text was obtained, stored in document_pages.method.
+const (
+	pageMethodOCR       = "ocr"
+	pageMethodTextLayer = "text_layer"
+)
+
+// A text layer is only trusted when it has some substance and mostly decodes
+// to real characters. PDFs with broken font encodings extract as control
+// characters and replacement runes, those pages are better off OCR'd.
+const (
+	minTextLayerChars    = 20
+	minTextLayerValidity = 0.9
+)
+
+// textLayerPage is the embedded text of one PDF page as reported by
+// pdftotext -bbox-layout, in PDF points.
+type textLayerPage struct {
+	Width  float64          `xml:"width,attr"`
+	Height float64          `xml:"height,attr"`
+	Blocks []textLayerBlock `xml:"flow>block"`
+}
+
+type textLayerBlock struct {
+	Lines []textLayerLine `xml:"line"`
+}
+
+type textLayerLine struct {
+	Words []textLayerWord `xml:"word"`
+}
+
+type textLayerWord struct {
+	XMin float64 `xml:"xMin,attr"`
+	YMin float64 `xml:"yMin,attr"`
+	XMax float64 `xml:"xMax,attr"`
+	YMax float64 `xml:"yMax,attr"`
+	Text string  `xml:",chardata"`
+}
+
+// readTextLayer extracts the embedded text of every page of a PDF.
+func readTextLayer(ctx context.Context, pdfPath string) ([]textLayerPage, error) {
+	extractCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	cmd := exec.CommandContext(extractCtx, "pdftotext", "-bbox-layout", "-enc", "UTF-8", pdfPath, "-")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf(
+			"failed to extract pdf text: %w (stderr: %s)",
+			err,
+			stderr.String(),
+		)
+	}
+	return parseTextLayer(&stdout)
+}
+
+// parseTextLayer reads the XHTML pdftotext -bbox-layout writes.
+func parseTextLayer(r io.Reader) ([]textLayerPage, error) {
+	var doc struct {
+		Pages []textLayerPage `xml:"body>doc>page"`
+	}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse pdf text: %w", err)
+	}
+	return doc.Pages, nil
+}
+
+// usable reports whether the page's text layer can stand in for OCR.
+func (p textLayerPage) usable() bool {
+	var total, valid int
+	for _, block := range p.Blocks {
+		for _, line := range block.Lines {
+			for _, word := range line.Words {
+				for _, r := range word.Text {
+					if unicode.IsSpace(r) {
+						continue
+					}
+					total++
+					if r != unicode.ReplacementChar && unicode.IsGraphic(r) {
+						valid++
+					}
+				}
+			}
+		}
+	}
+	return total >= minTextLayerChars && float64(valid) >= minTextLayerValidity*float64(total)
+}
+
+// result converts the text layer to an OCR result in pixels of the page
+// image, so words line up with the image the same way OCR'd words do.
+// Embedded text is exact, its confidence is 100.
+func (p textLayerPage) result(width, height int) *ocr.Result {
+	scaleX, scaleY := float64(renderDPI)/72, float64(renderDPI)/72
+	if p.Width > 0 && p.Height > 0 {
+		scaleX, scaleY = float64(width)/p.Width, float64(height)/p.Height
+	}
+
+	var words []ocr.Word
+	var text strings.Builder
+	line := 0
+	for i, block := range p.Blocks {
+		if i > 0 {
+			text.WriteString("\n\n")
+		}
+		for j, l := range block.Lines {
+			if len(l.Words) == 0 {
+				continue
+			}
+			if j > 0 {
+				text.WriteString("\n")
+			}
+			for k, word := range l.Words {
+				if k > 0 {
+					text.WriteString(" ")
+				}
+				text.WriteString(word.Text)
+				words = append(words, ocr.Word{
+					Text:       word.Text,
+					Line:       line,
+					X:          int(word.XMin * scaleX),
+					Y:          int(word.YMin * scaleY),
+					Width:      int((word.XMax - word.XMin) * scaleX),
+					Height:     int((word.YMax - word.YMin) * scaleY),
+					Confidence: 100,
+				})
+			}
+			line++
+		}
+	}
+
+	return &ocr.Result{
+		Text:       strings.TrimSpace(text.String()),
+		Words:      words,
+		Confidence: ocr.MeanConfidence(words),
+	}
+}
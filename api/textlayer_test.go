@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yosa/ocr-golang-back/ocr"
+)
+
+func readTestTextLayer(t *testing.T) []textLayerPage {
+	file, err := os.Open("testdata/textlayer.html")
+	require.NoError(t, err)
+	defer file.Close()
+
+	pages, err := parseTextLayer(file)
+	require.NoError(t, err)
+	require.Len(t, pages, 3)
+	return pages
+}
+
+func TestParseTextLayer(t *testing.T) {
+	pages := readTestTextLayer(t)
+
+	page := pages[0]
+	require.Equal(t, 612.0, page.Width)
+	require.Equal(t, 792.0, page.Height)
+	require.Len(t, page.Blocks, 2)
+	require.Len(t, page.Blocks[0].Lines, 2)
+	require.Equal(t, "&128.50", page.Blocks[0].Lines[1].Words[1].Text)
+
+	require.Empty(t, pages[1].Blocks)
+}
+
+func TestTextLayerUsable(t *testing.T) {
+	pages := readTestTextLayer(t)
+
+	require.True(t, pages[0].usable())
+	require.False(t, pages[1].usable(), "no text")
+	require.False(t, pages[2].usable(), "undecodable text")
+}
+
+func TestTextLayerResult(t *testing.T) {
+	pages := readTestTextLayer(t)
+
+	// 612x792 points rendered at 150 DPI.
+	result := pages[0].result(1275, 1650)
+	require.Equal(t, "Invoice #1042\nTotal: &128.50\n\nThank-you-for-your-order", result.Text)
+	require.Equal(t, 100.0, result.Confidence)
+	require.Len(t, result.Words, 5)
+
+	require.Equal(t, ocr.Word{
+		Text: "Invoice", Line: 0, X: 150, Y: 150, Width: 100, Height: 25, Confidence: 100,
+	}, result.Words[0])
+	require.Equal(t, 1, result.Words[2].Line)
+	require.Equal(t, 2, result.Words[4].Line)
+}
+
+func TestRecognizePagesTextLayer(t *testing.T) {
+	images := []string{"testdata/page-1.png", "testdata/page-2.png"}
+	textLayer := readTestTextLayer(t)[:2]
+
+	pages, err := recognizePages(context.Background(), &ocr.Fake{}, images, textLayer, pageOptions{})
+	require.NoError(t, err)
+	require.Len(t, pages, 2)
+
+	require.Equal(t, pageMethodTextLayer, pages[0].Method)
+	require.Contains(t, pages[0].Text, "Invoice #1042")
+	require.Equal(t, 200, pages[0].Width)
+
+	// The second page has no text layer and is OCR'd.
+	require.Equal(t, pageMethodOCR, pages[1].Method)
+	require.Equal(t, "page-2", pages[1].Text)
+}
+
+func TestFirstOCRPage(t *testing.T) {
+	images := []string{"page-1.png", "page-2.png", "page-3.png"}
+	textLayer := readTestTextLayer(t)
+
+	sample, ok := firstOCRPage(images, textLayer)
+	require.True(t, ok)
+	require.Equal(t, "page-2.png", sample)
+
+	sample, ok = firstOCRPage(images, nil)
+	require.True(t, ok)
+	require.Equal(t, "page-1.png", sample)
+
+	_, ok = firstOCRPage(images[:1], textLayer[:1])
+	require.False(t, ok)
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+
+	"golang.org/x/image/tiff"
+)
+
+// maxTIFFPages bounds how many pages splitTIFF follows, so a corrupt IFD
+// chain can't keep it busy.
+const maxTIFFPages = 1000
+
+// splitTIFF decodes every page of a (possibly multi-page) TIFF, in order,
+// and calls fn with each, numbered from 1. Only one page is held at a time,
+// fn is done with it once it returns. An error from fn stops the split and is
+// returned.
+//
+// x/image/tiff only decodes the first image file directory (IFD). Each page is
+// decoded by overlaying the header's first-IFD offset with the offset of that
+// page's IFD, leaving the data untouched.
+func splitTIFF(r io.ReaderAt, size int64, fn func(number int, page image.Image) error) error {
+	var header [8]byte
+	if _, err := r.ReadAt(header[:], 0); err != nil {
+		return fmt.Errorf("failed to read TIFF header: %w", err)
+	}
+
+	order, err := tiffByteOrder(header)
+	if err != nil {
+		return err
+	}
+
+	pages := 0
+	seen := make(map[uint32]bool)
+	for offset := order.Uint32(header[4:]); offset != 0; {
+		if seen[offset] || pages == maxTIFFPages {
+			return fmt.Errorf("TIFF has a looping or too long IFD chain")
+		}
+		seen[offset] = true
+		pages++
+
+		patched := header
+		order.PutUint32(patched[4:], offset)
+		page, err := tiff.Decode(io.NewSectionReader(&headerOverlay{r, patched}, 0, size))
+		if err != nil {
+			return fmt.Errorf("failed to decode TIFF page %d: %w", pages, err)
+		}
+		if err := fn(pages, page); err != nil {
+			return err
+		}
+
+		offset, err = nextIFD(r, order, offset)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pages == 0 {
+		return fmt.Errorf("TIFF has no pages")
+	}
+	return nil
+}
+
+// tiffByteOrder returns the byte order a TIFF header says the file is in.
+func tiffByteOrder(header [8]byte) (binary.ByteOrder, error) {
+	switch string(header[:4]) {
+	case "II*\x00":
+		return binary.LittleEndian, nil
+	case "MM\x00*":
+		return binary.BigEndian, nil
+	}
+	return nil, fmt.Errorf("not a TIFF file")
+}
+
+// TIFF tags and field types tiffDPI reads.
+const (
+	tiffTagXResolution    = 282
+	tiffTagResolutionUnit = 296
+	tiffTypeShort         = 3
+	tiffTypeRational      = 5
+)
+
+// tiffDPI reads the horizontal resolution of the first page of a TIFF.
+func tiffDPI(r io.ReaderAt) float64 {
+	var header [8]byte
+	if _, err := r.ReadAt(header[:], 0); err != nil {
+		return 0
+	}
+	order, err := tiffByteOrder(header)
+	if err != nil {
+		return 0
+	}
+
+	offset := int64(order.Uint32(header[4:]))
+	var count [2]byte
+	if _, err := r.ReadAt(count[:], offset); err != nil {
+		return 0
+	}
+
+	var resolution float64
+	unit := uint16(2) // inches unless told otherwise
+	for i := range int64(order.Uint16(count[:])) {
+		var entry [12]byte
+		if _, err := r.ReadAt(entry[:], offset+2+i*12); err != nil {
+			return 0
+		}
+		tag, kind := order.Uint16(entry[:2]), order.Uint16(entry[2:4])
+		switch {
+		case tag == tiffTagXResolution && kind == tiffTypeRational:
+			var rational [8]byte
+			if _, err := r.ReadAt(rational[:], int64(order.Uint32(entry[8:]))); err != nil {
+				return 0
+			}
+			num, den := order.Uint32(rational[:4]), order.Uint32(rational[4:])
+			if den == 0 {
+				return 0
+			}
+			resolution = float64(num) / float64(den)
+		case tag == tiffTagResolutionUnit && kind == tiffTypeShort:
+			unit = order.Uint16(entry[8:10])
+		}
+	}
+
+	switch unit {
+	case 2:
+		return resolution
+	case 3: // centimeters
+		return resolution * 2.54
+	}
+	return 0
+}
+
+// nextIFD reads the offset of the IFD following the one at offset; 0 ends the chain.
+func nextIFD(r io.ReaderAt, order binary.ByteOrder, offset uint32) (uint32, error) {
+	var buf [4]byte
+	if _, err := r.ReadAt(buf[:2], int64(offset)); err != nil {
+		return 0, fmt.Errorf("failed to read TIFF IFD: %w", err)
+	}
+	entries := int64(order.Uint16(buf[:2]))
+
+	if _, err := r.ReadAt(buf[:], int64(offset)+2+entries*12); err != nil {
+		return 0, fmt.Errorf("failed to read TIFF IFD: %w", err)
+	}
+	return order.Uint32(buf[:]), nil
+}
+
+// headerOverlay reads through to r, except for the 8 header bytes.
+type headerOverlay struct {
+	r      io.ReaderAt
+	header [8]byte
+}
+
+func (o *headerOverlay) ReadAt(p []byte, off int64) (int, error) {
+	n, err := o.r.ReadAt(p, off)
+	if off < int64(len(o.header)) {
+		copy(p[:n], o.header[off:])
+	}
+	return n, err
+}
@@ -8,8 +8,33 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/yosa/ocr-golang-back/token"
 )
 
+// liveTokenDuration is how long a live token can be used to open a socket.
+// It only needs to outlive the handshake, being sent in the URL.
+const liveTokenDuration = time.Minute
+
+type liveTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateLiveToken hands out a token for the token query parameter of
+// GET /documents/:id/live, for browsers that can't authenticate the socket
+// with a header.
+func (s *Server) CreateLiveToken(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	liveToken, payload, err := s.tokenMaker.CreatePurposeToken(authPayload.Username, token.PurposeLive, liveTokenDuration)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, liveTokenResponse{Token: liveToken, ExpiresAt: payload.ExpiresAt.Time})
+}
+
 type renewAccessTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
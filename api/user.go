@@ -2,14 +2,18 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/yosa/ocr-golang-back/db"
+	"github.com/yosa/ocr-golang-back/ocr"
+	"github.com/yosa/ocr-golang-back/token"
 	"github.com/yosa/ocr-golang-back/util"
 )
 
@@ -20,19 +24,21 @@ type createUserParams struct {
 	Provider string `json:"provider"`
 }
 type userResponse struct {
-	Username  string    `json:"username"`
-	Email     string    `json:"email" `
-	Provider  string    `json:"provider"`
-	CreatedAt time.Time `json:"created_at"`
+	Username     string    `json:"username"`
+	Email        string    `json:"email" `
+	Provider     string    `json:"provider"`
+	OCRLanguages []string  `json:"ocr_languages"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 func newUserResponse(user db.User) userResponse {
 
 	return userResponse{
-		Username:  user.Username,
-		Email:     user.Email,
-		Provider:  user.Provider.String,
-		CreatedAt: user.CreatedAt.Time,
+		Username:     user.Username,
+		Email:        user.Email,
+		Provider:     user.Provider.String,
+		OCRLanguages: user.OcrLanguages,
+		CreatedAt:    user.CreatedAt.Time,
 	}
 }
 
@@ -155,3 +161,49 @@ func (s *Server) LoginUser(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, rsp)
 
 }
+
+type updateUserRequest struct {
+	OCRLanguages []string `json:"ocr_languages" binding:"required,min=1"`
+}
+
+// UpdateCurrentUser changes the authenticated user's preferences, for now the
+// languages their uploads fall back to when detection is inconclusive.
+func (s *Server) UpdateCurrentUser(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	var req updateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	languages := ocr.ParseLanguages(strings.Join(req.OCRLanguages, "+"))
+	if err := ocr.ValidateLanguages(s.engine, languages); err != nil {
+		if errors.Is(err, ocr.ErrUnsupportedLanguage) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	user, err := s.queries.UpdateUserOCRLanguages(ctx, db.UpdateUserOCRLanguagesParams{
+		Username:     authPayload.Username,
+		OcrLanguages: languages,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// ListOCRLanguages returns the languages uploads can be OCR'd in.
+func (s *Server) ListOCRLanguages(ctx *gin.Context) {
+	languages, err := s.engine.Languages()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"languages": languages})
+}
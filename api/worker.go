@@ -0,0 +1,653 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/yosa/ocr-golang-back/db"
+	"github.com/yosa/ocr-golang-back/ocr"
+	"github.com/yosa/ocr-golang-back/preprocess"
+	"github.com/yosa/ocr-golang-back/storage"
+)
+
+// errNoExtractableText marks documents OCR ran on successfully but produced
+// nothing. Retrying won't change the outcome, so the job fails right away.
+var errNoExtractableText = errors.New("no extractable text found in document")
+
+// errDocumentBusy refuses a job for a document that has one queued or
+// running: concurrent jobs would each rewrite its text and status.
+var errDocumentBusy = errors.New("document is being processed, try again once OCR is done")
+
+// Document statuses, stored in documents.status. A partial document has some
+// pages that failed, they can be retried one by one.
+const (
+	documentStatusProcessing = "processing"
+	documentStatusReady      = "ready"
+	documentStatusPartial    = "partial"
+	documentStatusFailed     = "failed"
+)
+
+// Page statuses, stored in document_pages.status.
+const (
+	pageStatusSucceeded = "succeeded"
+	pageStatusFailed    = "failed"
+)
+
+// enqueueOCRJob queues a job reading the whole of docID.
+func (s *Server) enqueueOCRJob(ctx context.Context, docID string) (db.OcrJob, error) {
+	return s.enqueueJob(ctx, db.CreateOCRJobParams{
+		ID:         uuid.New().String(),
+		DocumentID: docID,
+	})
+}
+
+// enqueuePageOCRJob queues a job reading again a page of docID from its
+// stored image.
+func (s *Server) enqueuePageOCRJob(ctx context.Context, docID string, pageNumber int32) (db.OcrJob, error) {
+	return s.enqueueJob(ctx, db.CreateOCRJobParams{
+		ID:         uuid.New().String(),
+		DocumentID: docID,
+		PageNumber: pgtype.Int4{Int32: pageNumber, Valid: true},
+	})
+}
+
+// enqueueJob queues a job and wakes an idle worker. It fails with
+// errDocumentBusy when the document has a job queued or running.
+func (s *Server) enqueueJob(ctx context.Context, arg db.CreateOCRJobParams) (db.OcrJob, error) {
+	job, err := s.queries.CreateOCRJob(ctx, arg)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return job, errDocumentBusy
+		}
+		return job, err
+	}
+
+	select {
+	case s.jobQueued <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// startWorkers launches the OCR worker pool. Workers run until ctx is done.
+func (s *Server) startWorkers(ctx context.Context) {
+	workers := s.config.OCRWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	s.requeueStaleJobs(ctx)
+	for i := 0; i < workers; i++ {
+		go s.runWorker(ctx)
+	}
+}
+
+// requeueStaleJobs puts back jobs left running by a worker that died, e.g.
+// because the server was restarted mid-document. A job is stale once it has
+// been running for longer than the job timeout.
+func (s *Server) requeueStaleJobs(ctx context.Context) {
+	cutoff := pgtype.Timestamp{Time: time.Now().Add(-s.jobTimeout()), Valid: true}
+	n, err := s.queries.RequeueStaleOCRJobs(ctx, cutoff)
+	if err != nil {
+		log.Printf("Failed to requeue stale OCR jobs: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("Requeued %d stale OCR jobs", n)
+	}
+}
+
+func (s *Server) runWorker(ctx context.Context) {
+	interval := s.config.OCRPollInterval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before going back to sleep.
+		for s.processNextJob(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.jobQueued:
+		}
+	}
+}
+
+// processNextJob claims one queued job and runs it. It reports whether a job
+// was claimed, so the caller knows whether to keep polling.
+func (s *Server) processNextJob(ctx context.Context) bool {
+	job, err := s.queries.ClaimOCRJob(ctx)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) && ctx.Err() == nil {
+			log.Printf("Failed to claim OCR job: %v", err)
+		}
+		return false
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, s.jobTimeout())
+	defer cancel()
+
+	if job.PageNumber.Valid {
+		err = s.processPage(jobCtx, job.DocumentID, job.PageNumber.Int32)
+	} else {
+		err = s.processDocument(jobCtx, job.DocumentID)
+	}
+	if err == nil {
+		if err := s.queries.CompleteOCRJob(ctx, job.ID); err != nil {
+			log.Printf("Failed to complete OCR job %s: %v", job.ID, err)
+		}
+		return true
+	}
+
+	log.Printf("OCR job %s (attempt %d) failed: %v", job.ID, job.Attempts, err)
+	jobErr := pgtype.Text{String: err.Error(), Valid: true}
+
+	if !errors.Is(err, errNoExtractableText) && job.Attempts < s.maxAttempts() {
+		err = s.queries.RetryOCRJob(ctx, db.RetryOCRJobParams{ID: job.ID, Error: jobErr})
+	} else {
+		err = s.queries.FailOCRJob(ctx, db.FailOCRJobParams{ID: job.ID, Error: jobErr})
+		// A failed page retry leaves the document as it was, its page can
+		// be retried again.
+		if !job.PageNumber.Valid {
+			s.setDocumentStatus(ctx, job.DocumentID, documentStatusFailed)
+			// Only documents predating blob storage have files here.
+			removeUpload(job.DocumentID)
+			cleanupPageImages(job.DocumentID)
+		}
+		s.progress.publish(progressEvent{
+			Type:       eventFailed,
+			DocumentID: job.DocumentID,
+			Page:       int(job.PageNumber.Int32),
+			Error:      jobErr.String,
+		})
+	}
+	if err != nil {
+		log.Printf("Failed to update OCR job %s: %v", job.ID, err)
+	}
+	return true
+}
+
+// processDocument runs OCR on an uploaded document and stores the result.
+// Pages that fail are stored as failed and make the document partial, the
+// job only fails when no page could be read.
+func (s *Server) processDocument(ctx context.Context, docID string) error {
+	document, err := s.queries.GetDocumentByID(ctx, docID)
+	if err != nil {
+		return fmt.Errorf("failed to load document: %w", err)
+	}
+	fileType := document.FileType.String
+
+	// The original and the page images are worked on in a scratch dir, what
+	// is kept goes to blob storage.
+	dir, err := s.scratchDir()
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	uploadPath, err := s.fetchOriginal(ctx, document, dir)
+	if err != nil {
+		return fmt.Errorf("failed to fetch original: %w", err)
+	}
+
+	// The resolution of the pages sizes them in searchable PDFs and lets
+	// preprocessing normalize them.
+	dpiValue := uploadDPI(uploadPath, fileType)
+	if dpi := (pgtype.Int4{Int32: int32(dpiValue), Valid: dpiValue > 0}); dpi != document.Dpi {
+		err := s.queries.SetDocumentDPI(ctx, db.SetDocumentDPIParams{ID: docID, Dpi: dpi})
+		if err != nil {
+			return fmt.Errorf("failed to store document resolution: %w", err)
+		}
+		document.Dpi = dpi
+	}
+
+	images, textLayer, err := preparePages(ctx, uploadPath, fileType, dir)
+	if err != nil {
+		return fmt.Errorf("OCR failed: %w", err)
+	}
+	s.progress.publish(progressEvent{Type: eventConverted, DocumentID: docID, Pages: len(images)})
+
+	// Previews are rendered while pages are read, so clients can show pages
+	// before their text is in.
+	var previews sync.WaitGroup
+	previews.Add(1)
+	go func() {
+		defer previews.Done()
+		s.storePreviews(ctx, docID, images)
+	}()
+	defer previews.Wait()
+
+	languages, err := s.documentLanguages(ctx, document, images, textLayer, dir)
+	if err != nil {
+		return fmt.Errorf("language detection failed: %w", err)
+	}
+
+	opts := pageOptions{
+		OCR:        ocr.Options{Languages: languages},
+		Preprocess: preprocessOptions(document),
+		Workers:    s.config.OCRPageWorkers,
+		TempDir:    dir,
+		OnPage: func(page pageResult, done int) {
+			s.publishPage(docID, page, done, len(images))
+		},
+	}
+	pages, err := recognizePages(ctx, s.engine, images, textLayer, opts)
+	if err != nil {
+		return fmt.Errorf("OCR failed: %w", err)
+	}
+
+	failed := 0
+	for _, page := range pages {
+		if page.Err != nil {
+			failed++
+		}
+	}
+	if failed == len(pages) {
+		return fmt.Errorf("all %d pages failed: %w", failed, pages[0].Err)
+	}
+
+	content := joinPageText(pages)
+	if content == "" && failed == 0 {
+		return errNoExtractableText
+	}
+
+	for _, page := range pages {
+		if err := s.storePageImage(ctx, docID, page); err != nil {
+			return fmt.Errorf("failed to store image of page %d: %w", page.Number, err)
+		}
+		if err := s.savePage(ctx, docID, page); err != nil {
+			return fmt.Errorf("failed to save page %d: %w", page.Number, err)
+		}
+	}
+
+	if err := s.saveDocumentText(ctx, docID, content); err != nil {
+		return err
+	}
+
+	// Clients told the document is ready expect its previews.
+	previews.Wait()
+
+	status := documentStatusReady
+	if failed > 0 {
+		status = documentStatusPartial
+	}
+	if err := s.queries.SetDocumentStatus(ctx, db.SetDocumentStatusParams{ID: docID, Status: status}); err != nil {
+		return fmt.Errorf("failed to update document status: %w", err)
+	}
+	s.progress.publish(progressEvent{Type: eventFinished, DocumentID: docID, Status: status})
+
+	if !document.StorageKey.Valid {
+		removeUpload(docID)
+	}
+	return nil
+}
+
+// scratchDir creates a directory for a job to work in. The job removes it
+// when done.
+func (s *Server) scratchDir() (string, error) {
+	dir, err := os.MkdirTemp(s.config.ScratchDir, "ocr-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create scratch dir: %w", err)
+	}
+	return dir, nil
+}
+
+// fetchOriginal copies the original of document from blob storage to dir,
+// where the conversion tools read it, and returns its path. Documents
+// uploaded before originals were kept in storage still have their upload in
+// the upload dir.
+func (s *Server) fetchOriginal(ctx context.Context, document db.Document, dir string) (string, error) {
+	if !document.StorageKey.Valid {
+		return uploadPathFor(document.ID, document.FileType.String), nil
+	}
+
+	src, err := s.blobs.Get(ctx, document.StorageKey.String)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	uploadPath := filepath.Join(dir, "original"+uploadExtension(document.FileType.String))
+	if err := writeFile(uploadPath, src); err != nil {
+		return "", err
+	}
+	return uploadPath, nil
+}
+
+// storePageImage keeps the image of page in blob storage, under the key
+// savePage stores it with.
+func (s *Server) storePageImage(ctx context.Context, docID string, page pageResult) error {
+	file, err := os.Open(page.ImagePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	ext := filepath.Ext(page.ImagePath)
+	return s.blobs.Put(ctx, pageKey(docID, page.Number, ext), file, info.Size(), mime.TypeByExtension(ext))
+}
+
+// openPageImage opens the stored image of a page, storage.ErrNotFound when
+// there is none.
+func (s *Server) openPageImage(ctx context.Context, imageKey string) (io.ReadCloser, error) {
+	if !legacyPageKey(imageKey) {
+		return s.blobs.Get(ctx, imageKey)
+	}
+
+	file, err := os.Open(filepath.Join(uploadDir, imageKey))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", storage.ErrNotFound, imageKey)
+	}
+	return file, err
+}
+
+// pageImageExists reports whether the stored image of a page is still there.
+func (s *Server) pageImageExists(ctx context.Context, imageKey string) (bool, error) {
+	file, err := s.openPageImage(ctx, imageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	file.Close()
+	return true, nil
+}
+
+// fetchPageImage copies the stored image of a page to dir, where the OCR
+// engine and the PDF writer read it, and returns its path.
+func (s *Server) fetchPageImage(ctx context.Context, imageKey, dir string) (string, error) {
+	src, err := s.openPageImage(ctx, imageKey)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	imgPath := filepath.Join(dir, path.Base(imageKey))
+	if err := writeFile(imgPath, src); err != nil {
+		return "", err
+	}
+	return imgPath, nil
+}
+
+// writeFile copies src to a new file at name, removed again if the copy
+// fails.
+func writeFile(name string, src io.Reader) error {
+	dst, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(name)
+	}
+	return err
+}
+
+// processPage reads a page again from its stored image, for page retries.
+// The document text and status are rebuilt from all of its pages after.
+func (s *Server) processPage(ctx context.Context, docID string, pageNumber int32) error {
+	document, err := s.queries.GetDocumentByID(ctx, docID)
+	if err != nil {
+		return fmt.Errorf("failed to load document: %w", err)
+	}
+
+	stored, err := s.queries.GetDocumentPage(ctx, db.GetDocumentPageParams{
+		DocumentID: docID,
+		PageNumber: pageNumber,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load page %d: %w", pageNumber, err)
+	}
+
+	if !stored.ImageKey.Valid {
+		return fmt.Errorf("page %d has no stored image", pageNumber)
+	}
+
+	dir, err := s.scratchDir()
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	imgPath, err := s.fetchPageImage(ctx, stored.ImageKey.String, dir)
+	if err != nil {
+		return fmt.Errorf("failed to fetch image of page %d: %w", pageNumber, err)
+	}
+
+	opts := pageOptions{
+		OCR:        ocr.Options{Languages: document.Languages},
+		Preprocess: preprocessOptions(document),
+		TempDir:    dir,
+	}
+	page, err := readPage(ctx, s.engine, imgPath, nil, opts)
+	if err != nil {
+		return fmt.Errorf("page %d: %w", pageNumber, err)
+	}
+	page.Number = int(pageNumber)
+
+	// Images of pages read before they were kept in storage move there.
+	if legacyPageKey(stored.ImageKey.String) {
+		if err := s.storePageImage(ctx, docID, page); err != nil {
+			return fmt.Errorf("failed to store image of page %d: %w", pageNumber, err)
+		}
+	}
+	if err := s.savePage(ctx, docID, page); err != nil {
+		return fmt.Errorf("failed to save page %d: %w", pageNumber, err)
+	}
+	s.publishPage(docID, page, 1, 1)
+
+	storedPages, err := s.queries.ListDocumentPages(ctx, docID)
+	if err != nil {
+		return fmt.Errorf("failed to load pages: %w", err)
+	}
+
+	texts := make([]string, 0, len(storedPages))
+	status := documentStatusReady
+	for _, p := range storedPages {
+		if p.Status == pageStatusFailed {
+			status = documentStatusPartial
+		}
+		texts = append(texts, p.Text)
+	}
+
+	if err := s.saveDocumentText(ctx, docID, joinTexts(texts)); err != nil {
+		return err
+	}
+	if err := s.queries.SetDocumentStatus(ctx, db.SetDocumentStatusParams{ID: docID, Status: status}); err != nil {
+		return fmt.Errorf("failed to update document status: %w", err)
+	}
+	s.progress.publish(progressEvent{Type: eventFinished, DocumentID: docID, Page: int(pageNumber), Status: status})
+	return nil
+}
+
+// saveDocumentText stores the whole-document text, kept in extracted_texts
+// for existing clients. Every save adds a row, the latest one is current
+// and the only one searched.
+func (s *Server) saveDocumentText(ctx context.Context, docID, content string) error {
+	_, err := s.queries.CreateExtractedText(ctx, db.CreateExtractedTextParams{
+		ID:         uuid.New().String(),
+		DocumentID: docID,
+		Content:    pgtype.Text{String: content, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save extracted text: %w", err)
+	}
+	return nil
+}
+
+// publishPage tells the clients following docID that page was read, done
+// pages out of pages so far.
+func (s *Server) publishPage(docID string, page pageResult, done, pages int) {
+	event := progressEvent{
+		Type:       eventPage,
+		DocumentID: docID,
+		Page:       page.Number,
+		Done:       done,
+		Pages:      pages,
+		Status:     pageStatusSucceeded,
+		result:     &page,
+	}
+	if page.Err != nil {
+		event.Status = pageStatusFailed
+		event.Error = page.Err.Error()
+	}
+	s.progress.publish(event)
+}
+
+func (s *Server) setDocumentStatus(ctx context.Context, docID, status string) {
+	err := s.queries.SetDocumentStatus(ctx, db.SetDocumentStatusParams{ID: docID, Status: status})
+	if err != nil {
+		log.Printf("Failed to set status of document %s: %v", docID, err)
+	}
+}
+
+// documentLanguages returns the languages to OCR document in. Uploads that
+// didn't name any get them detected on their first page needing OCR, falling
+// back to the owner's default when detection is inconclusive. The outcome is
+// stored on the document, so retries don't detect again. The detection
+// sample is written to dir.
+func (s *Server) documentLanguages(ctx context.Context, document db.Document, images []string, textLayer []textLayerPage, dir string) ([]string, error) {
+	if len(document.Languages) > 0 {
+		return document.Languages, nil
+	}
+
+	arg := db.SetDocumentLanguagesParams{ID: document.ID}
+
+	sample, ok := firstOCRPage(images, textLayer)
+	if ok {
+		opts := ocr.DetectOptions{
+			Candidates: ocr.ParseLanguages(s.config.OCRDetectLanguages),
+			TempDir:    dir,
+		}
+		detection, err := ocr.DetectLanguage(ctx, s.engine, sample, opts)
+		switch {
+		case err == nil:
+			arg.Languages = []string{detection.Language}
+			arg.DetectedLanguage = pgtype.Text{String: detection.Language, Valid: true}
+			arg.DetectedScript = pgtype.Text{String: detection.Script, Valid: detection.Script != ""}
+		case errors.Is(err, ocr.ErrLanguageUndetected):
+			log.Printf("Document %s: %v, using the owner's default", document.ID, err)
+		default:
+			return nil, err
+		}
+	}
+
+	if len(arg.Languages) == 0 {
+		user, err := s.queries.GetUserByUsername(ctx, document.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load document owner: %w", err)
+		}
+		arg.Languages = user.OcrLanguages
+	}
+
+	if err := s.queries.SetDocumentLanguages(ctx, arg); err != nil {
+		return nil, err
+	}
+	return arg.Languages, nil
+}
+
+// preprocessOptions returns the preprocessing asked for on upload. Page
+// images rendered from PDFs have a known resolution, scans only when their
+// metadata gives it.
+func preprocessOptions(document db.Document) preprocess.Options {
+	opts := preprocess.Options{Steps: make([]preprocess.Step, len(document.Preprocess))}
+	for i, step := range document.Preprocess {
+		opts.Steps[i] = preprocess.Step(step)
+	}
+
+	opts.SourceDPI = documentDPI(document)
+	return opts
+}
+
+// savePage stores a page and its words, replacing what a previous attempt
+// may have stored. Failed pages are stored with their error and no text.
+func (s *Server) savePage(ctx context.Context, docID string, page pageResult) error {
+	arg := db.UpsertDocumentPageParams{
+		DocumentID: docID,
+		PageNumber: int32(page.Number),
+		Text:       page.Text,
+		Confidence: page.Confidence,
+		Width:      int32(page.Width),
+		Height:     int32(page.Height),
+		ImageKey:   pgtype.Text{String: pageKey(docID, page.Number, filepath.Ext(page.ImagePath)), Valid: true},
+		Method:     page.Method,
+		Status:     pageStatusSucceeded,
+	}
+	if page.Err != nil {
+		arg.Status = pageStatusFailed
+		arg.Error = pgtype.Text{String: page.Err.Error(), Valid: true}
+	}
+
+	_, err := s.queries.UpsertDocumentPage(ctx, arg)
+	if err != nil {
+		return err
+	}
+
+	err = s.queries.DeleteDocumentPageWords(ctx, db.DeleteDocumentPageWordsParams{
+		DocumentID: docID,
+		PageNumber: int32(page.Number),
+	})
+	if err != nil {
+		return err
+	}
+
+	words := make([]db.CreateDocumentWordsParams, len(page.Words))
+	for i, word := range page.Words {
+		words[i] = db.CreateDocumentWordsParams{
+			DocumentID: docID,
+			PageNumber: int32(page.Number),
+			WordIndex:  int32(i),
+			LineNumber: int32(word.Line),
+			Text:       word.Text,
+			X:          int32(word.X),
+			Y:          int32(word.Y),
+			Width:      int32(word.Width),
+			Height:     int32(word.Height),
+			Confidence: word.Confidence,
+		}
+	}
+	_, err = s.queries.CreateDocumentWords(ctx, words)
+	return err
+}
+
+func (s *Server) jobTimeout() time.Duration {
+	if s.config.OCRJobTimeout <= 0 {
+		return 30 * time.Minute
+	}
+	return s.config.OCRJobTimeout
+}
+
+func (s *Server) maxAttempts() int32 {
+	if s.config.OCRMaxAttempts <= 0 {
+		return 1
+	}
+	return s.config.OCRMaxAttempts
+}
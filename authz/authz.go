@@ -0,0 +1,180 @@
+// Package authz decides what an authenticated user may do with a document or
+// a folder, so that every route asks the same question the same way.
+package authz
+
+import (
+	"context"
+	"errors"
+
+	"github.com/yosa/ocr-golang-back/db"
+)
+
+// Action is something a route does to a document or a folder.
+type Action string
+
+const (
+	// ActionRead covers reading a document, its text, pages, files and
+	// progress.
+	ActionRead Action = "read"
+	// ActionEdit covers renaming a document, changing its metadata, tags and
+	// folder and retrying its pages. On folders, it covers renaming and
+	// moving them and putting documents and folders in them. Moves that
+	// change the access folder shares give other users also need
+	// ActionShare.
+	ActionEdit Action = "edit"
+	// ActionDelete covers deleting a document.
+	ActionDelete Action = "delete"
+	// ActionShare covers sharing a document or a folder and revoking its
+	// shares.
+	ActionShare Action = "share"
+)
+
+// Role is how a user relates to a document or a folder.
+type Role string
+
+// Roles from the weakest to the strongest. Viewers and editors are given
+// their role by a share, of the document or of a folder it is in.
+const (
+	// RoleNone is the role of users who can't access a document.
+	RoleNone   Role = ""
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleOwner  Role = "owner"
+)
+
+var roleActions = map[Role][]Action{
+	RoleViewer: {ActionRead},
+	RoleEditor: {ActionRead, ActionEdit},
+	RoleOwner:  {ActionRead, ActionEdit, ActionDelete, ActionShare},
+}
+
+var roleRanks = map[Role]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+// Shareable reports whether the role can be given by a share.
+func (r Role) Shareable() bool {
+	return r == RoleViewer || r == RoleEditor
+}
+
+// Allows reports whether the role lets a user take action.
+func (r Role) Allows(action Action) bool {
+	for _, allowed := range roleActions[r] {
+		if allowed == action {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// ErrNoAccess is returned to users who can't access a document at all.
+	// Routes answer as if it didn't exist, so ids can't be probed.
+	ErrNoAccess = errors.New("document not found")
+	// ErrNoFolderAccess is ErrNoAccess for folders.
+	ErrNoFolderAccess = errors.New("folder not found")
+	// ErrForbidden is returned to users whose role doesn't allow the action.
+	ErrForbidden = errors.New("not allowed to do this")
+)
+
+// Grants looks up the roles shares give users.
+type Grants interface {
+	ListDocumentGrants(ctx context.Context, arg db.ListDocumentGrantsParams) ([]string, error)
+	ListFolderGrants(ctx context.Context, arg db.ListFolderGrantsParams) ([]string, error)
+}
+
+// Policy resolves the role of users on documents and folders.
+type Policy struct {
+	grants Grants
+}
+
+func NewPolicy(grants Grants) *Policy {
+	return &Policy{grants: grants}
+}
+
+// Outranks reports whether the role is stronger than other.
+func (r Role) Outranks(other Role) bool {
+	return roleRanks[r] > roleRanks[other]
+}
+
+// strongest returns the strongest of the roles given by shares.
+func strongest(roles []string) Role {
+	best := RoleNone
+	for _, role := range roles {
+		if Role(role).Outranks(best) {
+			best = Role(role)
+		}
+	}
+	return best
+}
+
+// DocumentRole returns the role of username on document: owner, or the
+// strongest role given by a share of the document or of a folder it is in.
+func (p *Policy) DocumentRole(ctx context.Context, username string, document db.Document) (Role, error) {
+	if username == "" {
+		return RoleNone, nil
+	}
+	if document.UserID == username {
+		return RoleOwner, nil
+	}
+
+	roles, err := p.grants.ListDocumentGrants(ctx, db.ListDocumentGrantsParams{
+		FolderID:   document.FolderID,
+		DocumentID: document.ID,
+		Username:   username,
+	})
+	if err != nil {
+		return RoleNone, err
+	}
+	return strongest(roles), nil
+}
+
+// AuthorizeDocument returns nil when username may take action on document,
+// ErrNoAccess or ErrForbidden when not.
+func (p *Policy) AuthorizeDocument(ctx context.Context, username string, document db.Document, action Action) error {
+	role, err := p.DocumentRole(ctx, username, document)
+	if err != nil {
+		return err
+	}
+	if role == RoleNone {
+		return ErrNoAccess
+	}
+	if !role.Allows(action) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// FolderRole returns the role of username on folder: owner, or the strongest
+// role given by a share of the folder or of one of its ancestors.
+func (p *Policy) FolderRole(ctx context.Context, username string, folder db.Folder) (Role, error) {
+	if username == "" {
+		return RoleNone, nil
+	}
+	if folder.UserID == username {
+		return RoleOwner, nil
+	}
+
+	roles, err := p.grants.ListFolderGrants(ctx, db.ListFolderGrantsParams{
+		FolderID: folder.ID,
+		Username: username,
+	})
+	if err != nil {
+		return RoleNone, err
+	}
+	return strongest(roles), nil
+}
+
+// AuthorizeFolder returns nil when username may take action on folder,
+// ErrNoFolderAccess or ErrForbidden when not.
+func (p *Policy) AuthorizeFolder(ctx context.Context, username string, folder db.Folder, action Action) error {
+	role, err := p.FolderRole(ctx, username, folder)
+	if err != nil {
+		return err
+	}
+	if role == RoleNone {
+		return ErrNoFolderAccess
+	}
+	if !role.Allows(action) {
+		return ErrForbidden
+	}
+	return nil
+}
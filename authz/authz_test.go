@@ -0,0 +1,113 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yosa/ocr-golang-back/db"
+)
+
+// fakeGrants shares doc-1 with carol as a viewer, and folder-1, with every
+// document and folder within, with dave as an editor. folder-2 is in folder-1.
+type fakeGrants struct{}
+
+var folderParents = map[string]string{"folder-2": "folder-1"}
+
+func (fakeGrants) ListDocumentGrants(ctx context.Context, arg db.ListDocumentGrantsParams) ([]string, error) {
+	var roles []string
+	if arg.DocumentID == "doc-1" && arg.Username == "carol" {
+		roles = append(roles, "viewer")
+	}
+	if arg.FolderID.Valid {
+		folderRoles, _ := fakeGrants{}.ListFolderGrants(ctx, db.ListFolderGrantsParams{FolderID: arg.FolderID.String, Username: arg.Username})
+		roles = append(roles, folderRoles...)
+	}
+	return roles, nil
+}
+
+func (fakeGrants) ListFolderGrants(ctx context.Context, arg db.ListFolderGrantsParams) ([]string, error) {
+	var roles []string
+	for id := arg.FolderID; id != ""; id = folderParents[id] {
+		if id == "folder-1" && arg.Username == "dave" {
+			roles = append(roles, "editor")
+		}
+	}
+	return roles, nil
+}
+
+func TestAuthorizeDocument(t *testing.T) {
+	document := db.Document{ID: "doc-1", UserID: "alice", FolderID: pgtype.Text{String: "folder-2", Valid: true}}
+	policy := NewPolicy(fakeGrants{})
+
+	testCases := []struct {
+		username string
+		want     map[Action]error
+	}{
+		{username: "alice", want: map[Action]error{}},
+		{username: "bob", want: map[Action]error{ActionRead: ErrNoAccess, ActionEdit: ErrNoAccess, ActionDelete: ErrNoAccess, ActionShare: ErrNoAccess}},
+		// Usernames are compared exactly.
+		{username: "Alice", want: map[Action]error{ActionRead: ErrNoAccess, ActionEdit: ErrNoAccess, ActionDelete: ErrNoAccess, ActionShare: ErrNoAccess}},
+		{username: "", want: map[Action]error{ActionRead: ErrNoAccess, ActionEdit: ErrNoAccess, ActionDelete: ErrNoAccess, ActionShare: ErrNoAccess}},
+		{username: "carol", want: map[Action]error{ActionEdit: ErrForbidden, ActionDelete: ErrForbidden, ActionShare: ErrForbidden}},
+		// Shared through the parent of the document's folder.
+		{username: "dave", want: map[Action]error{ActionDelete: ErrForbidden, ActionShare: ErrForbidden}},
+	}
+
+	for _, tc := range testCases {
+		for _, action := range []Action{ActionRead, ActionEdit, ActionDelete, ActionShare} {
+			t.Run(fmt.Sprintf("%s/%s", tc.username, action), func(t *testing.T) {
+				err := policy.AuthorizeDocument(context.Background(), tc.username, document, action)
+				if tc.want[action] == nil {
+					require.NoError(t, err)
+					return
+				}
+				require.ErrorIs(t, err, tc.want[action])
+			})
+		}
+	}
+}
+
+func TestAuthorizeFolder(t *testing.T) {
+	folder := db.Folder{ID: "folder-2", UserID: "alice"}
+	policy := NewPolicy(fakeGrants{})
+
+	require.NoError(t, policy.AuthorizeFolder(context.Background(), "alice", folder, ActionShare))
+	require.NoError(t, policy.AuthorizeFolder(context.Background(), "dave", folder, ActionEdit))
+	require.ErrorIs(t, policy.AuthorizeFolder(context.Background(), "dave", folder, ActionShare), ErrForbidden)
+	require.ErrorIs(t, policy.AuthorizeFolder(context.Background(), "bob", folder, ActionRead), ErrNoFolderAccess)
+	require.ErrorIs(t, policy.AuthorizeFolder(context.Background(), "", folder, ActionRead), ErrNoFolderAccess)
+}
+
+func TestRoleAllows(t *testing.T) {
+	require.True(t, RoleOwner.Allows(ActionRead))
+	require.True(t, RoleOwner.Allows(ActionEdit))
+	require.True(t, RoleOwner.Allows(ActionDelete))
+	require.True(t, RoleOwner.Allows(ActionShare))
+
+	require.True(t, RoleEditor.Allows(ActionEdit))
+	require.False(t, RoleEditor.Allows(ActionDelete))
+	require.True(t, RoleViewer.Allows(ActionRead))
+	require.False(t, RoleViewer.Allows(ActionEdit))
+
+	require.False(t, RoleNone.Allows(ActionRead))
+	require.False(t, RoleOwner.Allows(Action("publish")))
+}
+
+func TestStrongest(t *testing.T) {
+	require.Equal(t, RoleNone, strongest(nil))
+	require.Equal(t, RoleEditor, strongest([]string{"viewer", "editor", "viewer"}))
+	// Roles a share can't give are ignored.
+	require.Equal(t, RoleViewer, strongest([]string{"superuser", "viewer"}))
+}
+
+func TestOutranks(t *testing.T) {
+	require.True(t, RoleOwner.Outranks(RoleEditor))
+	require.True(t, RoleViewer.Outranks(RoleNone))
+	require.False(t, RoleViewer.Outranks(RoleViewer))
+	require.False(t, RoleEditor.Outranks(RoleOwner))
+	require.False(t, Role("superuser").Outranks(RoleNone))
+}
@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/yosa/ocr-golang-back/api"
 	"github.com/yosa/ocr-golang-back/db"
+	"github.com/yosa/ocr-golang-back/ocr/tesseract"
+	"github.com/yosa/ocr-golang-back/storage"
 	"github.com/yosa/ocr-golang-back/util"
 )
 
@@ -26,8 +29,18 @@ func main() {
 
 	queries := db.New(conn)
 
+	// Every document worker OCRs up to OCRPageWorkers pages at once, each
+	// with a Tesseract client of its own.
+	engine := tesseract.New(config.TessdataDir, config.OCRWorkers*config.OCRPageWorkers, "eng")
+	defer engine.Close()
+
+	blobs, err := newBlobStore(config)
+	if err != nil {
+		log.Fatalf("cannot open blob storage: %v", err)
+	}
+
 	// Create server
-	server, err := api.NewServer(config, queries)
+	server, err := api.NewServer(config, queries, engine, blobs)
 	if err != nil {
 		log.Fatalf("cannot create server: %v", err)
 	}
@@ -38,3 +51,22 @@ func main() {
 		log.Fatalf("cannot start server: %v", err)
 	}
 }
+
+// newBlobStore opens the storage configured for uploaded originals.
+func newBlobStore(config util.Config) (storage.Blob, error) {
+	switch config.StorageBackend {
+	case "local":
+		return storage.NewLocal(config.StorageDir)
+	case "s3":
+		return storage.NewS3(storage.S3Config{
+			Endpoint:        config.S3Endpoint,
+			Region:          config.S3Region,
+			Bucket:          config.S3Bucket,
+			AccessKeyID:     config.S3AccessKeyID,
+			SecretAccessKey: config.S3SecretAccessKey,
+			UseSSL:          config.S3UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q, use local or s3", config.StorageBackend)
+	}
+}
@@ -0,0 +1,51 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: copyfrom.go
+
+package db
+
+import (
+	"context"
+)
+
+// iteratorForCreateDocumentWords implements pgx.CopyFromSource.
+type iteratorForCreateDocumentWords struct {
+	rows                 []CreateDocumentWordsParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForCreateDocumentWords) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForCreateDocumentWords) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].DocumentID,
+		r.rows[0].PageNumber,
+		r.rows[0].WordIndex,
+		r.rows[0].LineNumber,
+		r.rows[0].Text,
+		r.rows[0].X,
+		r.rows[0].Y,
+		r.rows[0].Width,
+		r.rows[0].Height,
+		r.rows[0].Confidence,
+	}, nil
+}
+
+func (r iteratorForCreateDocumentWords) Err() error {
+	return nil
+}
+
+func (q *Queries) CreateDocumentWords(ctx context.Context, arg []CreateDocumentWordsParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"document_words"}, []string{"document_id", "page_number", "word_index", "line_number", "text", "x", "y", "width", "height", "confidence"}, &iteratorForCreateDocumentWords{rows: arg})
+}
@@ -0,0 +1,168 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: document_pages.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const getDocumentPage = `-- name: GetDocumentPage :one
+SELECT document_id, page_number, text, confidence, width, height, created_at, image_key, method, status, error FROM document_pages
+WHERE document_id = $1 AND page_number = $2
+`
+
+type GetDocumentPageParams struct {
+	DocumentID string `json:"document_id"`
+	PageNumber int32  `json:"page_number"`
+}
+
+func (q *Queries) GetDocumentPage(ctx context.Context, arg GetDocumentPageParams) (DocumentPage, error) {
+	row := q.db.QueryRow(ctx, getDocumentPage, arg.DocumentID, arg.PageNumber)
+	var i DocumentPage
+	err := row.Scan(
+		&i.DocumentID,
+		&i.PageNumber,
+		&i.Text,
+		&i.Confidence,
+		&i.Width,
+		&i.Height,
+		&i.CreatedAt,
+		&i.ImageKey,
+		&i.Method,
+		&i.Status,
+		&i.Error,
+	)
+	return i, err
+}
+
+const listDocumentPages = `-- name: ListDocumentPages :many
+SELECT document_id, page_number, text, confidence, width, height, created_at, image_key, method, status, error FROM document_pages
+WHERE document_id = $1
+ORDER BY page_number
+`
+
+func (q *Queries) ListDocumentPages(ctx context.Context, documentID string) ([]DocumentPage, error) {
+	rows, err := q.db.Query(ctx, listDocumentPages, documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DocumentPage
+	for rows.Next() {
+		var i DocumentPage
+		if err := rows.Scan(
+			&i.DocumentID,
+			&i.PageNumber,
+			&i.Text,
+			&i.Confidence,
+			&i.Width,
+			&i.Height,
+			&i.CreatedAt,
+			&i.ImageKey,
+			&i.Method,
+			&i.Status,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFailedDocumentPages = `-- name: ListFailedDocumentPages :many
+SELECT page_number, error FROM document_pages
+WHERE document_id = $1 AND status = 'failed'
+ORDER BY page_number
+`
+
+type ListFailedDocumentPagesRow struct {
+	PageNumber int32       `json:"page_number"`
+	Error      pgtype.Text `json:"error"`
+}
+
+func (q *Queries) ListFailedDocumentPages(ctx context.Context, documentID string) ([]ListFailedDocumentPagesRow, error) {
+	rows, err := q.db.Query(ctx, listFailedDocumentPages, documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFailedDocumentPagesRow
+	for rows.Next() {
+		var i ListFailedDocumentPagesRow
+		if err := rows.Scan(&i.PageNumber, &i.Error); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertDocumentPage = `-- name: UpsertDocumentPage :one
+INSERT INTO document_pages (document_id, page_number, text, confidence, width, height, image_key, method, status, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (document_id, page_number) DO UPDATE
+SET text = EXCLUDED.text,
+    confidence = EXCLUDED.confidence,
+    width = EXCLUDED.width,
+    height = EXCLUDED.height,
+    image_key = EXCLUDED.image_key,
+    method = EXCLUDED.method,
+    status = EXCLUDED.status,
+    error = EXCLUDED.error
+RETURNING document_id, page_number, text, confidence, width, height, created_at, image_key, method, status, error
+`
+
+type UpsertDocumentPageParams struct {
+	DocumentID string      `json:"document_id"`
+	PageNumber int32       `json:"page_number"`
+	Text       string      `json:"text"`
+	Confidence float64     `json:"confidence"`
+	Width      int32       `json:"width"`
+	Height     int32       `json:"height"`
+	ImageKey   pgtype.Text `json:"image_key"`
+	Method     string      `json:"method"`
+	Status     string      `json:"status"`
+	Error      pgtype.Text `json:"error"`
+}
+
+func (q *Queries) UpsertDocumentPage(ctx context.Context, arg UpsertDocumentPageParams) (DocumentPage, error) {
+	row := q.db.QueryRow(ctx, upsertDocumentPage,
+		arg.DocumentID,
+		arg.PageNumber,
+		arg.Text,
+		arg.Confidence,
+		arg.Width,
+		arg.Height,
+		arg.ImageKey,
+		arg.Method,
+		arg.Status,
+		arg.Error,
+	)
+	var i DocumentPage
+	err := row.Scan(
+		&i.DocumentID,
+		&i.PageNumber,
+		&i.Text,
+		&i.Confidence,
+		&i.Width,
+		&i.Height,
+		&i.CreatedAt,
+		&i.ImageKey,
+		&i.Method,
+		&i.Status,
+		&i.Error,
+	)
+	return i, err
+}
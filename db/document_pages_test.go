@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/yosa/ocr-golang-back/util"
+)
+
+func createRandomDocumentPage(t *testing.T, document Document, pageNumber int32) DocumentPage {
+	arg := UpsertDocumentPageParams{
+		DocumentID: document.ID,
+		PageNumber: pageNumber,
+		Text:       util.RandomContent(),
+		Confidence: float64(util.RandomInit(0, 100)),
+		Width:      1240,
+		Height:     1754,
+		Method:     "ocr",
+		Status:     "succeeded",
+	}
+
+	page, err := testQueries.UpsertDocumentPage(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.DocumentID, page.DocumentID)
+	require.Equal(t, arg.PageNumber, page.PageNumber)
+	require.Equal(t, arg.Text, page.Text)
+	require.Equal(t, arg.Confidence, page.Confidence)
+	require.Equal(t, arg.Width, page.Width)
+	require.Equal(t, arg.Height, page.Height)
+	require.Equal(t, arg.Method, page.Method)
+	require.Equal(t, arg.Status, page.Status)
+	require.False(t, page.Error.Valid)
+	require.NotZero(t, page.CreatedAt)
+	return page
+}
+
+func TestUpsertDocumentPage(t *testing.T) {
+	document := createRandomDocument(t)
+	page1 := createRandomDocumentPage(t, document, 1)
+
+	page2 := createRandomDocumentPage(t, document, 1)
+	require.Equal(t, page1.PageNumber, page2.PageNumber)
+
+	page3, err := testQueries.GetDocumentPage(context.Background(), GetDocumentPageParams{
+		DocumentID: document.ID,
+		PageNumber: 1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, page2.Text, page3.Text)
+}
+
+func TestListDocumentPages(t *testing.T) {
+	document := createRandomDocument(t)
+	for _, n := range []int32{3, 1, 2} {
+		createRandomDocumentPage(t, document, n)
+	}
+
+	pages, err := testQueries.ListDocumentPages(context.Background(), document.ID)
+	require.NoError(t, err)
+	require.Len(t, pages, 3)
+	for i, page := range pages {
+		require.Equal(t, int32(i+1), page.PageNumber)
+	}
+}
+
+func TestListFailedDocumentPages(t *testing.T) {
+	document := createRandomDocument(t)
+	createRandomDocumentPage(t, document, 1)
+
+	arg := UpsertDocumentPageParams{
+		DocumentID: document.ID,
+		PageNumber: 2,
+		Method:     "ocr",
+		Status:     "failed",
+		Error:      pgtype.Text{String: "ocr error on page 2", Valid: true},
+	}
+	_, err := testQueries.UpsertDocumentPage(context.Background(), arg)
+	require.NoError(t, err)
+
+	failed, err := testQueries.ListFailedDocumentPages(context.Background(), document.ID)
+	require.NoError(t, err)
+	require.Equal(t, []ListFailedDocumentPagesRow{{PageNumber: 2, Error: arg.Error}}, failed)
+
+	// A successful retry clears the failure.
+	createRandomDocumentPage(t, document, 2)
+	failed, err = testQueries.ListFailedDocumentPages(context.Background(), document.ID)
+	require.NoError(t, err)
+	require.Empty(t, failed)
+}
@@ -0,0 +1,67 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: document_tags.sql
+
+package db
+
+import (
+	"context"
+)
+
+const addDocumentTag = `-- name: AddDocumentTag :exec
+INSERT INTO document_tags (document_id, tag)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type AddDocumentTagParams struct {
+	DocumentID string `json:"document_id"`
+	Tag        string `json:"tag"`
+}
+
+func (q *Queries) AddDocumentTag(ctx context.Context, arg AddDocumentTagParams) error {
+	_, err := q.db.Exec(ctx, addDocumentTag, arg.DocumentID, arg.Tag)
+	return err
+}
+
+const listDocumentTags = `-- name: ListDocumentTags :many
+SELECT tag FROM document_tags
+WHERE document_id = $1
+ORDER BY tag
+`
+
+func (q *Queries) ListDocumentTags(ctx context.Context, documentID string) ([]string, error) {
+	rows, err := q.db.Query(ctx, listDocumentTags, documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		items = append(items, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const removeDocumentTag = `-- name: RemoveDocumentTag :exec
+DELETE FROM document_tags
+WHERE document_id = $1 AND tag = $2
+`
+
+type RemoveDocumentTagParams struct {
+	DocumentID string `json:"document_id"`
+	Tag        string `json:"tag"`
+}
+
+func (q *Queries) RemoveDocumentTag(ctx context.Context, arg RemoveDocumentTagParams) error {
+	_, err := q.db.Exec(ctx, removeDocumentTag, arg.DocumentID, arg.Tag)
+	return err
+}
@@ -0,0 +1,117 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: document_words.sql
+
+package db
+
+import (
+	"context"
+)
+
+type CreateDocumentWordsParams struct {
+	DocumentID string  `json:"document_id"`
+	PageNumber int32   `json:"page_number"`
+	WordIndex  int32   `json:"word_index"`
+	LineNumber int32   `json:"line_number"`
+	Text       string  `json:"text"`
+	X          int32   `json:"x"`
+	Y          int32   `json:"y"`
+	Width      int32   `json:"width"`
+	Height     int32   `json:"height"`
+	Confidence float64 `json:"confidence"`
+}
+
+const deleteDocumentPageWords = `-- name: DeleteDocumentPageWords :exec
+DELETE FROM document_words
+WHERE document_id = $1 AND page_number = $2
+`
+
+type DeleteDocumentPageWordsParams struct {
+	DocumentID string `json:"document_id"`
+	PageNumber int32  `json:"page_number"`
+}
+
+func (q *Queries) DeleteDocumentPageWords(ctx context.Context, arg DeleteDocumentPageWordsParams) error {
+	_, err := q.db.Exec(ctx, deleteDocumentPageWords, arg.DocumentID, arg.PageNumber)
+	return err
+}
+
+const listDocumentPageWords = `-- name: ListDocumentPageWords :many
+SELECT document_id, page_number, word_index, line_number, text, x, y, width, height, confidence FROM document_words
+WHERE document_id = $1 AND page_number = $2
+ORDER BY word_index
+`
+
+type ListDocumentPageWordsParams struct {
+	DocumentID string `json:"document_id"`
+	PageNumber int32  `json:"page_number"`
+}
+
+func (q *Queries) ListDocumentPageWords(ctx context.Context, arg ListDocumentPageWordsParams) ([]DocumentWord, error) {
+	rows, err := q.db.Query(ctx, listDocumentPageWords, arg.DocumentID, arg.PageNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DocumentWord
+	for rows.Next() {
+		var i DocumentWord
+		if err := rows.Scan(
+			&i.DocumentID,
+			&i.PageNumber,
+			&i.WordIndex,
+			&i.LineNumber,
+			&i.Text,
+			&i.X,
+			&i.Y,
+			&i.Width,
+			&i.Height,
+			&i.Confidence,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDocumentWords = `-- name: ListDocumentWords :many
+SELECT document_id, page_number, word_index, line_number, text, x, y, width, height, confidence FROM document_words
+WHERE document_id = $1
+ORDER BY page_number, word_index
+`
+
+func (q *Queries) ListDocumentWords(ctx context.Context, documentID string) ([]DocumentWord, error) {
+	rows, err := q.db.Query(ctx, listDocumentWords, documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DocumentWord
+	for rows.Next() {
+		var i DocumentWord
+		if err := rows.Scan(
+			&i.DocumentID,
+			&i.PageNumber,
+			&i.WordIndex,
+			&i.LineNumber,
+			&i.Text,
+			&i.X,
+			&i.Y,
+			&i.Width,
+			&i.Height,
+			&i.Confidence,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateDocumentWords(t *testing.T) {
+	document := createRandomDocument(t)
+	createRandomDocumentPage(t, document, 1)
+
+	arg := []CreateDocumentWordsParams{
+		{DocumentID: document.ID, PageNumber: 1, WordIndex: 0, LineNumber: 0, Text: "hello", X: 10, Y: 10, Width: 50, Height: 12, Confidence: 96.5},
+		{DocumentID: document.ID, PageNumber: 1, WordIndex: 1, LineNumber: 0, Text: "world", X: 70, Y: 10, Width: 55, Height: 12, Confidence: 91},
+		{DocumentID: document.ID, PageNumber: 1, WordIndex: 2, LineNumber: 1, Text: "again", X: 10, Y: 30, Width: 48, Height: 12, Confidence: 88},
+	}
+
+	n, err := testQueries.CreateDocumentWords(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(arg)), n)
+
+	words, err := testQueries.ListDocumentPageWords(context.Background(), ListDocumentPageWordsParams{
+		DocumentID: document.ID,
+		PageNumber: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, words, len(arg))
+	for i, word := range words {
+		require.Equal(t, arg[i].Text, word.Text)
+		require.Equal(t, arg[i].LineNumber, word.LineNumber)
+		require.Equal(t, arg[i].X, word.X)
+		require.Equal(t, arg[i].Confidence, word.Confidence)
+	}
+
+	err = testQueries.DeleteDocumentPageWords(context.Background(), DeleteDocumentPageWordsParams{
+		DocumentID: document.ID,
+		PageNumber: 1,
+	})
+	require.NoError(t, err)
+
+	words, err = testQueries.ListDocumentWords(context.Background(), document.ID)
+	require.NoError(t, err)
+	require.Empty(t, words)
+}
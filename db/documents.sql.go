@@ -7,21 +7,70 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countDocuments = `-- name: CountDocuments :one
+SELECT count(*) FROM documents
+WHERE user_id = $1
+  AND ($2::varchar IS NULL OR file_type = $2)
+  AND ($3::varchar IS NULL OR status = $3)
+  AND ($4::timestamp IS NULL OR uploaded_at >= $4)
+  AND ($5::timestamp IS NULL OR uploaded_at < $5)
+  AND ($6::varchar IS NULL OR $6 = ANY(languages))
+  AND ($7::varchar IS NULL OR filename ILIKE $7)
+  AND ($8::varchar IS NULL OR folder_id = $8)
+  AND ($9::varchar[] IS NULL OR $9::varchar[] <@ ARRAY(
+    SELECT tag FROM document_tags WHERE document_id = documents.id
+  )::varchar[])
+`
+
+type CountDocumentsParams struct {
+	UserID          string           `json:"user_id"`
+	FileType        pgtype.Text      `json:"file_type"`
+	Status          pgtype.Text      `json:"status"`
+	UploadedFrom    pgtype.Timestamp `json:"uploaded_from"`
+	UploadedTo      pgtype.Timestamp `json:"uploaded_to"`
+	Language        pgtype.Text      `json:"language"`
+	FilenamePattern pgtype.Text      `json:"filename_pattern"`
+	FolderID        pgtype.Text      `json:"folder_id"`
+	Tags            []string         `json:"tags"`
+}
+
+// Counts the documents ListDocuments goes through, with the same filters.
+func (q *Queries) CountDocuments(ctx context.Context, arg CountDocumentsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countDocuments,
+		arg.UserID,
+		arg.FileType,
+		arg.Status,
+		arg.UploadedFrom,
+		arg.UploadedTo,
+		arg.Language,
+		arg.FilenamePattern,
+		arg.FolderID,
+		arg.Tags,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createDocument = `-- name: CreateDocument :one
-INSERT INTO documents (id, user_id, filename, file_type)
-VALUES ($1, $2, $3, $4)
-RETURNING id, user_id, filename, file_type, uploaded_at
+INSERT INTO documents (id, user_id, filename, file_type, languages, preprocess, storage_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, user_id, filename, file_type, uploaded_at, languages, detected_language, detected_script, preprocess, status, storage_key, metadata, folder_id, dpi
 `
 
 type CreateDocumentParams struct {
-	ID       string      `json:"id"`
-	UserID   string      `json:"user_id"`
-	Filename pgtype.Text `json:"filename"`
-	FileType pgtype.Text `json:"file_type"`
+	ID         string      `json:"id"`
+	UserID     string      `json:"user_id"`
+	Filename   pgtype.Text `json:"filename"`
+	FileType   pgtype.Text `json:"file_type"`
+	Languages  []string    `json:"languages"`
+	Preprocess []string    `json:"preprocess"`
+	StorageKey pgtype.Text `json:"storage_key"`
 }
 
 func (q *Queries) CreateDocument(ctx context.Context, arg CreateDocumentParams) (Document, error) {
@@ -30,6 +79,9 @@ func (q *Queries) CreateDocument(ctx context.Context, arg CreateDocumentParams)
 		arg.UserID,
 		arg.Filename,
 		arg.FileType,
+		arg.Languages,
+		arg.Preprocess,
+		arg.StorageKey,
 	)
 	var i Document
 	err := row.Scan(
@@ -38,6 +90,15 @@ func (q *Queries) CreateDocument(ctx context.Context, arg CreateDocumentParams)
 		&i.Filename,
 		&i.FileType,
 		&i.UploadedAt,
+		&i.Languages,
+		&i.DetectedLanguage,
+		&i.DetectedScript,
+		&i.Preprocess,
+		&i.Status,
+		&i.StorageKey,
+		&i.Metadata,
+		&i.FolderID,
+		&i.Dpi,
 	)
 	return i, err
 }
@@ -52,8 +113,26 @@ func (q *Queries) DeleteDocument(ctx context.Context, id string) error {
 	return err
 }
 
+const deleteIdleDocument = `-- name: DeleteIdleDocument :execrows
+DELETE FROM documents d
+WHERE d.id = $1
+  AND NOT EXISTS (
+    SELECT 1 FROM ocr_jobs
+    WHERE document_id = d.id AND status IN ('queued', 'running')
+  )
+`
+
+// Deletes a document unless it has an OCR job queued or running.
+func (q *Queries) DeleteIdleDocument(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteIdleDocument, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getDocumentByID = `-- name: GetDocumentByID :one
-SELECT id, user_id, filename, file_type, uploaded_at FROM documents
+SELECT id, user_id, filename, file_type, uploaded_at, languages, detected_language, detected_script, preprocess, status, storage_key, metadata, folder_id, dpi FROM documents
 WHERE id = $1
 `
 
@@ -66,12 +145,127 @@ func (q *Queries) GetDocumentByID(ctx context.Context, id string) (Document, err
 		&i.Filename,
 		&i.FileType,
 		&i.UploadedAt,
+		&i.Languages,
+		&i.DetectedLanguage,
+		&i.DetectedScript,
+		&i.Preprocess,
+		&i.Status,
+		&i.StorageKey,
+		&i.Metadata,
+		&i.FolderID,
+		&i.Dpi,
 	)
 	return i, err
 }
 
+const listDocuments = `-- name: ListDocuments :many
+SELECT id, user_id, filename, file_type, uploaded_at, languages, detected_language, detected_script, preprocess, status, storage_key, metadata, folder_id, dpi FROM documents
+WHERE user_id = $1
+  AND ($2::varchar IS NULL OR file_type = $2)
+  AND ($3::varchar IS NULL OR status = $3)
+  AND ($4::timestamp IS NULL OR uploaded_at >= $4)
+  AND ($5::timestamp IS NULL OR uploaded_at < $5)
+  AND ($6::varchar IS NULL OR $6 = ANY(languages))
+  AND ($7::varchar IS NULL OR filename ILIKE $7)
+  AND ($8::varchar IS NULL OR folder_id = $8)
+  AND ($9::varchar[] IS NULL OR $9::varchar[] <@ ARRAY(
+    SELECT tag FROM document_tags WHERE document_id = documents.id
+  )::varchar[])
+  AND ($10::varchar IS NULL OR CASE
+    WHEN $11::varchar = 'filename' AND $12::boolean
+      THEN (coalesce(filename, ''), id) < ($13::varchar, $10)
+    WHEN $11::varchar = 'filename'
+      THEN (coalesce(filename, ''), id) > ($13::varchar, $10)
+    WHEN $12::boolean
+      THEN (coalesce(uploaded_at, '-infinity'), id) < ($14::timestamp, $10)
+    ELSE (coalesce(uploaded_at, '-infinity'), id) > ($14::timestamp, $10)
+  END)
+ORDER BY
+  CASE WHEN $11::varchar = 'filename' AND NOT $12::boolean THEN coalesce(filename, '') END ASC,
+  CASE WHEN $11::varchar = 'filename' AND $12::boolean THEN coalesce(filename, '') END DESC,
+  CASE WHEN $11::varchar = 'uploaded_at' AND NOT $12::boolean THEN coalesce(uploaded_at, '-infinity') END ASC,
+  CASE WHEN $11::varchar = 'uploaded_at' AND $12::boolean THEN coalesce(uploaded_at, '-infinity') END DESC,
+  CASE WHEN NOT $12::boolean THEN id END ASC,
+  CASE WHEN $12::boolean THEN id END DESC
+LIMIT $15
+`
+
+type ListDocumentsParams struct {
+	UserID          string           `json:"user_id"`
+	FileType        pgtype.Text      `json:"file_type"`
+	Status          pgtype.Text      `json:"status"`
+	UploadedFrom    pgtype.Timestamp `json:"uploaded_from"`
+	UploadedTo      pgtype.Timestamp `json:"uploaded_to"`
+	Language        pgtype.Text      `json:"language"`
+	FilenamePattern pgtype.Text      `json:"filename_pattern"`
+	FolderID        pgtype.Text      `json:"folder_id"`
+	Tags            []string         `json:"tags"`
+	AfterID         pgtype.Text      `json:"after_id"`
+	SortBy          string           `json:"sort_by"`
+	Descending      bool             `json:"descending"`
+	AfterFilename   pgtype.Text      `json:"after_filename"`
+	AfterUploadedAt pgtype.Timestamp `json:"after_uploaded_at"`
+	LimitCount      int32            `json:"limit_count"`
+}
+
+// Filters left null match every document, documents have every tag of
+// tags. Pages are keyset paginated: the
+// after_* arguments are the sort key and id of the last document of the
+// previous page, null for the first page. Documents are sorted by
+// sort_by, uploaded_at or filename, then by id.
+func (q *Queries) ListDocuments(ctx context.Context, arg ListDocumentsParams) ([]Document, error) {
+	rows, err := q.db.Query(ctx, listDocuments,
+		arg.UserID,
+		arg.FileType,
+		arg.Status,
+		arg.UploadedFrom,
+		arg.UploadedTo,
+		arg.Language,
+		arg.FilenamePattern,
+		arg.FolderID,
+		arg.Tags,
+		arg.AfterID,
+		arg.SortBy,
+		arg.Descending,
+		arg.AfterFilename,
+		arg.AfterUploadedAt,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Document
+	for rows.Next() {
+		var i Document
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Filename,
+			&i.FileType,
+			&i.UploadedAt,
+			&i.Languages,
+			&i.DetectedLanguage,
+			&i.DetectedScript,
+			&i.Preprocess,
+			&i.Status,
+			&i.StorageKey,
+			&i.Metadata,
+			&i.FolderID,
+			&i.Dpi,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listDocumentsByUser = `-- name: ListDocumentsByUser :many
-SELECT id, user_id, filename, file_type, uploaded_at FROM documents
+SELECT id, user_id, filename, file_type, uploaded_at, languages, detected_language, detected_script, preprocess, status, storage_key, metadata, folder_id, dpi FROM documents
 WHERE user_id = $1
 ORDER BY uploaded_at DESC LIMIT $2 OFFSET $3
 `
@@ -97,6 +291,15 @@ func (q *Queries) ListDocumentsByUser(ctx context.Context, arg ListDocumentsByUs
 			&i.Filename,
 			&i.FileType,
 			&i.UploadedAt,
+			&i.Languages,
+			&i.DetectedLanguage,
+			&i.DetectedScript,
+			&i.Preprocess,
+			&i.Status,
+			&i.StorageKey,
+			&i.Metadata,
+			&i.FolderID,
+			&i.Dpi,
 		); err != nil {
 			return nil, err
 		}
@@ -108,6 +311,133 @@ func (q *Queries) ListDocumentsByUser(ctx context.Context, arg ListDocumentsByUs
 	return items, nil
 }
 
+const setDocumentDPI = `-- name: SetDocumentDPI :exec
+UPDATE documents
+SET dpi = $2
+WHERE id = $1
+`
+
+type SetDocumentDPIParams struct {
+	ID  string      `json:"id"`
+	Dpi pgtype.Int4 `json:"dpi"`
+}
+
+func (q *Queries) SetDocumentDPI(ctx context.Context, arg SetDocumentDPIParams) error {
+	_, err := q.db.Exec(ctx, setDocumentDPI, arg.ID, arg.Dpi)
+	return err
+}
+
+const setDocumentFolder = `-- name: SetDocumentFolder :one
+UPDATE documents
+SET folder_id = $2
+WHERE id = $1
+RETURNING id, user_id, filename, file_type, uploaded_at, languages, detected_language, detected_script, preprocess, status, storage_key, metadata, folder_id, dpi
+`
+
+type SetDocumentFolderParams struct {
+	ID       string      `json:"id"`
+	FolderID pgtype.Text `json:"folder_id"`
+}
+
+func (q *Queries) SetDocumentFolder(ctx context.Context, arg SetDocumentFolderParams) (Document, error) {
+	row := q.db.QueryRow(ctx, setDocumentFolder, arg.ID, arg.FolderID)
+	var i Document
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Filename,
+		&i.FileType,
+		&i.UploadedAt,
+		&i.Languages,
+		&i.DetectedLanguage,
+		&i.DetectedScript,
+		&i.Preprocess,
+		&i.Status,
+		&i.StorageKey,
+		&i.Metadata,
+		&i.FolderID,
+		&i.Dpi,
+	)
+	return i, err
+}
+
+const setDocumentLanguages = `-- name: SetDocumentLanguages :exec
+UPDATE documents
+SET languages = $2,
+    detected_language = $3,
+    detected_script = $4
+WHERE id = $1
+`
+
+type SetDocumentLanguagesParams struct {
+	ID               string      `json:"id"`
+	Languages        []string    `json:"languages"`
+	DetectedLanguage pgtype.Text `json:"detected_language"`
+	DetectedScript   pgtype.Text `json:"detected_script"`
+}
+
+func (q *Queries) SetDocumentLanguages(ctx context.Context, arg SetDocumentLanguagesParams) error {
+	_, err := q.db.Exec(ctx, setDocumentLanguages,
+		arg.ID,
+		arg.Languages,
+		arg.DetectedLanguage,
+		arg.DetectedScript,
+	)
+	return err
+}
+
+const setDocumentStatus = `-- name: SetDocumentStatus :exec
+UPDATE documents
+SET status = $2
+WHERE id = $1
+`
+
+type SetDocumentStatusParams struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+func (q *Queries) SetDocumentStatus(ctx context.Context, arg SetDocumentStatusParams) error {
+	_, err := q.db.Exec(ctx, setDocumentStatus, arg.ID, arg.Status)
+	return err
+}
+
+const updateDocument = `-- name: UpdateDocument :one
+UPDATE documents
+SET filename = coalesce($1, filename),
+    metadata = coalesce($2, metadata)
+WHERE id = $3
+RETURNING id, user_id, filename, file_type, uploaded_at, languages, detected_language, detected_script, preprocess, status, storage_key, metadata, folder_id, dpi
+`
+
+type UpdateDocumentParams struct {
+	Filename pgtype.Text     `json:"filename"`
+	Metadata json.RawMessage `json:"metadata"`
+	ID       string          `json:"id"`
+}
+
+func (q *Queries) UpdateDocument(ctx context.Context, arg UpdateDocumentParams) (Document, error) {
+	row := q.db.QueryRow(ctx, updateDocument, arg.Filename, arg.Metadata, arg.ID)
+	var i Document
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Filename,
+		&i.FileType,
+		&i.UploadedAt,
+		&i.Languages,
+		&i.DetectedLanguage,
+		&i.DetectedScript,
+		&i.Preprocess,
+		&i.Status,
+		&i.StorageKey,
+		&i.Metadata,
+		&i.FolderID,
+		&i.Dpi,
+	)
+	return i, err
+}
+
 const updateDocumentFilename = `-- name: UpdateDocumentFilename :exec
 UPDATE documents
 SET filename = $2
@@ -0,0 +1,226 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/yosa/ocr-golang-back/util"
+)
+
+func createRandomDocument(t *testing.T) Document {
+	user := createRandomUser(t)
+
+	arg := CreateDocumentParams{
+		ID:         uuid.New().String(),
+		UserID:     user.Username,
+		Filename:   pgtype.Text{String: util.RandomFilename(), Valid: true},
+		FileType:   pgtype.Text{String: "application/pdf", Valid: true},
+		Languages:  []string{"eng"},
+		Preprocess: []string{"grayscale", "deskew"},
+		StorageKey: pgtype.Text{String: "originals/document.pdf", Valid: true},
+	}
+
+	document, err := testQueries.CreateDocument(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, document)
+
+	require.Equal(t, arg.ID, document.ID)
+	require.Equal(t, arg.UserID, document.UserID)
+	require.Equal(t, arg.Filename, document.Filename)
+	require.Equal(t, arg.FileType, document.FileType)
+	require.Equal(t, arg.Languages, document.Languages)
+	require.Equal(t, arg.Preprocess, document.Preprocess)
+	require.Equal(t, arg.StorageKey, document.StorageKey)
+	require.Equal(t, "processing", document.Status)
+	require.JSONEq(t, `{}`, string(document.Metadata))
+
+	require.NotZero(t, document.UploadedAt)
+	return document
+}
+
+func TestCreateDocument(t *testing.T) {
+	createRandomDocument(t)
+}
+
+func TestGetDocumentByID(t *testing.T) {
+	document1 := createRandomDocument(t)
+
+	document2, err := testQueries.GetDocumentByID(context.Background(), document1.ID)
+	require.NoError(t, err)
+	require.Equal(t, document1, document2)
+}
+
+func TestSetDocumentLanguages(t *testing.T) {
+	document1 := createRandomDocument(t)
+
+	arg := SetDocumentLanguagesParams{
+		ID:               document1.ID,
+		Languages:        []string{"ara"},
+		DetectedLanguage: pgtype.Text{String: "ara", Valid: true},
+		DetectedScript:   pgtype.Text{String: "Arabic", Valid: true},
+	}
+	err := testQueries.SetDocumentLanguages(context.Background(), arg)
+	require.NoError(t, err)
+
+	document2, err := testQueries.GetDocumentByID(context.Background(), document1.ID)
+	require.NoError(t, err)
+	require.Equal(t, arg.Languages, document2.Languages)
+	require.Equal(t, arg.DetectedLanguage, document2.DetectedLanguage)
+	require.Equal(t, arg.DetectedScript, document2.DetectedScript)
+}
+
+func TestSetDocumentStatus(t *testing.T) {
+	document1 := createRandomDocument(t)
+
+	err := testQueries.SetDocumentStatus(context.Background(), SetDocumentStatusParams{
+		ID:     document1.ID,
+		Status: "partial",
+	})
+	require.NoError(t, err)
+
+	document2, err := testQueries.GetDocumentByID(context.Background(), document1.ID)
+	require.NoError(t, err)
+	require.Equal(t, "partial", document2.Status)
+
+	err = testQueries.SetDocumentStatus(context.Background(), SetDocumentStatusParams{
+		ID:     document1.ID,
+		Status: "broken",
+	})
+	require.Error(t, err)
+}
+
+func TestUpdateDocument(t *testing.T) {
+	document1 := createRandomDocument(t)
+
+	// Fields left null are kept.
+	document2, err := testQueries.UpdateDocument(context.Background(), UpdateDocumentParams{
+		ID:       document1.ID,
+		Metadata: json.RawMessage(`{"client": "acme", "year": 2024}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, document1.Filename, document2.Filename)
+	require.JSONEq(t, `{"client": "acme", "year": 2024}`, string(document2.Metadata))
+
+	filename := pgtype.Text{String: util.RandomFilename(), Valid: true}
+	document3, err := testQueries.UpdateDocument(context.Background(), UpdateDocumentParams{
+		ID:       document1.ID,
+		Filename: filename,
+	})
+	require.NoError(t, err)
+	require.Equal(t, filename, document3.Filename)
+	require.JSONEq(t, `{"client": "acme", "year": 2024}`, string(document3.Metadata))
+}
+
+func TestDeleteDocument(t *testing.T) {
+	document := createRandomDocument(t)
+	createRandomDocumentPage(t, document, 1)
+	createRandomExtractedText(t, document, util.RandomContent())
+	_, err := testQueries.CreateDocumentWords(context.Background(), []CreateDocumentWordsParams{
+		{DocumentID: document.ID, PageNumber: 1, Text: "hello", Width: 50, Height: 12, Confidence: 96},
+	})
+	require.NoError(t, err)
+	_, err = testQueries.CreateOCRJob(context.Background(), CreateOCRJobParams{
+		ID:         uuid.New().String(),
+		DocumentID: document.ID,
+	})
+	require.NoError(t, err)
+
+	err = testQueries.DeleteDocument(context.Background(), document.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetDocumentByID(context.Background(), document.ID)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	// Everything stored for the document goes with it.
+	pages, err := testQueries.ListDocumentPages(context.Background(), document.ID)
+	require.NoError(t, err)
+	require.Empty(t, pages)
+	words, err := testQueries.ListDocumentWords(context.Background(), document.ID)
+	require.NoError(t, err)
+	require.Empty(t, words)
+	_, err = testQueries.GetLatestExtractedTextByDocument(context.Background(), document.ID)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+	_, err = testQueries.GetLatestOCRJobByDocument(context.Background(), document.ID)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestDeleteIdleDocument(t *testing.T) {
+	document := createRandomDocument(t)
+	job, err := testQueries.CreateOCRJob(context.Background(), CreateOCRJobParams{
+		ID:         uuid.New().String(),
+		DocumentID: document.ID,
+	})
+	require.NoError(t, err)
+
+	// Not while its job is queued.
+	deleted, err := testQueries.DeleteIdleDocument(context.Background(), document.ID)
+	require.NoError(t, err)
+	require.Zero(t, deleted)
+	_, err = testQueries.GetDocumentByID(context.Background(), document.ID)
+	require.NoError(t, err)
+
+	err = testQueries.FailOCRJob(context.Background(), FailOCRJobParams{ID: job.ID})
+	require.NoError(t, err)
+
+	deleted, err = testQueries.DeleteIdleDocument(context.Background(), document.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+	_, err = testQueries.GetDocumentByID(context.Background(), document.ID)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestListDocuments(t *testing.T) {
+	user := createRandomUser(t)
+
+	var documents []Document
+	for _, filename := range []string{"b_report.pdf", "a-report.pdf", "notes.png"} {
+		document, err := testQueries.CreateDocument(context.Background(), CreateDocumentParams{
+			ID:         uuid.New().String(),
+			UserID:     user.Username,
+			Filename:   pgtype.Text{String: filename, Valid: true},
+			FileType:   pgtype.Text{String: "application/pdf", Valid: true},
+			Languages:  []string{"eng"},
+			Preprocess: []string{},
+		})
+		require.NoError(t, err)
+		documents = append(documents, document)
+	}
+	createRandomDocument(t)
+
+	// Filenames in order, two at a time.
+	arg := ListDocumentsParams{UserID: user.Username, SortBy: "filename", LimitCount: 2}
+	page1, err := testQueries.ListDocuments(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, []Document{documents[1], documents[0]}, page1)
+
+	arg.AfterID = pgtype.Text{String: page1[1].ID, Valid: true}
+	arg.AfterFilename = page1[1].Filename
+	page2, err := testQueries.ListDocuments(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, []Document{documents[2]}, page2)
+
+	// "_" is matched as itself.
+	arg = ListDocumentsParams{
+		UserID:          user.Username,
+		SortBy:          "uploaded_at",
+		Descending:      true,
+		FilenamePattern: pgtype.Text{String: `%\_REPORT%`, Valid: true},
+		LimitCount:      10,
+	}
+	filtered, err := testQueries.ListDocuments(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, []Document{documents[0]}, filtered)
+
+	count, err := testQueries.CountDocuments(context.Background(), CountDocumentsParams{
+		UserID:   user.Username,
+		Status:   pgtype.Text{String: "processing", Valid: true},
+		Language: pgtype.Text{String: "eng", Valid: true},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 3, count)
+}
@@ -62,6 +62,25 @@ func (q *Queries) GetExtractedTextByID(ctx context.Context, id string) (Extracte
 	return i, err
 }
 
+const getLatestExtractedTextByDocument = `-- name: GetLatestExtractedTextByDocument :one
+SELECT id, document_id, content, created_at FROM extracted_texts
+WHERE document_id = $1
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestExtractedTextByDocument(ctx context.Context, documentID string) (ExtractedText, error) {
+	row := q.db.QueryRow(ctx, getLatestExtractedTextByDocument, documentID)
+	var i ExtractedText
+	err := row.Scan(
+		&i.ID,
+		&i.DocumentID,
+		&i.Content,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const listExtractedTextsByDocument = `-- name: ListExtractedTextsByDocument :many
 SELECT id, document_id, content, created_at FROM extracted_texts
 WHERE document_id = $1
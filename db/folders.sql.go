@@ -0,0 +1,200 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: folders.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createFolder = `-- name: CreateFolder :one
+INSERT INTO folders (id, user_id, parent_id, name)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, parent_id, name, created_at
+`
+
+type CreateFolderParams struct {
+	ID       string      `json:"id"`
+	UserID   string      `json:"user_id"`
+	ParentID pgtype.Text `json:"parent_id"`
+	Name     string      `json:"name"`
+}
+
+func (q *Queries) CreateFolder(ctx context.Context, arg CreateFolderParams) (Folder, error) {
+	row := q.db.QueryRow(ctx, createFolder,
+		arg.ID,
+		arg.UserID,
+		arg.ParentID,
+		arg.Name,
+	)
+	var i Folder
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ParentID,
+		&i.Name,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getFolderByID = `-- name: GetFolderByID :one
+SELECT id, user_id, parent_id, name, created_at FROM folders
+WHERE id = $1
+`
+
+func (q *Queries) GetFolderByID(ctx context.Context, id string) (Folder, error) {
+	row := q.db.QueryRow(ctx, getFolderByID, id)
+	var i Folder
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ParentID,
+		&i.Name,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const isFolderWithin = `-- name: IsFolderWithin :one
+WITH RECURSIVE ancestors AS (
+  SELECT folders.id, folders.parent_id FROM folders
+  WHERE folders.id = $2::varchar
+  UNION
+  SELECT f.id, f.parent_id FROM folders f
+  JOIN ancestors a ON f.id = a.parent_id
+)
+SELECT (count(*) > 0)::boolean AS within FROM ancestors
+WHERE ancestors.id = $1::varchar
+`
+
+type IsFolderWithinParams struct {
+	AncestorID string `json:"ancestor_id"`
+	FolderID   string `json:"folder_id"`
+}
+
+// Reports whether folder_id is ancestor_id or one of its subfolders.
+func (q *Queries) IsFolderWithin(ctx context.Context, arg IsFolderWithinParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isFolderWithin, arg.AncestorID, arg.FolderID)
+	var within bool
+	err := row.Scan(&within)
+	return within, err
+}
+
+const listFolderChildren = `-- name: ListFolderChildren :many
+SELECT id, user_id, parent_id, name, created_at FROM folders
+WHERE parent_id = $1
+ORDER BY name, id
+`
+
+func (q *Queries) ListFolderChildren(ctx context.Context, parentID pgtype.Text) ([]Folder, error) {
+	rows, err := q.db.Query(ctx, listFolderChildren, parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Folder
+	for rows.Next() {
+		var i Folder
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ParentID,
+			&i.Name,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFoldersByUser = `-- name: ListFoldersByUser :many
+SELECT id, user_id, parent_id, name, created_at FROM folders
+WHERE user_id = $1
+ORDER BY name, id
+`
+
+func (q *Queries) ListFoldersByUser(ctx context.Context, userID string) ([]Folder, error) {
+	rows, err := q.db.Query(ctx, listFoldersByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Folder
+	for rows.Next() {
+		var i Folder
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ParentID,
+			&i.Name,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveFolder = `-- name: MoveFolder :one
+UPDATE folders
+SET parent_id = $2
+WHERE id = $1
+RETURNING id, user_id, parent_id, name, created_at
+`
+
+type MoveFolderParams struct {
+	ID       string      `json:"id"`
+	ParentID pgtype.Text `json:"parent_id"`
+}
+
+func (q *Queries) MoveFolder(ctx context.Context, arg MoveFolderParams) (Folder, error) {
+	row := q.db.QueryRow(ctx, moveFolder, arg.ID, arg.ParentID)
+	var i Folder
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ParentID,
+		&i.Name,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const renameFolder = `-- name: RenameFolder :one
+UPDATE folders
+SET name = $2
+WHERE id = $1
+RETURNING id, user_id, parent_id, name, created_at
+`
+
+type RenameFolderParams struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (q *Queries) RenameFolder(ctx context.Context, arg RenameFolderParams) (Folder, error) {
+	row := q.db.QueryRow(ctx, renameFolder, arg.ID, arg.Name)
+	var i Folder
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ParentID,
+		&i.Name,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/yosa/ocr-golang-back/util"
+)
+
+func createRandomFolder(t *testing.T, userID string, parent *Folder) Folder {
+	arg := CreateFolderParams{
+		ID:     uuid.New().String(),
+		UserID: userID,
+		Name:   util.RandomString(8),
+	}
+	if parent != nil {
+		arg.ParentID = pgtype.Text{String: parent.ID, Valid: true}
+	}
+
+	folder, err := testQueries.CreateFolder(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, folder.ID)
+	require.Equal(t, arg.UserID, folder.UserID)
+	require.Equal(t, arg.ParentID, folder.ParentID)
+	require.Equal(t, arg.Name, folder.Name)
+	require.NotZero(t, folder.CreatedAt)
+	return folder
+}
+
+func TestCreateFolder(t *testing.T) {
+	user := createRandomUser(t)
+	folder := createRandomFolder(t, user.Username, nil)
+	createRandomFolder(t, user.Username, &folder)
+
+	// Siblings can't share a name, top-level folders included.
+	_, err := testQueries.CreateFolder(context.Background(), CreateFolderParams{
+		ID:     uuid.New().String(),
+		UserID: user.Username,
+		Name:   folder.Name,
+	})
+	require.Error(t, err)
+}
+
+func TestRenameAndMoveFolder(t *testing.T) {
+	user := createRandomUser(t)
+	parent := createRandomFolder(t, user.Username, nil)
+	folder := createRandomFolder(t, user.Username, nil)
+
+	renamed, err := testQueries.RenameFolder(context.Background(), RenameFolderParams{ID: folder.ID, Name: "taxes"})
+	require.NoError(t, err)
+	require.Equal(t, "taxes", renamed.Name)
+
+	moved, err := testQueries.MoveFolder(context.Background(), MoveFolderParams{
+		ID:       folder.ID,
+		ParentID: pgtype.Text{String: parent.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, parent.ID, moved.ParentID.String)
+
+	folders, err := testQueries.ListFoldersByUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, folders, 2)
+}
+
+func TestIsFolderWithin(t *testing.T) {
+	user := createRandomUser(t)
+	root := createRandomFolder(t, user.Username, nil)
+	child := createRandomFolder(t, user.Username, &root)
+	grandchild := createRandomFolder(t, user.Username, &child)
+	other := createRandomFolder(t, user.Username, nil)
+
+	testCases := []struct {
+		folder, ancestor Folder
+		within           bool
+	}{
+		{grandchild, root, true},
+		{grandchild, grandchild, true},
+		{child, grandchild, false},
+		{other, root, false},
+	}
+	for _, tc := range testCases {
+		within, err := testQueries.IsFolderWithin(context.Background(), IsFolderWithinParams{
+			FolderID:   tc.folder.ID,
+			AncestorID: tc.ancestor.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, tc.within, within)
+	}
+}
+
+func TestDocumentFoldersAndTags(t *testing.T) {
+	document1 := createRandomDocument(t)
+	folder := createRandomFolder(t, document1.UserID, nil)
+
+	document2, err := testQueries.SetDocumentFolder(context.Background(), SetDocumentFolderParams{
+		ID:       document1.ID,
+		FolderID: pgtype.Text{String: folder.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, folder.ID, document2.FolderID.String)
+
+	for _, tag := range []string{"invoice", "2024", "invoice"} {
+		err := testQueries.AddDocumentTag(context.Background(), AddDocumentTagParams{DocumentID: document1.ID, Tag: tag})
+		require.NoError(t, err)
+	}
+	tags, err := testQueries.ListDocumentTags(context.Background(), document1.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"2024", "invoice"}, tags)
+
+	arg := ListDocumentsParams{
+		UserID:     document1.UserID,
+		FolderID:   pgtype.Text{String: folder.ID, Valid: true},
+		Tags:       []string{"invoice", "2024"},
+		SortBy:     "uploaded_at",
+		LimitCount: 10,
+	}
+	documents, err := testQueries.ListDocuments(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, []Document{document2}, documents)
+
+	err = testQueries.RemoveDocumentTag(context.Background(), RemoveDocumentTagParams{DocumentID: document1.ID, Tag: "2024"})
+	require.NoError(t, err)
+	documents, err = testQueries.ListDocuments(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, documents)
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "ocr_jobs"
\ No newline at end of file
@@ -0,0 +1,18 @@
+CREATE TABLE "ocr_jobs" (
+  "id" varchar PRIMARY KEY,
+  "document_id" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'queued',
+  "attempts" int NOT NULL DEFAULT 0,
+  "error" text,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  "updated_at" timestamp NOT NULL DEFAULT (now()),
+  "started_at" timestamp,
+  "finished_at" timestamp,
+  CONSTRAINT "ocr_jobs_status_check" CHECK ("status" IN ('queued', 'running', 'succeeded', 'failed'))
+);
+
+CREATE INDEX ON "ocr_jobs" ("status", "created_at");
+
+CREATE INDEX ON "ocr_jobs" ("document_id");
+
+ALTER TABLE "ocr_jobs" ADD FOREIGN KEY ("document_id") REFERENCES "documents" ("id");
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "document_pages"
\ No newline at end of file
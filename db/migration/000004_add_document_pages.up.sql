@@ -0,0 +1,12 @@
+CREATE TABLE "document_pages" (
+  "document_id" varchar NOT NULL,
+  "page_number" int NOT NULL,
+  "text" text NOT NULL DEFAULT '',
+  "confidence" double precision NOT NULL DEFAULT 0,
+  "width" int NOT NULL,
+  "height" int NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("document_id", "page_number")
+);
+
+ALTER TABLE "document_pages" ADD FOREIGN KEY ("document_id") REFERENCES "documents" ("id");
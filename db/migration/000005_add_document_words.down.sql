@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "document_words"
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE "document_words" (
+  "document_id" varchar NOT NULL,
+  "page_number" int NOT NULL,
+  "word_index" int NOT NULL,
+  "line_number" int NOT NULL,
+  "text" varchar NOT NULL,
+  "x" int NOT NULL,
+  "y" int NOT NULL,
+  "width" int NOT NULL,
+  "height" int NOT NULL,
+  "confidence" double precision NOT NULL,
+  PRIMARY KEY ("document_id", "page_number", "word_index")
+);
+
+ALTER TABLE "document_words" ADD FOREIGN KEY ("document_id", "page_number") REFERENCES "document_pages" ("document_id", "page_number");
@@ -0,0 +1 @@
+ALTER TABLE "document_pages" DROP COLUMN IF EXISTS "image_key"
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "document_pages" ADD COLUMN "image_key" varchar;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "documents_user_id_idx";
+DROP INDEX IF EXISTS "document_pages_text_search_idx";
+DROP INDEX IF EXISTS "extracted_texts_content_search_idx";
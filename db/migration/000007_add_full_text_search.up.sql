@@ -0,0 +1,5 @@
+CREATE INDEX "extracted_texts_content_search_idx" ON "extracted_texts" USING GIN (to_tsvector('simple', coalesce("content", '')));
+
+CREATE INDEX "document_pages_text_search_idx" ON "document_pages" USING GIN (to_tsvector('simple', "text"));
+
+CREATE INDEX ON "documents" ("user_id");
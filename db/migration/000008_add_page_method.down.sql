@@ -0,0 +1 @@
+ALTER TABLE "document_pages" DROP COLUMN IF EXISTS "method"
\ No newline at end of file
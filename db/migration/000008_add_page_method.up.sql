@@ -0,0 +1,3 @@
+ALTER TABLE "document_pages" ADD COLUMN "method" varchar NOT NULL DEFAULT 'ocr';
+
+ALTER TABLE "document_pages" ADD CONSTRAINT "document_pages_method_check" CHECK ("method" IN ('ocr', 'text_layer'));
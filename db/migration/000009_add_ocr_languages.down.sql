@@ -0,0 +1,3 @@
+ALTER TABLE "documents" DROP COLUMN IF EXISTS "languages";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "ocr_languages"
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE "users" ADD COLUMN "ocr_languages" text[] NOT NULL DEFAULT '{eng}';
+
+ALTER TABLE "documents" ADD COLUMN "languages" text[] NOT NULL DEFAULT '{eng}';
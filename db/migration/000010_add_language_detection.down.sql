@@ -0,0 +1,3 @@
+ALTER TABLE "documents" DROP COLUMN IF EXISTS "detected_script";
+
+ALTER TABLE "documents" DROP COLUMN IF EXISTS "detected_language"
\ No newline at end of file
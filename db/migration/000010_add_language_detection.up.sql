@@ -0,0 +1,3 @@
+ALTER TABLE "documents" ADD COLUMN "detected_language" varchar;
+
+ALTER TABLE "documents" ADD COLUMN "detected_script" varchar;
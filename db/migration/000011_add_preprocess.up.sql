@@ -0,0 +1 @@
+ALTER TABLE "documents" ADD COLUMN "preprocess" text[] NOT NULL DEFAULT '{}';
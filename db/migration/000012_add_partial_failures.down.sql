@@ -0,0 +1,7 @@
+ALTER TABLE "ocr_jobs" DROP COLUMN IF EXISTS "page_number";
+
+ALTER TABLE "documents" DROP COLUMN IF EXISTS "status";
+
+ALTER TABLE "document_pages" DROP COLUMN IF EXISTS "error";
+
+ALTER TABLE "document_pages" DROP COLUMN IF EXISTS "status"
\ No newline at end of file
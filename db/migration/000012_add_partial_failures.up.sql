@@ -0,0 +1,33 @@
+ALTER TABLE "document_pages" ADD COLUMN "status" varchar NOT NULL DEFAULT 'succeeded';
+
+ALTER TABLE "document_pages" ADD COLUMN "error" text;
+
+ALTER TABLE "document_pages" ADD CONSTRAINT "document_pages_status_check" CHECK ("status" IN ('succeeded', 'failed'));
+
+ALTER TABLE "documents" ADD COLUMN "status" varchar NOT NULL DEFAULT 'processing';
+
+ALTER TABLE "documents" ADD CONSTRAINT "documents_status_check" CHECK ("status" IN ('processing', 'ready', 'partial', 'failed'));
+
+-- Existing documents take the status of their latest job.
+UPDATE "documents" d
+SET "status" = CASE j."status"
+  WHEN 'succeeded' THEN 'ready'
+  WHEN 'failed' THEN 'failed'
+  ELSE 'processing'
+END
+FROM (
+  SELECT DISTINCT ON ("document_id") "document_id", "status"
+  FROM "ocr_jobs"
+  ORDER BY "document_id", "created_at" DESC
+) j
+WHERE j."document_id" = d."id";
+
+-- Documents older than the job queue were processed during their upload.
+UPDATE "documents" d
+SET "status" = CASE
+  WHEN EXISTS (SELECT 1 FROM "extracted_texts" t WHERE t."document_id" = d."id") THEN 'ready'
+  ELSE 'failed'
+END
+WHERE NOT EXISTS (SELECT 1 FROM "ocr_jobs" j WHERE j."document_id" = d."id");
+
+ALTER TABLE "ocr_jobs" ADD COLUMN "page_number" int;
@@ -0,0 +1 @@
+ALTER TABLE "documents" ADD COLUMN "storage_key" varchar;
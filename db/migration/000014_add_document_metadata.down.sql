@@ -0,0 +1,13 @@
+ALTER TABLE "document_words" DROP CONSTRAINT "document_words_document_id_page_number_fkey";
+ALTER TABLE "document_words" ADD CONSTRAINT "document_words_document_id_page_number_fkey" FOREIGN KEY ("document_id", "page_number") REFERENCES "document_pages" ("document_id", "page_number");
+
+ALTER TABLE "document_pages" DROP CONSTRAINT "document_pages_document_id_fkey";
+ALTER TABLE "document_pages" ADD CONSTRAINT "document_pages_document_id_fkey" FOREIGN KEY ("document_id") REFERENCES "documents" ("id");
+
+ALTER TABLE "ocr_jobs" DROP CONSTRAINT "ocr_jobs_document_id_fkey";
+ALTER TABLE "ocr_jobs" ADD CONSTRAINT "ocr_jobs_document_id_fkey" FOREIGN KEY ("document_id") REFERENCES "documents" ("id");
+
+ALTER TABLE "extracted_texts" DROP CONSTRAINT "extracted_texts_document_id_fkey";
+ALTER TABLE "extracted_texts" ADD CONSTRAINT "extracted_texts_document_id_fkey" FOREIGN KEY ("document_id") REFERENCES "documents" ("id");
+
+ALTER TABLE "documents" DROP COLUMN IF EXISTS "metadata"
\ No newline at end of file
@@ -0,0 +1,14 @@
+ALTER TABLE "documents" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}';
+
+-- Deleting a document deletes everything stored for it.
+ALTER TABLE "extracted_texts" DROP CONSTRAINT "extracted_texts_document_id_fkey";
+ALTER TABLE "extracted_texts" ADD CONSTRAINT "extracted_texts_document_id_fkey" FOREIGN KEY ("document_id") REFERENCES "documents" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "ocr_jobs" DROP CONSTRAINT "ocr_jobs_document_id_fkey";
+ALTER TABLE "ocr_jobs" ADD CONSTRAINT "ocr_jobs_document_id_fkey" FOREIGN KEY ("document_id") REFERENCES "documents" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "document_pages" DROP CONSTRAINT "document_pages_document_id_fkey";
+ALTER TABLE "document_pages" ADD CONSTRAINT "document_pages_document_id_fkey" FOREIGN KEY ("document_id") REFERENCES "documents" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "document_words" DROP CONSTRAINT "document_words_document_id_page_number_fkey";
+ALTER TABLE "document_words" ADD CONSTRAINT "document_words_document_id_page_number_fkey" FOREIGN KEY ("document_id", "page_number") REFERENCES "document_pages" ("document_id", "page_number") ON DELETE CASCADE;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "document_tags";
+
+ALTER TABLE "documents" DROP COLUMN IF EXISTS "folder_id";
+
+DROP TABLE IF EXISTS "folders"
\ No newline at end of file
@@ -0,0 +1,33 @@
+CREATE TABLE "folders" (
+  "id" varchar PRIMARY KEY,
+  "user_id" varchar NOT NULL,
+  "parent_id" varchar,
+  "name" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "folders" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("username");
+
+-- Deleting a folder deletes its subfolders, the documents in them move to
+-- the root.
+ALTER TABLE "folders" ADD FOREIGN KEY ("parent_id") REFERENCES "folders" ("id") ON DELETE CASCADE;
+
+-- Sibling folders have different names, top-level folders per user.
+CREATE UNIQUE INDEX "folders_user_id_parent_id_name_idx" ON "folders" ("user_id", coalesce("parent_id", ''), "name");
+
+ALTER TABLE "documents" ADD COLUMN "folder_id" varchar;
+
+ALTER TABLE "documents" ADD FOREIGN KEY ("folder_id") REFERENCES "folders" ("id") ON DELETE SET NULL;
+
+CREATE INDEX ON "documents" ("folder_id");
+
+CREATE TABLE "document_tags" (
+  "document_id" varchar NOT NULL,
+  "tag" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("document_id", "tag")
+);
+
+ALTER TABLE "document_tags" ADD FOREIGN KEY ("document_id") REFERENCES "documents" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "document_tags" ("tag");
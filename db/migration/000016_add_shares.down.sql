@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "folder_shares";
+
+DROP TABLE IF EXISTS "document_shares"
\ No newline at end of file
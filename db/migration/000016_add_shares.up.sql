@@ -0,0 +1,32 @@
+CREATE TABLE "document_shares" (
+  "document_id" varchar NOT NULL,
+  "username" varchar NOT NULL,
+  "role" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("document_id", "username")
+);
+
+ALTER TABLE "document_shares" ADD CONSTRAINT "document_shares_role_check" CHECK ("role" IN ('viewer', 'editor'));
+
+ALTER TABLE "document_shares" ADD FOREIGN KEY ("document_id") REFERENCES "documents" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "document_shares" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "document_shares" ("username");
+
+-- A folder share covers the documents and subfolders of the folder.
+CREATE TABLE "folder_shares" (
+  "folder_id" varchar NOT NULL,
+  "username" varchar NOT NULL,
+  "role" varchar NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("folder_id", "username")
+);
+
+ALTER TABLE "folder_shares" ADD CONSTRAINT "folder_shares_role_check" CHECK ("role" IN ('viewer', 'editor'));
+
+ALTER TABLE "folder_shares" ADD FOREIGN KEY ("folder_id") REFERENCES "folders" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "folder_shares" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "folder_shares" ("username");
@@ -0,0 +1,22 @@
+-- Public links to a document. The link token carries the id, the link
+-- expires with the token.
+CREATE TABLE "share_links" (
+  "id" uuid PRIMARY KEY,
+  "document_id" varchar NOT NULL,
+  "created_by" varchar NOT NULL,
+  "content" varchar NOT NULL,
+  "password_hash" varchar,
+  "max_downloads" int,
+  "downloads" int NOT NULL DEFAULT 0,
+  "expires_at" timestamp NOT NULL,
+  "revoked_at" timestamp,
+  "created_at" timestamp NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "share_links" ADD CONSTRAINT "share_links_content_check" CHECK ("content" IN ('text', 'file'));
+
+ALTER TABLE "share_links" ADD FOREIGN KEY ("document_id") REFERENCES "documents" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "share_links" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("username");
+
+CREATE INDEX ON "share_links" ("document_id");
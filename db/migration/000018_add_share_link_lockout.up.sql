@@ -0,0 +1,4 @@
+-- Wrong passwords in a row for a link, which lock it for a while once there
+-- are too many.
+ALTER TABLE "share_links" ADD COLUMN "password_failures" int NOT NULL DEFAULT 0;
+ALTER TABLE "share_links" ADD COLUMN "locked_until" timestamp;
@@ -0,0 +1 @@
+ALTER TABLE "documents" DROP COLUMN IF EXISTS "dpi"
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Resolution of the page images, null when the upload didn't say.
+ALTER TABLE "documents" ADD COLUMN "dpi" int;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "extracted_texts_document_id_created_at_idx"
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Finds the latest text of a document, for search and exports.
+CREATE INDEX ON "extracted_texts" ("document_id", "created_at");
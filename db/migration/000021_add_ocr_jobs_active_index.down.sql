@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "ocr_jobs_active_document_idx"
\ No newline at end of file
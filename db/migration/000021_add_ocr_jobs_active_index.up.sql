@@ -0,0 +1,17 @@
+-- A document has at most one job queued or running, so page retries and
+-- whole-document jobs never rewrite its text at the same time. Older jobs
+-- still active next to a newer one are dropped first.
+UPDATE "ocr_jobs" j
+SET "status" = 'failed',
+    "error" = 'superseded by a later job',
+    "finished_at" = now(),
+    "updated_at" = now()
+WHERE j."status" IN ('queued', 'running')
+  AND EXISTS (
+    SELECT 1 FROM "ocr_jobs" later
+    WHERE later."document_id" = j."document_id"
+      AND later."status" IN ('queued', 'running')
+      AND (later."created_at", later."id") > (j."created_at", j."id")
+  );
+
+CREATE UNIQUE INDEX "ocr_jobs_active_document_idx" ON "ocr_jobs" ("document_id") WHERE "status" IN ('queued', 'running');
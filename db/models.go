@@ -5,15 +5,66 @@
 package db
 
 import (
+	"encoding/json"
+
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Document struct {
-	ID         string           `json:"id"`
-	UserID     string           `json:"user_id"`
-	Filename   pgtype.Text      `json:"filename"`
-	FileType   pgtype.Text      `json:"file_type"`
-	UploadedAt pgtype.Timestamp `json:"uploaded_at"`
+	ID               string           `json:"id"`
+	UserID           string           `json:"user_id"`
+	Filename         pgtype.Text      `json:"filename"`
+	FileType         pgtype.Text      `json:"file_type"`
+	UploadedAt       pgtype.Timestamp `json:"uploaded_at"`
+	Languages        []string         `json:"languages"`
+	DetectedLanguage pgtype.Text      `json:"detected_language"`
+	DetectedScript   pgtype.Text      `json:"detected_script"`
+	Preprocess       []string         `json:"preprocess"`
+	Status           string           `json:"status"`
+	StorageKey       pgtype.Text      `json:"storage_key"`
+	Metadata         json.RawMessage  `json:"metadata"`
+	FolderID         pgtype.Text      `json:"folder_id"`
+	Dpi              pgtype.Int4      `json:"dpi"`
+}
+
+type DocumentPage struct {
+	DocumentID string           `json:"document_id"`
+	PageNumber int32            `json:"page_number"`
+	Text       string           `json:"text"`
+	Confidence float64          `json:"confidence"`
+	Width      int32            `json:"width"`
+	Height     int32            `json:"height"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+	ImageKey   pgtype.Text      `json:"image_key"`
+	Method     string           `json:"method"`
+	Status     string           `json:"status"`
+	Error      pgtype.Text      `json:"error"`
+}
+
+type DocumentShare struct {
+	DocumentID string           `json:"document_id"`
+	Username   string           `json:"username"`
+	Role       string           `json:"role"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
+type DocumentTag struct {
+	DocumentID string           `json:"document_id"`
+	Tag        string           `json:"tag"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
+type DocumentWord struct {
+	DocumentID string  `json:"document_id"`
+	PageNumber int32   `json:"page_number"`
+	WordIndex  int32   `json:"word_index"`
+	LineNumber int32   `json:"line_number"`
+	Text       string  `json:"text"`
+	X          int32   `json:"x"`
+	Y          int32   `json:"y"`
+	Width      int32   `json:"width"`
+	Height     int32   `json:"height"`
+	Confidence float64 `json:"confidence"`
 }
 
 type ExtractedText struct {
@@ -23,6 +74,34 @@ type ExtractedText struct {
 	CreatedAt  pgtype.Timestamp `json:"created_at"`
 }
 
+type Folder struct {
+	ID        string           `json:"id"`
+	UserID    string           `json:"user_id"`
+	ParentID  pgtype.Text      `json:"parent_id"`
+	Name      string           `json:"name"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type FolderShare struct {
+	FolderID  string           `json:"folder_id"`
+	Username  string           `json:"username"`
+	Role      string           `json:"role"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type OcrJob struct {
+	ID         string           `json:"id"`
+	DocumentID string           `json:"document_id"`
+	Status     string           `json:"status"`
+	Attempts   int32            `json:"attempts"`
+	Error      pgtype.Text      `json:"error"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+	UpdatedAt  pgtype.Timestamp `json:"updated_at"`
+	StartedAt  pgtype.Timestamp `json:"started_at"`
+	FinishedAt pgtype.Timestamp `json:"finished_at"`
+	PageNumber pgtype.Int4      `json:"page_number"`
+}
+
 type Session struct {
 	ID           pgtype.UUID      `json:"id"`
 	Username     string           `json:"username"`
@@ -34,10 +113,26 @@ type Session struct {
 	CreatedAt    pgtype.Timestamp `json:"created_at"`
 }
 
+type ShareLink struct {
+	ID               pgtype.UUID      `json:"id"`
+	DocumentID       string           `json:"document_id"`
+	CreatedBy        string           `json:"created_by"`
+	Content          string           `json:"content"`
+	PasswordHash     pgtype.Text      `json:"password_hash"`
+	MaxDownloads     pgtype.Int4      `json:"max_downloads"`
+	Downloads        int32            `json:"downloads"`
+	ExpiresAt        pgtype.Timestamp `json:"expires_at"`
+	RevokedAt        pgtype.Timestamp `json:"revoked_at"`
+	CreatedAt        pgtype.Timestamp `json:"created_at"`
+	PasswordFailures int32            `json:"password_failures"`
+	LockedUntil      pgtype.Timestamp `json:"locked_until"`
+}
+
 type User struct {
 	Username     string           `json:"username"`
 	Email        string           `json:"email"`
 	PasswordHash pgtype.Text      `json:"password_hash"`
 	Provider     pgtype.Text      `json:"provider"`
 	CreatedAt    pgtype.Timestamp `json:"created_at"`
+	OcrLanguages []string         `json:"ocr_languages"`
 }
@@ -0,0 +1,193 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: ocr_jobs.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimOCRJob = `-- name: ClaimOCRJob :one
+UPDATE ocr_jobs
+SET status = 'running',
+    attempts = attempts + 1,
+    started_at = now(),
+    updated_at = now()
+WHERE id = (
+  SELECT id FROM ocr_jobs
+  WHERE status = 'queued'
+  ORDER BY created_at
+  LIMIT 1
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, document_id, status, attempts, error, created_at, updated_at, started_at, finished_at, page_number
+`
+
+func (q *Queries) ClaimOCRJob(ctx context.Context) (OcrJob, error) {
+	row := q.db.QueryRow(ctx, claimOCRJob)
+	var i OcrJob
+	err := row.Scan(
+		&i.ID,
+		&i.DocumentID,
+		&i.Status,
+		&i.Attempts,
+		&i.Error,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.PageNumber,
+	)
+	return i, err
+}
+
+const completeOCRJob = `-- name: CompleteOCRJob :exec
+UPDATE ocr_jobs
+SET status = 'succeeded',
+    error = NULL,
+    finished_at = now(),
+    updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) CompleteOCRJob(ctx context.Context, id string) error {
+	_, err := q.db.Exec(ctx, completeOCRJob, id)
+	return err
+}
+
+const createOCRJob = `-- name: CreateOCRJob :one
+INSERT INTO ocr_jobs (id, document_id, page_number)
+VALUES ($1, $2, $3)
+ON CONFLICT (document_id) WHERE status IN ('queued', 'running') DO NOTHING
+RETURNING id, document_id, status, attempts, error, created_at, updated_at, started_at, finished_at, page_number
+`
+
+type CreateOCRJobParams struct {
+	ID         string      `json:"id"`
+	DocumentID string      `json:"document_id"`
+	PageNumber pgtype.Int4 `json:"page_number"`
+}
+
+// Queues a job, unless the document has one queued or running already: then
+// no row is returned.
+func (q *Queries) CreateOCRJob(ctx context.Context, arg CreateOCRJobParams) (OcrJob, error) {
+	row := q.db.QueryRow(ctx, createOCRJob, arg.ID, arg.DocumentID, arg.PageNumber)
+	var i OcrJob
+	err := row.Scan(
+		&i.ID,
+		&i.DocumentID,
+		&i.Status,
+		&i.Attempts,
+		&i.Error,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.PageNumber,
+	)
+	return i, err
+}
+
+const failOCRJob = `-- name: FailOCRJob :exec
+UPDATE ocr_jobs
+SET status = 'failed',
+    error = $2,
+    finished_at = now(),
+    updated_at = now()
+WHERE id = $1
+`
+
+type FailOCRJobParams struct {
+	ID    string      `json:"id"`
+	Error pgtype.Text `json:"error"`
+}
+
+func (q *Queries) FailOCRJob(ctx context.Context, arg FailOCRJobParams) error {
+	_, err := q.db.Exec(ctx, failOCRJob, arg.ID, arg.Error)
+	return err
+}
+
+const getLatestOCRJobByDocument = `-- name: GetLatestOCRJobByDocument :one
+SELECT id, document_id, status, attempts, error, created_at, updated_at, started_at, finished_at, page_number FROM ocr_jobs
+WHERE document_id = $1
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestOCRJobByDocument(ctx context.Context, documentID string) (OcrJob, error) {
+	row := q.db.QueryRow(ctx, getLatestOCRJobByDocument, documentID)
+	var i OcrJob
+	err := row.Scan(
+		&i.ID,
+		&i.DocumentID,
+		&i.Status,
+		&i.Attempts,
+		&i.Error,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.PageNumber,
+	)
+	return i, err
+}
+
+const getOCRJob = `-- name: GetOCRJob :one
+SELECT id, document_id, status, attempts, error, created_at, updated_at, started_at, finished_at, page_number FROM ocr_jobs
+WHERE id = $1
+`
+
+func (q *Queries) GetOCRJob(ctx context.Context, id string) (OcrJob, error) {
+	row := q.db.QueryRow(ctx, getOCRJob, id)
+	var i OcrJob
+	err := row.Scan(
+		&i.ID,
+		&i.DocumentID,
+		&i.Status,
+		&i.Attempts,
+		&i.Error,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.PageNumber,
+	)
+	return i, err
+}
+
+const requeueStaleOCRJobs = `-- name: RequeueStaleOCRJobs :execrows
+UPDATE ocr_jobs
+SET status = 'queued',
+    updated_at = now()
+WHERE status = 'running' AND updated_at < $1
+`
+
+func (q *Queries) RequeueStaleOCRJobs(ctx context.Context, updatedAt pgtype.Timestamp) (int64, error) {
+	result, err := q.db.Exec(ctx, requeueStaleOCRJobs, updatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const retryOCRJob = `-- name: RetryOCRJob :exec
+UPDATE ocr_jobs
+SET status = 'queued',
+    error = $2,
+    updated_at = now()
+WHERE id = $1
+`
+
+type RetryOCRJobParams struct {
+	ID    string      `json:"id"`
+	Error pgtype.Text `json:"error"`
+}
+
+func (q *Queries) RetryOCRJob(ctx context.Context, arg RetryOCRJobParams) error {
+	_, err := q.db.Exec(ctx, retryOCRJob, arg.ID, arg.Error)
+	return err
+}
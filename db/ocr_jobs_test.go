@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomOCRJob(t *testing.T) OcrJob {
+	document := createRandomDocument(t)
+
+	arg := CreateOCRJobParams{
+		ID:         uuid.New().String(),
+		DocumentID: document.ID,
+	}
+
+	job, err := testQueries.CreateOCRJob(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, job.ID)
+	require.Equal(t, arg.DocumentID, job.DocumentID)
+	require.Equal(t, "queued", job.Status)
+	require.Zero(t, job.Attempts)
+	require.False(t, job.Error.Valid)
+	require.False(t, job.PageNumber.Valid)
+	return job
+}
+
+func TestCreatePageOCRJob(t *testing.T) {
+	document := createRandomDocument(t)
+
+	arg := CreateOCRJobParams{
+		ID:         uuid.New().String(),
+		DocumentID: document.ID,
+		PageNumber: pgtype.Int4{Int32: 3, Valid: true},
+	}
+
+	job, err := testQueries.CreateOCRJob(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.PageNumber, job.PageNumber)
+}
+
+func TestCreateOCRJobBusy(t *testing.T) {
+	job1 := createRandomOCRJob(t)
+
+	// No other job while the first is queued or running.
+	arg := CreateOCRJobParams{
+		ID:         uuid.New().String(),
+		DocumentID: job1.DocumentID,
+		PageNumber: pgtype.Int4{Int32: 2, Valid: true},
+	}
+	_, err := testQueries.CreateOCRJob(context.Background(), arg)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	err = testQueries.CompleteOCRJob(context.Background(), job1.ID)
+	require.NoError(t, err)
+
+	job2, err := testQueries.CreateOCRJob(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, job2.ID)
+}
+
+func TestClaimOCRJob(t *testing.T) {
+	createRandomOCRJob(t)
+
+	job, err := testQueries.ClaimOCRJob(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "running", job.Status)
+	require.GreaterOrEqual(t, job.Attempts, int32(1))
+	require.True(t, job.StartedAt.Valid)
+}
+
+func TestClaimOCRJobConcurrent(t *testing.T) {
+	n := 5
+	for range n {
+		createRandomOCRJob(t)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	claimed := make(map[string]bool)
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job, err := testQueries.ClaimOCRJob(context.Background())
+			require.NoError(t, err)
+
+			mu.Lock()
+			defer mu.Unlock()
+			require.False(t, claimed[job.ID], "job %s claimed twice", job.ID)
+			claimed[job.ID] = true
+		}()
+	}
+	wg.Wait()
+	require.Len(t, claimed, n)
+}
+
+func TestFailOCRJob(t *testing.T) {
+	job1 := createRandomOCRJob(t)
+
+	arg := FailOCRJobParams{
+		ID:    job1.ID,
+		Error: pgtype.Text{String: "boom", Valid: true},
+	}
+	err := testQueries.FailOCRJob(context.Background(), arg)
+	require.NoError(t, err)
+
+	job2, err := testQueries.GetLatestOCRJobByDocument(context.Background(), job1.DocumentID)
+	require.NoError(t, err)
+	require.Equal(t, job1.ID, job2.ID)
+	require.Equal(t, "failed", job2.Status)
+	require.Equal(t, arg.Error, job2.Error)
+	require.True(t, job2.FinishedAt.Valid)
+}
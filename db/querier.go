@@ -0,0 +1,113 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Querier interface {
+	AddDocumentTag(ctx context.Context, arg AddDocumentTagParams) error
+	ClaimOCRJob(ctx context.Context) (OcrJob, error)
+	CompleteOCRJob(ctx context.Context, id string) error
+	// Counts the documents ListDocuments goes through, with the same filters.
+	CountDocuments(ctx context.Context, arg CountDocumentsParams) (int64, error)
+	CreateDocument(ctx context.Context, arg CreateDocumentParams) (Document, error)
+	CreateDocumentWords(ctx context.Context, arg []CreateDocumentWordsParams) (int64, error)
+	CreateExtractedText(ctx context.Context, arg CreateExtractedTextParams) (ExtractedText, error)
+	CreateFolder(ctx context.Context, arg CreateFolderParams) (Folder, error)
+	// Queues a job, unless the document has one queued or running already: then
+	// no row is returned.
+	CreateOCRJob(ctx context.Context, arg CreateOCRJobParams) (OcrJob, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateShareLink(ctx context.Context, arg CreateShareLinkParams) (ShareLink, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteDocument(ctx context.Context, id string) error
+	DeleteDocumentPageWords(ctx context.Context, arg DeleteDocumentPageWordsParams) error
+	DeleteDocumentShare(ctx context.Context, arg DeleteDocumentShareParams) (int64, error)
+	DeleteExtractedText(ctx context.Context, id string) error
+	DeleteFolderShare(ctx context.Context, arg DeleteFolderShareParams) (int64, error)
+	// Deletes a document unless it has an OCR job queued or running.
+	DeleteIdleDocument(ctx context.Context, id string) (int64, error)
+	DeleteUser(ctx context.Context, username string) error
+	FailOCRJob(ctx context.Context, arg FailOCRJobParams) error
+	// Counts a wrong password. The max_failures-th in a row locks the link for
+	// lockout_seconds and starts the count over.
+	FailShareLinkPassword(ctx context.Context, arg FailShareLinkPasswordParams) (ShareLink, error)
+	GetDocumentByID(ctx context.Context, id string) (Document, error)
+	GetDocumentPage(ctx context.Context, arg GetDocumentPageParams) (DocumentPage, error)
+	GetExtractedTextByID(ctx context.Context, id string) (ExtractedText, error)
+	GetFolderByID(ctx context.Context, id string) (Folder, error)
+	GetLatestExtractedTextByDocument(ctx context.Context, documentID string) (ExtractedText, error)
+	GetLatestOCRJobByDocument(ctx context.Context, documentID string) (OcrJob, error)
+	GetOCRJob(ctx context.Context, id string) (OcrJob, error)
+	GetSession(ctx context.Context, id pgtype.UUID) (Session, error)
+	GetShareLink(ctx context.Context, id pgtype.UUID) (ShareLink, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserByUsername(ctx context.Context, username string) (User, error)
+	// Reports whether folder_id is ancestor_id or one of its subfolders.
+	IsFolderWithin(ctx context.Context, arg IsFolderWithinParams) (bool, error)
+	// Roles given to username on a document, directly or through folder_id, the
+	// folder the document is in, or one of its ancestors.
+	ListDocumentGrants(ctx context.Context, arg ListDocumentGrantsParams) ([]string, error)
+	ListDocumentPageWords(ctx context.Context, arg ListDocumentPageWordsParams) ([]DocumentWord, error)
+	ListDocumentPages(ctx context.Context, documentID string) ([]DocumentPage, error)
+	ListDocumentShares(ctx context.Context, documentID string) ([]DocumentShare, error)
+	ListDocumentTags(ctx context.Context, documentID string) ([]string, error)
+	ListDocumentWords(ctx context.Context, documentID string) ([]DocumentWord, error)
+	// Filters left null match every document, documents have every tag of
+	// tags. Pages are keyset paginated: the
+	// after_* arguments are the sort key and id of the last document of the
+	// previous page, null for the first page. Documents are sorted by
+	// sort_by, uploaded_at or filename, then by id.
+	ListDocuments(ctx context.Context, arg ListDocumentsParams) ([]Document, error)
+	ListDocumentsByUser(ctx context.Context, arg ListDocumentsByUserParams) ([]Document, error)
+	ListDocumentsSharedWithUser(ctx context.Context, username string) ([]ListDocumentsSharedWithUserRow, error)
+	ListExtractedTextsByDocument(ctx context.Context, arg ListExtractedTextsByDocumentParams) ([]ExtractedText, error)
+	ListFailedDocumentPages(ctx context.Context, documentID string) ([]ListFailedDocumentPagesRow, error)
+	ListFolderChildren(ctx context.Context, parentID pgtype.Text) ([]Folder, error)
+	// Roles given to username on a folder, directly or through one of its
+	// ancestors.
+	ListFolderGrants(ctx context.Context, arg ListFolderGrantsParams) ([]string, error)
+	ListFolderShares(ctx context.Context, folderID string) ([]FolderShare, error)
+	ListFoldersByUser(ctx context.Context, userID string) ([]Folder, error)
+	ListFoldersSharedWithUser(ctx context.Context, username string) ([]ListFoldersSharedWithUserRow, error)
+	// Shares of a folder and of its ancestors, which everything in the folder
+	// inherits.
+	ListInheritedFolderShares(ctx context.Context, folderID string) ([]ListInheritedFolderSharesRow, error)
+	ListShareLinks(ctx context.Context, documentID string) ([]ShareLink, error)
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	MoveFolder(ctx context.Context, arg MoveFolderParams) (Folder, error)
+	RemoveDocumentTag(ctx context.Context, arg RemoveDocumentTagParams) error
+	RenameFolder(ctx context.Context, arg RenameFolderParams) (Folder, error)
+	RequeueStaleOCRJobs(ctx context.Context, updatedAt pgtype.Timestamp) (int64, error)
+	ResetShareLinkPasswordFailures(ctx context.Context, id pgtype.UUID) error
+	RetryOCRJob(ctx context.Context, arg RetryOCRJobParams) error
+	RevokeShareLink(ctx context.Context, arg RevokeShareLinkParams) (ShareLink, error)
+	// Matches are ranked against the whole-document text, the latest one saved
+	// for the document. Texts are matched first, through
+	// extracted_texts_content_search_idx, and those a later text of their
+	// document replaced are dropped after. The snippet is built from
+	// HTML-escaped text so the <mark> tags are the only markup in it.
+	SearchDocuments(ctx context.Context, arg SearchDocumentsParams) ([]SearchDocumentsRow, error)
+	SetDocumentDPI(ctx context.Context, arg SetDocumentDPIParams) error
+	SetDocumentFolder(ctx context.Context, arg SetDocumentFolderParams) (Document, error)
+	SetDocumentLanguages(ctx context.Context, arg SetDocumentLanguagesParams) error
+	SetDocumentStatus(ctx context.Context, arg SetDocumentStatusParams) error
+	UpdateDocument(ctx context.Context, arg UpdateDocumentParams) (Document, error)
+	UpdateDocumentFilename(ctx context.Context, arg UpdateDocumentFilenameParams) error
+	UpdateExtractedTextContent(ctx context.Context, arg UpdateExtractedTextContentParams) error
+	UpdateUserOCRLanguages(ctx context.Context, arg UpdateUserOCRLanguagesParams) (User, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	UpsertDocumentPage(ctx context.Context, arg UpsertDocumentPageParams) (DocumentPage, error)
+	UpsertDocumentShare(ctx context.Context, arg UpsertDocumentShareParams) (DocumentShare, error)
+	UpsertFolderShare(ctx context.Context, arg UpsertFolderShareParams) (FolderShare, error)
+	// Counts a download, unless the link is revoked or its downloads are used up.
+	UseShareLink(ctx context.Context, id pgtype.UUID) (ShareLink, error)
+}
+
+var _ Querier = (*Queries)(nil)
@@ -0,0 +1,27 @@
+-- name: UpsertDocumentPage :one
+INSERT INTO document_pages (document_id, page_number, text, confidence, width, height, image_key, method, status, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (document_id, page_number) DO UPDATE
+SET text = EXCLUDED.text,
+    confidence = EXCLUDED.confidence,
+    width = EXCLUDED.width,
+    height = EXCLUDED.height,
+    image_key = EXCLUDED.image_key,
+    method = EXCLUDED.method,
+    status = EXCLUDED.status,
+    error = EXCLUDED.error
+RETURNING *;
+
+-- name: GetDocumentPage :one
+SELECT * FROM document_pages
+WHERE document_id = $1 AND page_number = $2;
+
+-- name: ListDocumentPages :many
+SELECT * FROM document_pages
+WHERE document_id = $1
+ORDER BY page_number;
+
+-- name: ListFailedDocumentPages :many
+SELECT page_number, error FROM document_pages
+WHERE document_id = $1 AND status = 'failed'
+ORDER BY page_number;
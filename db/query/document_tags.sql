@@ -0,0 +1,13 @@
+-- name: AddDocumentTag :exec
+INSERT INTO document_tags (document_id, tag)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveDocumentTag :exec
+DELETE FROM document_tags
+WHERE document_id = $1 AND tag = $2;
+
+-- name: ListDocumentTags :many
+SELECT tag FROM document_tags
+WHERE document_id = $1
+ORDER BY tag;
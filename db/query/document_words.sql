@@ -0,0 +1,28 @@
+-- name: CreateDocumentWords :copyfrom
+INSERT INTO document_words (
+  document_id,
+  page_number,
+  word_index,
+  line_number,
+  text,
+  x,
+  y,
+  width,
+  height,
+  confidence
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+
+-- name: ListDocumentPageWords :many
+SELECT * FROM document_words
+WHERE document_id = $1 AND page_number = $2
+ORDER BY word_index;
+
+-- name: ListDocumentWords :many
+SELECT * FROM document_words
+WHERE document_id = $1
+ORDER BY page_number, word_index;
+
+-- name: DeleteDocumentPageWords :exec
+DELETE FROM document_words
+WHERE document_id = $1 AND page_number = $2;
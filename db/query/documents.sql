@@ -1,6 +1,6 @@
 -- name: CreateDocument :one
-INSERT INTO documents (id, user_id, filename, file_type)
-VALUES ($1, $2, $3, $4)
+INSERT INTO documents (id, user_id, filename, file_type, languages, preprocess, storage_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 RETURNING *;
 
 -- name: GetDocumentByID :one
@@ -12,12 +12,102 @@ SELECT * FROM documents
 WHERE user_id = $1
 ORDER BY uploaded_at DESC LIMIT $2 OFFSET $3;
 
+-- name: ListDocuments :many
+-- Filters left null match every document, documents have every tag of
+-- tags. Pages are keyset paginated: the
+-- after_* arguments are the sort key and id of the last document of the
+-- previous page, null for the first page. Documents are sorted by
+-- sort_by, uploaded_at or filename, then by id.
+SELECT * FROM documents
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(file_type)::varchar IS NULL OR file_type = sqlc.narg(file_type))
+  AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(uploaded_from)::timestamp IS NULL OR uploaded_at >= sqlc.narg(uploaded_from))
+  AND (sqlc.narg(uploaded_to)::timestamp IS NULL OR uploaded_at < sqlc.narg(uploaded_to))
+  AND (sqlc.narg(language)::varchar IS NULL OR sqlc.narg(language) = ANY(languages))
+  AND (sqlc.narg(filename_pattern)::varchar IS NULL OR filename ILIKE sqlc.narg(filename_pattern))
+  AND (sqlc.narg(folder_id)::varchar IS NULL OR folder_id = sqlc.narg(folder_id))
+  AND (sqlc.narg(tags)::varchar[] IS NULL OR sqlc.narg(tags)::varchar[] <@ ARRAY(
+    SELECT tag FROM document_tags WHERE document_id = documents.id
+  )::varchar[])
+  AND (sqlc.narg(after_id)::varchar IS NULL OR CASE
+    WHEN sqlc.arg(sort_by)::varchar = 'filename' AND sqlc.arg(descending)::boolean
+      THEN (coalesce(filename, ''), id) < (sqlc.narg(after_filename)::varchar, sqlc.narg(after_id))
+    WHEN sqlc.arg(sort_by)::varchar = 'filename'
+      THEN (coalesce(filename, ''), id) > (sqlc.narg(after_filename)::varchar, sqlc.narg(after_id))
+    WHEN sqlc.arg(descending)::boolean
+      THEN (coalesce(uploaded_at, '-infinity'), id) < (sqlc.narg(after_uploaded_at)::timestamp, sqlc.narg(after_id))
+    ELSE (coalesce(uploaded_at, '-infinity'), id) > (sqlc.narg(after_uploaded_at)::timestamp, sqlc.narg(after_id))
+  END)
+ORDER BY
+  CASE WHEN sqlc.arg(sort_by)::varchar = 'filename' AND NOT sqlc.arg(descending)::boolean THEN coalesce(filename, '') END ASC,
+  CASE WHEN sqlc.arg(sort_by)::varchar = 'filename' AND sqlc.arg(descending)::boolean THEN coalesce(filename, '') END DESC,
+  CASE WHEN sqlc.arg(sort_by)::varchar = 'uploaded_at' AND NOT sqlc.arg(descending)::boolean THEN coalesce(uploaded_at, '-infinity') END ASC,
+  CASE WHEN sqlc.arg(sort_by)::varchar = 'uploaded_at' AND sqlc.arg(descending)::boolean THEN coalesce(uploaded_at, '-infinity') END DESC,
+  CASE WHEN NOT sqlc.arg(descending)::boolean THEN id END ASC,
+  CASE WHEN sqlc.arg(descending)::boolean THEN id END DESC
+LIMIT sqlc.arg(limit_count);
+
+-- name: CountDocuments :one
+-- Counts the documents ListDocuments goes through, with the same filters.
+SELECT count(*) FROM documents
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(file_type)::varchar IS NULL OR file_type = sqlc.narg(file_type))
+  AND (sqlc.narg(status)::varchar IS NULL OR status = sqlc.narg(status))
+  AND (sqlc.narg(uploaded_from)::timestamp IS NULL OR uploaded_at >= sqlc.narg(uploaded_from))
+  AND (sqlc.narg(uploaded_to)::timestamp IS NULL OR uploaded_at < sqlc.narg(uploaded_to))
+  AND (sqlc.narg(language)::varchar IS NULL OR sqlc.narg(language) = ANY(languages))
+  AND (sqlc.narg(filename_pattern)::varchar IS NULL OR filename ILIKE sqlc.narg(filename_pattern))
+  AND (sqlc.narg(folder_id)::varchar IS NULL OR folder_id = sqlc.narg(folder_id))
+  AND (sqlc.narg(tags)::varchar[] IS NULL OR sqlc.narg(tags)::varchar[] <@ ARRAY(
+    SELECT tag FROM document_tags WHERE document_id = documents.id
+  )::varchar[]);
+
 -- name: UpdateDocumentFilename :exec
 UPDATE documents
 SET filename = $2
 WHERE id = $1;
 
+-- name: UpdateDocument :one
+UPDATE documents
+SET filename = coalesce(sqlc.narg(filename), filename),
+    metadata = coalesce(sqlc.narg(metadata), metadata)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: SetDocumentLanguages :exec
+UPDATE documents
+SET languages = $2,
+    detected_language = $3,
+    detected_script = $4
+WHERE id = $1;
+
+-- name: SetDocumentDPI :exec
+UPDATE documents
+SET dpi = $2
+WHERE id = $1;
+
+-- name: SetDocumentFolder :one
+UPDATE documents
+SET folder_id = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: SetDocumentStatus :exec
+UPDATE documents
+SET status = $2
+WHERE id = $1;
+
 -- name: DeleteDocument :exec
 DELETE FROM documents
 WHERE id = $1;
 
+-- name: DeleteIdleDocument :execrows
+-- Deletes a document unless it has an OCR job queued or running.
+DELETE FROM documents d
+WHERE d.id = $1
+  AND NOT EXISTS (
+    SELECT 1 FROM ocr_jobs
+    WHERE document_id = d.id AND status IN ('queued', 'running')
+  );
+
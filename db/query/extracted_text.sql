@@ -21,3 +21,9 @@ WHERE id = $1;
 DELETE FROM extracted_texts
 WHERE id = $1;
 
+
+-- name: GetLatestExtractedTextByDocument :one
+SELECT * FROM extracted_texts
+WHERE document_id = $1
+ORDER BY created_at DESC
+LIMIT 1;
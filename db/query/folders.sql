@@ -0,0 +1,42 @@
+-- name: CreateFolder :one
+INSERT INTO folders (id, user_id, parent_id, name)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetFolderByID :one
+SELECT * FROM folders
+WHERE id = $1;
+
+-- name: ListFoldersByUser :many
+SELECT * FROM folders
+WHERE user_id = $1
+ORDER BY name, id;
+
+-- name: RenameFolder :one
+UPDATE folders
+SET name = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: MoveFolder :one
+UPDATE folders
+SET parent_id = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: IsFolderWithin :one
+-- Reports whether folder_id is ancestor_id or one of its subfolders.
+WITH RECURSIVE ancestors AS (
+  SELECT folders.id, folders.parent_id FROM folders
+  WHERE folders.id = sqlc.arg(folder_id)::varchar
+  UNION
+  SELECT f.id, f.parent_id FROM folders f
+  JOIN ancestors a ON f.id = a.parent_id
+)
+SELECT (count(*) > 0)::boolean AS within FROM ancestors
+WHERE ancestors.id = sqlc.arg(ancestor_id)::varchar;
+
+-- name: ListFolderChildren :many
+SELECT * FROM folders
+WHERE parent_id = $1
+ORDER BY name, id;
@@ -0,0 +1,61 @@
+-- name: CreateOCRJob :one
+-- Queues a job, unless the document has one queued or running already: then
+-- no row is returned.
+INSERT INTO ocr_jobs (id, document_id, page_number)
+VALUES ($1, $2, $3)
+ON CONFLICT (document_id) WHERE status IN ('queued', 'running') DO NOTHING
+RETURNING *;
+
+-- name: GetOCRJob :one
+SELECT * FROM ocr_jobs
+WHERE id = $1;
+
+-- name: GetLatestOCRJobByDocument :one
+SELECT * FROM ocr_jobs
+WHERE document_id = $1
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: ClaimOCRJob :one
+UPDATE ocr_jobs
+SET status = 'running',
+    attempts = attempts + 1,
+    started_at = now(),
+    updated_at = now()
+WHERE id = (
+  SELECT id FROM ocr_jobs
+  WHERE status = 'queued'
+  ORDER BY created_at
+  LIMIT 1
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteOCRJob :exec
+UPDATE ocr_jobs
+SET status = 'succeeded',
+    error = NULL,
+    finished_at = now(),
+    updated_at = now()
+WHERE id = $1;
+
+-- name: RetryOCRJob :exec
+UPDATE ocr_jobs
+SET status = 'queued',
+    error = $2,
+    updated_at = now()
+WHERE id = $1;
+
+-- name: FailOCRJob :exec
+UPDATE ocr_jobs
+SET status = 'failed',
+    error = $2,
+    finished_at = now(),
+    updated_at = now()
+WHERE id = $1;
+
+-- name: RequeueStaleOCRJobs :execrows
+UPDATE ocr_jobs
+SET status = 'queued',
+    updated_at = now()
+WHERE status = 'running' AND updated_at < $1;
@@ -0,0 +1,42 @@
+-- name: SearchDocuments :many
+-- Matches are ranked against the whole-document text, the latest one saved
+-- for the document. Texts are matched first, through
+-- extracted_texts_content_search_idx, and those a later text of their
+-- document replaced are dropped after. The snippet is built from
+-- HTML-escaped text so the <mark> tags are the only markup in it.
+WITH search AS (
+  SELECT websearch_to_tsquery('simple', sqlc.arg(query)::text) AS query
+)
+SELECT
+  d.id,
+  d.filename,
+  d.file_type,
+  d.uploaded_at,
+  ts_rank(to_tsvector('simple', coalesce(e.content, '')), search.query)::real AS rank,
+  ts_headline(
+    'simple',
+    replace(replace(replace(coalesce(e.content, ''), '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+    search.query,
+    'StartSel=<mark>, StopSel=</mark>, MaxFragments=3, MaxWords=20, MinWords=5'
+  )::text AS snippet,
+  coalesce((
+    SELECT array_agg(p.page_number ORDER BY p.page_number)
+    FROM document_pages p
+    WHERE p.document_id = d.id AND to_tsvector('simple', p.text) @@ search.query
+  ), '{}')::int[] AS pages
+FROM extracted_texts e
+CROSS JOIN search
+JOIN documents d ON d.id = e.document_id
+WHERE to_tsvector('simple', coalesce(e.content, '')) @@ search.query
+  AND NOT EXISTS (
+    SELECT 1 FROM extracted_texts later
+    WHERE later.document_id = e.document_id
+      AND (later.created_at, later.id) > (e.created_at, e.id)
+  )
+  AND d.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(folder_id)::varchar IS NULL OR d.folder_id = sqlc.narg(folder_id))
+  AND (sqlc.narg(tags)::varchar[] IS NULL OR sqlc.narg(tags)::varchar[] <@ ARRAY(
+    SELECT tag FROM document_tags WHERE document_id = d.id
+  )::varchar[])
+ORDER BY rank DESC, d.uploaded_at DESC
+LIMIT sqlc.arg(limit_count) OFFSET sqlc.arg(offset_count);
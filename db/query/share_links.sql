@@ -0,0 +1,49 @@
+-- name: CreateShareLink :one
+INSERT INTO share_links (id, document_id, created_by, content, password_hash, max_downloads, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetShareLink :one
+SELECT * FROM share_links
+WHERE id = $1;
+
+-- name: ListShareLinks :many
+SELECT * FROM share_links
+WHERE document_id = $1
+ORDER BY created_at DESC, id;
+
+-- name: RevokeShareLink :one
+UPDATE share_links
+SET revoked_at = coalesce(revoked_at, now())
+WHERE id = $1 AND document_id = $2
+RETURNING *;
+
+-- name: UseShareLink :one
+-- Counts a download, unless the link is revoked or its downloads are used up.
+UPDATE share_links
+SET downloads = downloads + 1
+WHERE id = $1
+  AND revoked_at IS NULL
+  AND (max_downloads IS NULL OR downloads < max_downloads)
+RETURNING *;
+
+-- name: FailShareLinkPassword :one
+-- Counts a wrong password. The max_failures-th in a row locks the link for
+-- lockout_seconds and starts the count over.
+UPDATE share_links
+SET password_failures = CASE
+      WHEN password_failures + 1 >= sqlc.arg(max_failures)::int THEN 0
+      ELSE password_failures + 1
+    END,
+    locked_until = CASE
+      WHEN password_failures + 1 >= sqlc.arg(max_failures)::int
+        THEN now() + make_interval(secs => sqlc.arg(lockout_seconds)::float8)
+      ELSE locked_until
+    END
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ResetShareLinkPasswordFailures :exec
+UPDATE share_links
+SET password_failures = 0
+WHERE id = $1 AND password_failures > 0;
@@ -0,0 +1,89 @@
+-- name: UpsertDocumentShare :one
+INSERT INTO document_shares (document_id, username, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (document_id, username) DO UPDATE
+SET role = EXCLUDED.role
+RETURNING *;
+
+-- name: ListDocumentShares :many
+SELECT * FROM document_shares
+WHERE document_id = $1
+ORDER BY username;
+
+-- name: DeleteDocumentShare :execrows
+DELETE FROM document_shares
+WHERE document_id = $1 AND username = $2;
+
+-- name: UpsertFolderShare :one
+INSERT INTO folder_shares (folder_id, username, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (folder_id, username) DO UPDATE
+SET role = EXCLUDED.role
+RETURNING *;
+
+-- name: ListFolderShares :many
+SELECT * FROM folder_shares
+WHERE folder_id = $1
+ORDER BY username;
+
+-- name: DeleteFolderShare :execrows
+DELETE FROM folder_shares
+WHERE folder_id = $1 AND username = $2;
+
+-- name: ListDocumentGrants :many
+-- Roles given to username on a document, directly or through folder_id, the
+-- folder the document is in, or one of its ancestors.
+WITH RECURSIVE ancestors AS (
+  SELECT folders.id, folders.parent_id FROM folders
+  WHERE folders.id = sqlc.narg(folder_id)::varchar
+  UNION
+  SELECT f.id, f.parent_id FROM folders f
+  JOIN ancestors a ON f.id = a.parent_id
+)
+SELECT document_shares.role FROM document_shares
+WHERE document_shares.document_id = sqlc.arg(document_id)::varchar
+  AND document_shares.username = sqlc.arg(username)::varchar
+UNION ALL
+SELECT folder_shares.role FROM folder_shares
+JOIN ancestors ON ancestors.id = folder_shares.folder_id
+WHERE folder_shares.username = sqlc.arg(username)::varchar;
+
+-- name: ListFolderGrants :many
+-- Roles given to username on a folder, directly or through one of its
+-- ancestors.
+WITH RECURSIVE ancestors AS (
+  SELECT folders.id, folders.parent_id FROM folders
+  WHERE folders.id = sqlc.arg(folder_id)::varchar
+  UNION
+  SELECT f.id, f.parent_id FROM folders f
+  JOIN ancestors a ON f.id = a.parent_id
+)
+SELECT folder_shares.role FROM folder_shares
+JOIN ancestors ON ancestors.id = folder_shares.folder_id
+WHERE folder_shares.username = sqlc.arg(username)::varchar;
+
+-- name: ListInheritedFolderShares :many
+-- Shares of a folder and of its ancestors, which everything in the folder
+-- inherits.
+WITH RECURSIVE ancestors AS (
+  SELECT folders.id, folders.parent_id FROM folders
+  WHERE folders.id = sqlc.arg(folder_id)::varchar
+  UNION
+  SELECT f.id, f.parent_id FROM folders f
+  JOIN ancestors a ON f.id = a.parent_id
+)
+SELECT folder_shares.username, folder_shares.role FROM folder_shares
+JOIN ancestors ON ancestors.id = folder_shares.folder_id
+ORDER BY folder_shares.username;
+
+-- name: ListDocumentsSharedWithUser :many
+SELECT sqlc.embed(documents), document_shares.role FROM documents
+JOIN document_shares ON document_shares.document_id = documents.id
+WHERE document_shares.username = $1
+ORDER BY document_shares.created_at DESC, documents.id;
+
+-- name: ListFoldersSharedWithUser :many
+SELECT sqlc.embed(folders), folder_shares.role FROM folders
+JOIN folder_shares ON folder_shares.folder_id = folders.id
+WHERE folder_shares.username = $1
+ORDER BY folder_shares.created_at DESC, folders.id;
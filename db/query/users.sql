@@ -20,6 +20,12 @@ UPDATE users
 SET password_hash = $2
 WHERE username = $1;
 
+-- name: UpdateUserOCRLanguages :one
+UPDATE users
+SET ocr_languages = $2
+WHERE username = $1
+RETURNING *;
+
 -- name: DeleteUser :exec
 DELETE FROM users
 WHERE username = $1;
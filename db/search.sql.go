@@ -0,0 +1,110 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: search.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const searchDocuments = `-- name: SearchDocuments :many
+WITH search AS (
+  SELECT websearch_to_tsquery('simple', $6::text) AS query
+)
+SELECT
+  d.id,
+  d.filename,
+  d.file_type,
+  d.uploaded_at,
+  ts_rank(to_tsvector('simple', coalesce(e.content, '')), search.query)::real AS rank,
+  ts_headline(
+    'simple',
+    replace(replace(replace(coalesce(e.content, ''), '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+    search.query,
+    'StartSel=<mark>, StopSel=</mark>, MaxFragments=3, MaxWords=20, MinWords=5'
+  )::text AS snippet,
+  coalesce((
+    SELECT array_agg(p.page_number ORDER BY p.page_number)
+    FROM document_pages p
+    WHERE p.document_id = d.id AND to_tsvector('simple', p.text) @@ search.query
+  ), '{}')::int[] AS pages
+FROM extracted_texts e
+CROSS JOIN search
+JOIN documents d ON d.id = e.document_id
+WHERE to_tsvector('simple', coalesce(e.content, '')) @@ search.query
+  AND NOT EXISTS (
+    SELECT 1 FROM extracted_texts later
+    WHERE later.document_id = e.document_id
+      AND (later.created_at, later.id) > (e.created_at, e.id)
+  )
+  AND d.user_id = $1
+  AND ($2::varchar IS NULL OR d.folder_id = $2)
+  AND ($3::varchar[] IS NULL OR $3::varchar[] <@ ARRAY(
+    SELECT tag FROM document_tags WHERE document_id = d.id
+  )::varchar[])
+ORDER BY rank DESC, d.uploaded_at DESC
+LIMIT $5 OFFSET $4
+`
+
+type SearchDocumentsParams struct {
+	UserID      string      `json:"user_id"`
+	FolderID    pgtype.Text `json:"folder_id"`
+	Tags        []string    `json:"tags"`
+	OffsetCount int32       `json:"offset_count"`
+	LimitCount  int32       `json:"limit_count"`
+	Query       string      `json:"query"`
+}
+
+type SearchDocumentsRow struct {
+	ID         string           `json:"id"`
+	Filename   pgtype.Text      `json:"filename"`
+	FileType   pgtype.Text      `json:"file_type"`
+	UploadedAt pgtype.Timestamp `json:"uploaded_at"`
+	Rank       float32          `json:"rank"`
+	Snippet    string           `json:"snippet"`
+	Pages      []int32          `json:"pages"`
+}
+
+// Matches are ranked against the whole-document text, the latest one saved
+// for the document. Texts are matched first, through
+// extracted_texts_content_search_idx, and those a later text of their
+// document replaced are dropped after. The snippet is built from
+// HTML-escaped text so the <mark> tags are the only markup in it.
+func (q *Queries) SearchDocuments(ctx context.Context, arg SearchDocumentsParams) ([]SearchDocumentsRow, error) {
+	rows, err := q.db.Query(ctx, searchDocuments,
+		arg.UserID,
+		arg.FolderID,
+		arg.Tags,
+		arg.OffsetCount,
+		arg.LimitCount,
+		arg.Query,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchDocumentsRow
+	for rows.Next() {
+		var i SearchDocumentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Filename,
+			&i.FileType,
+			&i.UploadedAt,
+			&i.Rank,
+			&i.Snippet,
+			&i.Pages,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/yosa/ocr-golang-back/util"
+)
+
+func createRandomExtractedText(t *testing.T, document Document, content string) ExtractedText {
+	arg := CreateExtractedTextParams{
+		ID:         uuid.New().String(),
+		DocumentID: document.ID,
+		Content:    pgtype.Text{String: content, Valid: true},
+	}
+
+	extracted, err := testQueries.CreateExtractedText(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Content, extracted.Content)
+	return extracted
+}
+
+func TestSearchDocuments(t *testing.T) {
+	word := util.RandomString(12)
+	content := fmt.Sprintf("Invoice <draft> for %s services", word)
+
+	document1 := createRandomDocument(t)
+	createRandomExtractedText(t, document1, content)
+	_, err := testQueries.UpsertDocumentPage(context.Background(), UpsertDocumentPageParams{
+		DocumentID: document1.ID,
+		PageNumber: 2,
+		Text:       content,
+		Width:      100,
+		Height:     100,
+		Method:     "text_layer",
+		Status:     "succeeded",
+	})
+	require.NoError(t, err)
+
+	// Same text, other user: must never show up in document1's owner results.
+	document2 := createRandomDocument(t)
+	createRandomExtractedText(t, document2, content)
+
+	results, err := testQueries.SearchDocuments(context.Background(), SearchDocumentsParams{
+		UserID:      document1.UserID,
+		Query:       word,
+		LimitCount:  10,
+		OffsetCount: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, document1.ID, results[0].ID)
+	require.Equal(t, []int32{2}, results[0].Pages)
+	require.Contains(t, results[0].Snippet, "<mark>"+word+"</mark>")
+	require.Contains(t, results[0].Snippet, "&lt;draft&gt;")
+	require.Positive(t, results[0].Rank)
+}
+
+// TestSearchDocumentsAfterRetry searches a document whose text was saved
+// again, as a page retry does: only the latest text counts.
+func TestSearchDocumentsAfterRetry(t *testing.T) {
+	oldWord := util.RandomString(12)
+	newWord := util.RandomString(12)
+
+	document := createRandomDocument(t)
+	createRandomExtractedText(t, document, fmt.Sprintf("Page one. Page two %s failed", oldWord))
+	createRandomExtractedText(t, document, fmt.Sprintf("Page one. Page two %s retried", newWord))
+
+	search := func(query string) []SearchDocumentsRow {
+		results, err := testQueries.SearchDocuments(context.Background(), SearchDocumentsParams{
+			UserID:      document.UserID,
+			Query:       query,
+			LimitCount:  10,
+			OffsetCount: 0,
+		})
+		require.NoError(t, err)
+		return results
+	}
+
+	results := search(newWord)
+	require.Len(t, results, 1)
+	require.Equal(t, document.ID, results[0].ID)
+	require.Contains(t, results[0].Snippet, "<mark>"+newWord+"</mark>")
+
+	require.Empty(t, search(oldWord))
+	require.Len(t, search("page"), 1)
+}
@@ -0,0 +1,236 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: share_links.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createShareLink = `-- name: CreateShareLink :one
+INSERT INTO share_links (id, document_id, created_by, content, password_hash, max_downloads, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, document_id, created_by, content, password_hash, max_downloads, downloads, expires_at, revoked_at, created_at, password_failures, locked_until
+`
+
+type CreateShareLinkParams struct {
+	ID           pgtype.UUID      `json:"id"`
+	DocumentID   string           `json:"document_id"`
+	CreatedBy    string           `json:"created_by"`
+	Content      string           `json:"content"`
+	PasswordHash pgtype.Text      `json:"password_hash"`
+	MaxDownloads pgtype.Int4      `json:"max_downloads"`
+	ExpiresAt    pgtype.Timestamp `json:"expires_at"`
+}
+
+func (q *Queries) CreateShareLink(ctx context.Context, arg CreateShareLinkParams) (ShareLink, error) {
+	row := q.db.QueryRow(ctx, createShareLink,
+		arg.ID,
+		arg.DocumentID,
+		arg.CreatedBy,
+		arg.Content,
+		arg.PasswordHash,
+		arg.MaxDownloads,
+		arg.ExpiresAt,
+	)
+	var i ShareLink
+	err := row.Scan(
+		&i.ID,
+		&i.DocumentID,
+		&i.CreatedBy,
+		&i.Content,
+		&i.PasswordHash,
+		&i.MaxDownloads,
+		&i.Downloads,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.PasswordFailures,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const failShareLinkPassword = `-- name: FailShareLinkPassword :one
+UPDATE share_links
+SET password_failures = CASE
+      WHEN password_failures + 1 >= $1::int THEN 0
+      ELSE password_failures + 1
+    END,
+    locked_until = CASE
+      WHEN password_failures + 1 >= $1::int
+        THEN now() + make_interval(secs => $2::float8)
+      ELSE locked_until
+    END
+WHERE id = $3
+RETURNING id, document_id, created_by, content, password_hash, max_downloads, downloads, expires_at, revoked_at, created_at, password_failures, locked_until
+`
+
+type FailShareLinkPasswordParams struct {
+	MaxFailures    int32       `json:"max_failures"`
+	LockoutSeconds float64     `json:"lockout_seconds"`
+	ID             pgtype.UUID `json:"id"`
+}
+
+// Counts a wrong password. The max_failures-th in a row locks the link for
+// lockout_seconds and starts the count over.
+func (q *Queries) FailShareLinkPassword(ctx context.Context, arg FailShareLinkPasswordParams) (ShareLink, error) {
+	row := q.db.QueryRow(ctx, failShareLinkPassword, arg.MaxFailures, arg.LockoutSeconds, arg.ID)
+	var i ShareLink
+	err := row.Scan(
+		&i.ID,
+		&i.DocumentID,
+		&i.CreatedBy,
+		&i.Content,
+		&i.PasswordHash,
+		&i.MaxDownloads,
+		&i.Downloads,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.PasswordFailures,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const getShareLink = `-- name: GetShareLink :one
+SELECT id, document_id, created_by, content, password_hash, max_downloads, downloads, expires_at, revoked_at, created_at, password_failures, locked_until FROM share_links
+WHERE id = $1
+`
+
+func (q *Queries) GetShareLink(ctx context.Context, id pgtype.UUID) (ShareLink, error) {
+	row := q.db.QueryRow(ctx, getShareLink, id)
+	var i ShareLink
+	err := row.Scan(
+		&i.ID,
+		&i.DocumentID,
+		&i.CreatedBy,
+		&i.Content,
+		&i.PasswordHash,
+		&i.MaxDownloads,
+		&i.Downloads,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.PasswordFailures,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const listShareLinks = `-- name: ListShareLinks :many
+SELECT id, document_id, created_by, content, password_hash, max_downloads, downloads, expires_at, revoked_at, created_at, password_failures, locked_until FROM share_links
+WHERE document_id = $1
+ORDER BY created_at DESC, id
+`
+
+func (q *Queries) ListShareLinks(ctx context.Context, documentID string) ([]ShareLink, error) {
+	rows, err := q.db.Query(ctx, listShareLinks, documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ShareLink
+	for rows.Next() {
+		var i ShareLink
+		if err := rows.Scan(
+			&i.ID,
+			&i.DocumentID,
+			&i.CreatedBy,
+			&i.Content,
+			&i.PasswordHash,
+			&i.MaxDownloads,
+			&i.Downloads,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+			&i.PasswordFailures,
+			&i.LockedUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const resetShareLinkPasswordFailures = `-- name: ResetShareLinkPasswordFailures :exec
+UPDATE share_links
+SET password_failures = 0
+WHERE id = $1 AND password_failures > 0
+`
+
+func (q *Queries) ResetShareLinkPasswordFailures(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, resetShareLinkPasswordFailures, id)
+	return err
+}
+
+const revokeShareLink = `-- name: RevokeShareLink :one
+UPDATE share_links
+SET revoked_at = coalesce(revoked_at, now())
+WHERE id = $1 AND document_id = $2
+RETURNING id, document_id, created_by, content, password_hash, max_downloads, downloads, expires_at, revoked_at, created_at, password_failures, locked_until
+`
+
+type RevokeShareLinkParams struct {
+	ID         pgtype.UUID `json:"id"`
+	DocumentID string      `json:"document_id"`
+}
+
+func (q *Queries) RevokeShareLink(ctx context.Context, arg RevokeShareLinkParams) (ShareLink, error) {
+	row := q.db.QueryRow(ctx, revokeShareLink, arg.ID, arg.DocumentID)
+	var i ShareLink
+	err := row.Scan(
+		&i.ID,
+		&i.DocumentID,
+		&i.CreatedBy,
+		&i.Content,
+		&i.PasswordHash,
+		&i.MaxDownloads,
+		&i.Downloads,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.PasswordFailures,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const useShareLink = `-- name: UseShareLink :one
+UPDATE share_links
+SET downloads = downloads + 1
+WHERE id = $1
+  AND revoked_at IS NULL
+  AND (max_downloads IS NULL OR downloads < max_downloads)
+RETURNING id, document_id, created_by, content, password_hash, max_downloads, downloads, expires_at, revoked_at, created_at, password_failures, locked_until
+`
+
+// Counts a download, unless the link is revoked or its downloads are used up.
+func (q *Queries) UseShareLink(ctx context.Context, id pgtype.UUID) (ShareLink, error) {
+	row := q.db.QueryRow(ctx, useShareLink, id)
+	var i ShareLink
+	err := row.Scan(
+		&i.ID,
+		&i.DocumentID,
+		&i.CreatedBy,
+		&i.Content,
+		&i.PasswordHash,
+		&i.MaxDownloads,
+		&i.Downloads,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.PasswordFailures,
+		&i.LockedUntil,
+	)
+	return i, err
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareLinks(t *testing.T) {
+	document := createRandomDocument(t)
+
+	arg := CreateShareLinkParams{
+		ID:           pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		DocumentID:   document.ID,
+		CreatedBy:    document.UserID,
+		Content:      "text",
+		MaxDownloads: pgtype.Int4{Int32: 2, Valid: true},
+		ExpiresAt:    pgtype.Timestamp{Time: time.Now().Add(time.Hour), Valid: true},
+	}
+	link, err := testQueries.CreateShareLink(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, link.ID)
+	require.Equal(t, arg.MaxDownloads, link.MaxDownloads)
+	require.Zero(t, link.Downloads)
+	require.False(t, link.RevokedAt.Valid)
+
+	links, err := testQueries.ListShareLinks(context.Background(), document.ID)
+	require.NoError(t, err)
+	require.Equal(t, []ShareLink{link}, links)
+
+	// Downloads stop once max_downloads are used.
+	for i := int32(1); i <= 2; i++ {
+		used, err := testQueries.UseShareLink(context.Background(), link.ID)
+		require.NoError(t, err)
+		require.Equal(t, i, used.Downloads)
+	}
+	_, err = testQueries.UseShareLink(context.Background(), link.ID)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+
+	// Revoked links stop right away, and only through their document.
+	arg.ID = pgtype.UUID{Bytes: uuid.New(), Valid: true}
+	arg.MaxDownloads = pgtype.Int4{}
+	link, err = testQueries.CreateShareLink(context.Background(), arg)
+	require.NoError(t, err)
+
+	_, err = testQueries.RevokeShareLink(context.Background(), RevokeShareLinkParams{ID: link.ID, DocumentID: createRandomDocument(t).ID})
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+	revoked, err := testQueries.RevokeShareLink(context.Background(), RevokeShareLinkParams{ID: link.ID, DocumentID: document.ID})
+	require.NoError(t, err)
+	require.True(t, revoked.RevokedAt.Valid)
+
+	_, err = testQueries.UseShareLink(context.Background(), link.ID)
+	require.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestFailShareLinkPassword(t *testing.T) {
+	document := createRandomDocument(t)
+	link, err := testQueries.CreateShareLink(context.Background(), CreateShareLinkParams{
+		ID:         pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		DocumentID: document.ID,
+		CreatedBy:  document.UserID,
+		Content:    "text",
+		ExpiresAt:  pgtype.Timestamp{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	require.NoError(t, err)
+	require.Zero(t, link.PasswordFailures)
+	require.False(t, link.LockedUntil.Valid)
+
+	arg := FailShareLinkPasswordParams{ID: link.ID, MaxFailures: 3, LockoutSeconds: 900}
+	for i := int32(1); i < 3; i++ {
+		link, err = testQueries.FailShareLinkPassword(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, i, link.PasswordFailures)
+		require.False(t, link.LockedUntil.Valid)
+	}
+
+	// The last failure allowed locks the link and starts the count over.
+	link, err = testQueries.FailShareLinkPassword(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, link.PasswordFailures)
+	require.True(t, link.LockedUntil.Valid)
+
+	_, err = testQueries.FailShareLinkPassword(context.Background(), arg)
+	require.NoError(t, err)
+	require.NoError(t, testQueries.ResetShareLinkPasswordFailures(context.Background(), link.ID))
+	link, err = testQueries.GetShareLink(context.Background(), link.ID)
+	require.NoError(t, err)
+	require.Zero(t, link.PasswordFailures)
+}
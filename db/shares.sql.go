@@ -0,0 +1,374 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: shares.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteDocumentShare = `-- name: DeleteDocumentShare :execrows
+DELETE FROM document_shares
+WHERE document_id = $1 AND username = $2
+`
+
+type DeleteDocumentShareParams struct {
+	DocumentID string `json:"document_id"`
+	Username   string `json:"username"`
+}
+
+func (q *Queries) DeleteDocumentShare(ctx context.Context, arg DeleteDocumentShareParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteDocumentShare, arg.DocumentID, arg.Username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteFolderShare = `-- name: DeleteFolderShare :execrows
+DELETE FROM folder_shares
+WHERE folder_id = $1 AND username = $2
+`
+
+type DeleteFolderShareParams struct {
+	FolderID string `json:"folder_id"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) DeleteFolderShare(ctx context.Context, arg DeleteFolderShareParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteFolderShare, arg.FolderID, arg.Username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const listDocumentGrants = `-- name: ListDocumentGrants :many
+WITH RECURSIVE ancestors AS (
+  SELECT folders.id, folders.parent_id FROM folders
+  WHERE folders.id = $1::varchar
+  UNION
+  SELECT f.id, f.parent_id FROM folders f
+  JOIN ancestors a ON f.id = a.parent_id
+)
+SELECT document_shares.role FROM document_shares
+WHERE document_shares.document_id = $2::varchar
+  AND document_shares.username = $3::varchar
+UNION ALL
+SELECT folder_shares.role FROM folder_shares
+JOIN ancestors ON ancestors.id = folder_shares.folder_id
+WHERE folder_shares.username = $3::varchar
+`
+
+type ListDocumentGrantsParams struct {
+	FolderID   pgtype.Text `json:"folder_id"`
+	DocumentID string      `json:"document_id"`
+	Username   string      `json:"username"`
+}
+
+// Roles given to username on a document, directly or through folder_id, the
+// folder the document is in, or one of its ancestors.
+func (q *Queries) ListDocumentGrants(ctx context.Context, arg ListDocumentGrantsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, listDocumentGrants, arg.FolderID, arg.DocumentID, arg.Username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		items = append(items, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDocumentShares = `-- name: ListDocumentShares :many
+SELECT document_id, username, role, created_at FROM document_shares
+WHERE document_id = $1
+ORDER BY username
+`
+
+func (q *Queries) ListDocumentShares(ctx context.Context, documentID string) ([]DocumentShare, error) {
+	rows, err := q.db.Query(ctx, listDocumentShares, documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DocumentShare
+	for rows.Next() {
+		var i DocumentShare
+		if err := rows.Scan(
+			&i.DocumentID,
+			&i.Username,
+			&i.Role,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDocumentsSharedWithUser = `-- name: ListDocumentsSharedWithUser :many
+SELECT documents.id, documents.user_id, documents.filename, documents.file_type, documents.uploaded_at, documents.languages, documents.detected_language, documents.detected_script, documents.preprocess, documents.status, documents.storage_key, documents.metadata, documents.folder_id, documents.dpi, document_shares.role FROM documents
+JOIN document_shares ON document_shares.document_id = documents.id
+WHERE document_shares.username = $1
+ORDER BY document_shares.created_at DESC, documents.id
+`
+
+type ListDocumentsSharedWithUserRow struct {
+	Document Document `json:"document"`
+	Role     string   `json:"role"`
+}
+
+func (q *Queries) ListDocumentsSharedWithUser(ctx context.Context, username string) ([]ListDocumentsSharedWithUserRow, error) {
+	rows, err := q.db.Query(ctx, listDocumentsSharedWithUser, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDocumentsSharedWithUserRow
+	for rows.Next() {
+		var i ListDocumentsSharedWithUserRow
+		if err := rows.Scan(
+			&i.Document.ID,
+			&i.Document.UserID,
+			&i.Document.Filename,
+			&i.Document.FileType,
+			&i.Document.UploadedAt,
+			&i.Document.Languages,
+			&i.Document.DetectedLanguage,
+			&i.Document.DetectedScript,
+			&i.Document.Preprocess,
+			&i.Document.Status,
+			&i.Document.StorageKey,
+			&i.Document.Metadata,
+			&i.Document.FolderID,
+			&i.Document.Dpi,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFolderGrants = `-- name: ListFolderGrants :many
+WITH RECURSIVE ancestors AS (
+  SELECT folders.id, folders.parent_id FROM folders
+  WHERE folders.id = $2::varchar
+  UNION
+  SELECT f.id, f.parent_id FROM folders f
+  JOIN ancestors a ON f.id = a.parent_id
+)
+SELECT folder_shares.role FROM folder_shares
+JOIN ancestors ON ancestors.id = folder_shares.folder_id
+WHERE folder_shares.username = $1::varchar
+`
+
+type ListFolderGrantsParams struct {
+	Username string `json:"username"`
+	FolderID string `json:"folder_id"`
+}
+
+// Roles given to username on a folder, directly or through one of its
+// ancestors.
+func (q *Queries) ListFolderGrants(ctx context.Context, arg ListFolderGrantsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, listFolderGrants, arg.Username, arg.FolderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		items = append(items, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFolderShares = `-- name: ListFolderShares :many
+SELECT folder_id, username, role, created_at FROM folder_shares
+WHERE folder_id = $1
+ORDER BY username
+`
+
+func (q *Queries) ListFolderShares(ctx context.Context, folderID string) ([]FolderShare, error) {
+	rows, err := q.db.Query(ctx, listFolderShares, folderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FolderShare
+	for rows.Next() {
+		var i FolderShare
+		if err := rows.Scan(
+			&i.FolderID,
+			&i.Username,
+			&i.Role,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFoldersSharedWithUser = `-- name: ListFoldersSharedWithUser :many
+SELECT folders.id, folders.user_id, folders.parent_id, folders.name, folders.created_at, folder_shares.role FROM folders
+JOIN folder_shares ON folder_shares.folder_id = folders.id
+WHERE folder_shares.username = $1
+ORDER BY folder_shares.created_at DESC, folders.id
+`
+
+type ListFoldersSharedWithUserRow struct {
+	Folder Folder `json:"folder"`
+	Role   string `json:"role"`
+}
+
+func (q *Queries) ListFoldersSharedWithUser(ctx context.Context, username string) ([]ListFoldersSharedWithUserRow, error) {
+	rows, err := q.db.Query(ctx, listFoldersSharedWithUser, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFoldersSharedWithUserRow
+	for rows.Next() {
+		var i ListFoldersSharedWithUserRow
+		if err := rows.Scan(
+			&i.Folder.ID,
+			&i.Folder.UserID,
+			&i.Folder.ParentID,
+			&i.Folder.Name,
+			&i.Folder.CreatedAt,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInheritedFolderShares = `-- name: ListInheritedFolderShares :many
+WITH RECURSIVE ancestors AS (
+  SELECT folders.id, folders.parent_id FROM folders
+  WHERE folders.id = $1::varchar
+  UNION
+  SELECT f.id, f.parent_id FROM folders f
+  JOIN ancestors a ON f.id = a.parent_id
+)
+SELECT folder_shares.username, folder_shares.role FROM folder_shares
+JOIN ancestors ON ancestors.id = folder_shares.folder_id
+ORDER BY folder_shares.username
+`
+
+type ListInheritedFolderSharesRow struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// Shares of a folder and of its ancestors, which everything in the folder
+// inherits.
+func (q *Queries) ListInheritedFolderShares(ctx context.Context, folderID string) ([]ListInheritedFolderSharesRow, error) {
+	rows, err := q.db.Query(ctx, listInheritedFolderShares, folderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListInheritedFolderSharesRow
+	for rows.Next() {
+		var i ListInheritedFolderSharesRow
+		if err := rows.Scan(&i.Username, &i.Role); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertDocumentShare = `-- name: UpsertDocumentShare :one
+INSERT INTO document_shares (document_id, username, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (document_id, username) DO UPDATE
+SET role = EXCLUDED.role
+RETURNING document_id, username, role, created_at
+`
+
+type UpsertDocumentShareParams struct {
+	DocumentID string `json:"document_id"`
+	Username   string `json:"username"`
+	Role       string `json:"role"`
+}
+
+func (q *Queries) UpsertDocumentShare(ctx context.Context, arg UpsertDocumentShareParams) (DocumentShare, error) {
+	row := q.db.QueryRow(ctx, upsertDocumentShare, arg.DocumentID, arg.Username, arg.Role)
+	var i DocumentShare
+	err := row.Scan(
+		&i.DocumentID,
+		&i.Username,
+		&i.Role,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertFolderShare = `-- name: UpsertFolderShare :one
+INSERT INTO folder_shares (folder_id, username, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (folder_id, username) DO UPDATE
+SET role = EXCLUDED.role
+RETURNING folder_id, username, role, created_at
+`
+
+type UpsertFolderShareParams struct {
+	FolderID string `json:"folder_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+func (q *Queries) UpsertFolderShare(ctx context.Context, arg UpsertFolderShareParams) (FolderShare, error) {
+	row := q.db.QueryRow(ctx, upsertFolderShare, arg.FolderID, arg.Username, arg.Role)
+	var i FolderShare
+	err := row.Scan(
+		&i.FolderID,
+		&i.Username,
+		&i.Role,
+		&i.CreatedAt,
+	)
+	return i, err
+}
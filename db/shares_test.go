@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentShares(t *testing.T) {
+	document := createRandomDocument(t)
+	user := createRandomUser(t)
+
+	share, err := testQueries.UpsertDocumentShare(context.Background(), UpsertDocumentShareParams{
+		DocumentID: document.ID,
+		Username:   user.Username,
+		Role:       "viewer",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "viewer", share.Role)
+
+	// Sharing again changes the role.
+	share, err = testQueries.UpsertDocumentShare(context.Background(), UpsertDocumentShareParams{
+		DocumentID: document.ID,
+		Username:   user.Username,
+		Role:       "editor",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "editor", share.Role)
+
+	_, err = testQueries.UpsertDocumentShare(context.Background(), UpsertDocumentShareParams{
+		DocumentID: document.ID,
+		Username:   user.Username,
+		Role:       "owner",
+	})
+	require.Error(t, err)
+
+	shares, err := testQueries.ListDocumentShares(context.Background(), document.ID)
+	require.NoError(t, err)
+	require.Equal(t, []DocumentShare{share}, shares)
+
+	shared, err := testQueries.ListDocumentsSharedWithUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, shared, 1)
+	require.Equal(t, document, shared[0].Document)
+	require.Equal(t, "editor", shared[0].Role)
+
+	deleted, err := testQueries.DeleteDocumentShare(context.Background(), DeleteDocumentShareParams{
+		DocumentID: document.ID,
+		Username:   user.Username,
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, deleted)
+
+	grants, err := testQueries.ListDocumentGrants(context.Background(), ListDocumentGrantsParams{
+		DocumentID: document.ID,
+		Username:   user.Username,
+	})
+	require.NoError(t, err)
+	require.Empty(t, grants)
+}
+
+func TestFolderGrants(t *testing.T) {
+	owner := createRandomUser(t)
+	user := createRandomUser(t)
+	root := createRandomFolder(t, owner.Username, nil)
+	child := createRandomFolder(t, owner.Username, &root)
+
+	_, err := testQueries.UpsertFolderShare(context.Background(), UpsertFolderShareParams{
+		FolderID: root.ID,
+		Username: user.Username,
+		Role:     "viewer",
+	})
+	require.NoError(t, err)
+	_, err = testQueries.UpsertFolderShare(context.Background(), UpsertFolderShareParams{
+		FolderID: child.ID,
+		Username: user.Username,
+		Role:     "editor",
+	})
+	require.NoError(t, err)
+
+	// Shares of the folder and of its ancestors count.
+	grants, err := testQueries.ListFolderGrants(context.Background(), ListFolderGrantsParams{
+		FolderID: child.ID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"viewer", "editor"}, grants)
+
+	grants, err = testQueries.ListFolderGrants(context.Background(), ListFolderGrantsParams{
+		FolderID: root.ID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"viewer"}, grants)
+
+	document, err := testQueries.SetDocumentFolder(context.Background(), SetDocumentFolderParams{
+		ID:       createRandomDocument(t).ID,
+		FolderID: pgtype.Text{String: child.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	grants, err = testQueries.ListDocumentGrants(context.Background(), ListDocumentGrantsParams{
+		FolderID:   document.FolderID,
+		DocumentID: document.ID,
+		Username:   user.Username,
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"viewer", "editor"}, grants)
+
+	shared, err := testQueries.ListFoldersSharedWithUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, shared, 2)
+
+	deleted, err := testQueries.DeleteFolderShare(context.Background(), DeleteFolderShareParams{
+		FolderID: root.ID,
+		Username: owner.Username,
+	})
+	require.NoError(t, err)
+	require.Zero(t, deleted)
+}
@@ -14,7 +14,7 @@ import (
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (username, email, password_hash, provider)
 VALUES ($1, $2, $3, $4)
-RETURNING username, email, password_hash, provider, created_at
+RETURNING username, email, password_hash, provider, created_at, ocr_languages
 `
 
 type CreateUserParams struct {
@@ -38,6 +38,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.PasswordHash,
 		&i.Provider,
 		&i.CreatedAt,
+		&i.OcrLanguages,
 	)
 	return i, err
 }
@@ -53,7 +54,7 @@ func (q *Queries) DeleteUser(ctx context.Context, username string) error {
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT username, email, password_hash, provider, created_at FROM users
+SELECT username, email, password_hash, provider, created_at, ocr_languages FROM users
 WHERE email = $1
 `
 
@@ -66,12 +67,13 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.PasswordHash,
 		&i.Provider,
 		&i.CreatedAt,
+		&i.OcrLanguages,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT username, email, password_hash, provider, created_at FROM users
+SELECT username, email, password_hash, provider, created_at, ocr_languages FROM users
 WHERE username = $1
 `
 
@@ -84,12 +86,13 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.PasswordHash,
 		&i.Provider,
 		&i.CreatedAt,
+		&i.OcrLanguages,
 	)
 	return i, err
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT username, email, password_hash, provider, created_at FROM users
+SELECT username, email, password_hash, provider, created_at, ocr_languages FROM users
 ORDER BY created_at DESC LIMIT $1 OFFSET $2
 `
 
@@ -113,6 +116,7 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.PasswordHash,
 			&i.Provider,
 			&i.CreatedAt,
+			&i.OcrLanguages,
 		); err != nil {
 			return nil, err
 		}
@@ -124,6 +128,32 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 	return items, nil
 }
 
+const updateUserOCRLanguages = `-- name: UpdateUserOCRLanguages :one
+UPDATE users
+SET ocr_languages = $2
+WHERE username = $1
+RETURNING username, email, password_hash, provider, created_at, ocr_languages
+`
+
+type UpdateUserOCRLanguagesParams struct {
+	Username     string   `json:"username"`
+	OcrLanguages []string `json:"ocr_languages"`
+}
+
+func (q *Queries) UpdateUserOCRLanguages(ctx context.Context, arg UpdateUserOCRLanguagesParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserOCRLanguages, arg.Username, arg.OcrLanguages)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Provider,
+		&i.CreatedAt,
+		&i.OcrLanguages,
+	)
+	return i, err
+}
+
 const updateUserPassword = `-- name: UpdateUserPassword :exec
 UPDATE users
 SET password_hash = $2
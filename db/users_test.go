@@ -28,6 +28,7 @@ func createRandomUser(t *testing.T) User {
 	require.Equal(t, arg.Email, user.Email)
 	require.Equal(t, arg.PasswordHash, user.PasswordHash)
 	require.Equal(t, arg.Provider, user.Provider)
+	require.Equal(t, []string{"eng"}, user.OcrLanguages)
 
 	require.NotZero(t, user.CreatedAt)
 	return user
@@ -140,3 +141,17 @@ func TestUpdateUserPassword(t *testing.T) {
 	require.WithinDuration(t, user1.CreatedAt.Time, user2.CreatedAt.Time, time.Second)
 
 }
+
+func TestUpdateUserOCRLanguages(t *testing.T) {
+	user1 := createRandomUser(t)
+
+	arg := UpdateUserOCRLanguagesParams{
+		Username:     user1.Username,
+		OcrLanguages: []string{"fra", "ara"},
+	}
+
+	user2, err := testQueries.UpdateUserOCRLanguages(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, user1.Username, user2.Username)
+	require.Equal(t, arg.OcrLanguages, user2.OcrLanguages)
+}
@@ -0,0 +1,139 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// ALTO 4.2, see https://www.loc.gov/standards/alto/
+
+type altoDocument struct {
+	XMLName        xml.Name        `xml:"alto"`
+	Xmlns          string          `xml:"xmlns,attr"`
+	XmlnsXsi       string          `xml:"xmlns:xsi,attr"`
+	SchemaLocation string          `xml:"xsi:schemaLocation,attr"`
+	Description    altoDescription `xml:"Description"`
+	Pages          []altoPage      `xml:"Layout>Page"`
+}
+
+type altoDescription struct {
+	MeasurementUnit string `xml:"MeasurementUnit"`
+	FileName        string `xml:"sourceImageInformation>fileName"`
+	Processing      struct {
+		ID       string `xml:"ID,attr"`
+		Software string `xml:"ocrProcessingStep>processingSoftware>softwareName"`
+	} `xml:"OCRProcessing"`
+}
+
+type altoBox struct {
+	ID     string `xml:"ID,attr,omitempty"`
+	HPos   int    `xml:"HPOS,attr"`
+	VPos   int    `xml:"VPOS,attr"`
+	Width  int    `xml:"WIDTH,attr"`
+	Height int    `xml:"HEIGHT,attr"`
+}
+
+type altoPage struct {
+	ID            string `xml:"ID,attr"`
+	PhysicalImgNr int    `xml:"PHYSICAL_IMG_NR,attr"`
+	Width         int    `xml:"WIDTH,attr"`
+	Height        int    `xml:"HEIGHT,attr"`
+	PrintSpace    struct {
+		altoBox
+		Blocks []altoTextBlock `xml:"TextBlock"`
+	} `xml:"PrintSpace"`
+}
+
+type altoTextBlock struct {
+	altoBox
+	Lines []altoTextLine `xml:"TextLine"`
+}
+
+type altoTextLine struct {
+	altoBox
+	Content []any
+}
+
+type altoString struct {
+	XMLName xml.Name `xml:"String"`
+	altoBox
+	Content    string `xml:"CONTENT,attr"`
+	Confidence string `xml:"WC,attr"`
+}
+
+type altoSpace struct {
+	XMLName xml.Name `xml:"SP"`
+}
+
+// ALTO writes the document as an ALTO 4 file in pixel units. Each page holds a
+// single text block with one TextLine per OCR line.
+func ALTO(w io.Writer, doc Document) error {
+	alto := altoDocument{
+		Xmlns:          "http://www.loc.gov/standards/alto/ns-v4#",
+		XmlnsXsi:       "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation: "http://www.loc.gov/standards/alto/ns-v4# http://www.loc.gov/alto/v4/alto-4-2.xsd",
+	}
+	alto.Description.MeasurementUnit = "pixel"
+	alto.Description.FileName = doc.Filename
+	alto.Description.Processing.ID = "OCR_0"
+	alto.Description.Processing.Software = "ocr-golang-back"
+
+	for _, page := range doc.Pages {
+		altoPage := altoPage{
+			ID:            fmt.Sprintf("page_%d", page.Number),
+			PhysicalImgNr: page.Number,
+			Width:         page.Width,
+			Height:        page.Height,
+		}
+		altoPage.PrintSpace.altoBox = altoBox{Width: page.Width, Height: page.Height}
+
+		lines := page.lines()
+		if len(lines) > 0 {
+			block := altoTextBlock{altoBox: altoBox{ID: fmt.Sprintf("block_%d", page.Number)}}
+			box := lines[0].box
+			for l, line := range lines {
+				box = box.Union(line.box)
+				textLine := altoTextLine{altoBox: altoBox{
+					ID:     fmt.Sprintf("line_%d_%d", page.Number, l+1),
+					HPos:   line.box.Min.X,
+					VPos:   line.box.Min.Y,
+					Width:  line.box.Dx(),
+					Height: line.box.Dy(),
+				}}
+				for i, word := range line.words {
+					if i > 0 {
+						textLine.Content = append(textLine.Content, altoSpace{})
+					}
+					textLine.Content = append(textLine.Content, altoString{
+						altoBox: altoBox{
+							ID:     fmt.Sprintf("string_%d_%d_%d", page.Number, l+1, i+1),
+							HPos:   word.X,
+							VPos:   word.Y,
+							Width:  word.Width,
+							Height: word.Height,
+						},
+						Content:    word.Text,
+						Confidence: fmt.Sprintf("%.2f", word.Confidence/100),
+					})
+				}
+				block.Lines = append(block.Lines, textLine)
+			}
+			block.HPos, block.VPos = box.Min.X, box.Min.Y
+			block.Width, block.Height = box.Dx(), box.Dy()
+			altoPage.PrintSpace.Blocks = append(altoPage.PrintSpace.Blocks, block)
+		}
+		alto.Pages = append(alto.Pages, altoPage)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", " ")
+	if err := encoder.Encode(alto); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,70 @@
+// Package export renders stored OCR results in the formats downstream
+// archive systems ingest.
+package export
+
+import (
+	"encoding/json"
+	"image"
+	"io"
+
+	"github.com/yosa/ocr-golang-back/ocr"
+)
+
+// Document is the OCR output of a whole document, as stored per page.
+type Document struct {
+	ID       string `json:"id"`
+	Filename string `json:"filename"`
+	// Languages are the Tesseract codes the document was recognized in.
+	Languages []string `json:"languages"`
+	// Text is the whole-document text, pages separated by a blank line.
+	Text  string `json:"text"`
+	Pages []Page `json:"pages"`
+}
+
+// Page is one page of a Document. Word boxes are in pixels of the page image.
+type Page struct {
+	Number     int        `json:"page_number"`
+	Width      int        `json:"width"`
+	Height     int        `json:"height"`
+	Text       string     `json:"text"`
+	Confidence float64    `json:"confidence"`
+	Words      []ocr.Word `json:"words"`
+}
+
+// line is a run of words sharing the same line number, in reading order.
+type line struct {
+	box   image.Rectangle
+	words []ocr.Word
+}
+
+// lines groups the words of a page into lines. Words are expected in reading
+// order, which is how engines return and the database stores them.
+func (p Page) lines() []line {
+	var lines []line
+	for i, word := range p.Words {
+		if i == 0 || word.Line != p.Words[i-1].Line {
+			lines = append(lines, line{box: wordBox(word)})
+		}
+		current := &lines[len(lines)-1]
+		current.box = current.box.Union(wordBox(word))
+		current.words = append(current.words, word)
+	}
+	return lines
+}
+
+func wordBox(word ocr.Word) image.Rectangle {
+	return image.Rect(word.X, word.Y, word.X+word.Width, word.Y+word.Height)
+}
+
+// Text writes the plain whole-document text.
+func Text(w io.Writer, doc Document) error {
+	_, err := io.WriteString(w, doc.Text)
+	return err
+}
+
+// JSON writes the document with its pages and word boxes.
+func JSON(w io.Writer, doc Document) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
@@ -0,0 +1,139 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yosa/ocr-golang-back/ocr"
+)
+
+func testDocument() Document {
+	return Document{
+		ID:        "doc-1",
+		Filename:  "scan <1>.pdf",
+		Languages: []string{"deu", "eng"},
+		Text:      "Hello & world\nsecond line\n\nPage two",
+		Pages: []Page{
+			{
+				Number:     1,
+				Width:      1000,
+				Height:     1400,
+				Text:       "Hello & world\nsecond line",
+				Confidence: 90,
+				Words: []ocr.Word{
+					{Text: "Hello", Line: 0, X: 100, Y: 100, Width: 120, Height: 30, Confidence: 95},
+					{Text: "&", Line: 0, X: 230, Y: 102, Width: 20, Height: 28, Confidence: 80},
+					{Text: "world", Line: 0, X: 260, Y: 98, Width: 130, Height: 34, Confidence: 91},
+					{Text: "second", Line: 1, X: 100, Y: 150, Width: 150, Height: 30, Confidence: 93},
+					{Text: "line", Line: 1, X: 260, Y: 150, Width: 90, Height: 30, Confidence: 91},
+				},
+			},
+			{
+				Number:     2,
+				Width:      1000,
+				Height:     1400,
+				Text:       "Page two",
+				Confidence: 0,
+			},
+		},
+	}
+}
+
+// requireWellFormed fails unless data parses as XML from start to end.
+func requireWellFormed(t *testing.T, data []byte) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestHOCR(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, HOCR(&buf, testDocument()))
+	out := buf.String()
+
+	requireWellFormed(t, buf.Bytes())
+	require.Contains(t, out, `<!DOCTYPE html`)
+	require.Contains(t, out, `<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="de">`)
+	require.Contains(t, out, `<meta name="ocr-langs" content="de en">`)
+	require.Contains(t, out, `<title>scan &lt;1&gt;.pdf</title>`)
+	require.Contains(t, out, `class="ocr_page" id="page_1" title="bbox 0 0 1000 1400; ppageno 0"`)
+	require.Contains(t, out, `class="ocr_page" id="page_2" title="bbox 0 0 1000 1400; ppageno 1"`)
+	require.Contains(t, out, `class="ocr_line" id="line_1_1" title="bbox 100 98 390 132"`)
+	require.Contains(t, out, `class="ocr_line" id="line_1_2" title="bbox 100 150 350 180"`)
+	require.Contains(t, out, `title="bbox 100 100 220 130; x_wconf 95">Hello</span>`)
+	require.Contains(t, out, `>&amp;</span>`)
+}
+
+func TestHOCREmpty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, HOCR(&buf, Document{ID: "doc-1"}))
+	out := buf.String()
+
+	requireWellFormed(t, buf.Bytes())
+	require.Contains(t, out, `<body></body>`)
+	require.NotContains(t, out, `xml:lang`)
+	require.NotContains(t, out, `ocr-langs`)
+}
+
+func TestLanguageTags(t *testing.T) {
+	testCases := []struct {
+		codes []string
+		want  []string
+	}{
+		{[]string{"eng"}, []string{"en"}},
+		{[]string{"heb", "yid"}, []string{"he", "yi"}},
+		{[]string{"chi_sim", "chi_tra_vert"}, []string{"zh-Hans", "zh-Hant"}},
+		{[]string{"srp_latn", "srp"}, []string{"sr-Latn", "sr"}},
+		{[]string{"deu", "frk", "deu_latf"}, []string{"de"}},
+		{[]string{"grc", "fil"}, []string{"grc", "fil"}},
+		{[]string{"osd", "equ", "", "eng+fra", "ENG"}, nil},
+		{nil, nil},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.want, languageTags(tc.codes), "%v", tc.codes)
+	}
+}
+
+func TestALTO(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, ALTO(&buf, testDocument()))
+	out := buf.String()
+
+	requireWellFormed(t, buf.Bytes())
+	require.Contains(t, out, `<alto xmlns="http://www.loc.gov/standards/alto/ns-v4#"`)
+	require.Contains(t, out, `<MeasurementUnit>pixel</MeasurementUnit>`)
+	require.Contains(t, out, `<Page ID="page_1" PHYSICAL_IMG_NR="1" WIDTH="1000" HEIGHT="1400">`)
+	require.Contains(t, out, `<TextBlock ID="block_1" HPOS="100" VPOS="98" WIDTH="290" HEIGHT="82">`)
+	require.Contains(t, out, `<TextLine ID="line_1_1" HPOS="100" VPOS="98" WIDTH="290" HEIGHT="34">`)
+	require.Contains(t, out, `<String ID="string_1_1_1" HPOS="100" VPOS="100" WIDTH="120" HEIGHT="30" CONTENT="Hello" WC="0.95">`)
+	require.Contains(t, out, `CONTENT="&amp;"`)
+	require.Contains(t, out, `<SP>`)
+	require.Contains(t, out, `<Page ID="page_2" PHYSICAL_IMG_NR="2" WIDTH="1000" HEIGHT="1400">`)
+}
+
+func TestText(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Text(&buf, testDocument()))
+	require.Equal(t, testDocument().Text, buf.String())
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, JSON(&buf, testDocument()))
+
+	var doc Document
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	require.Equal(t, testDocument(), doc)
+}
@@ -0,0 +1,109 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+)
+
+// hOCR 1.2, see http://kba.github.io/hocr-spec/1.2/
+
+const hocrDoctype = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">`
+
+type hocrHTML struct {
+	XMLName xml.Name `xml:"html"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Lang    string   `xml:"xml:lang,attr,omitempty"`
+	Head    hocrHead `xml:"head"`
+	Body    hocrBody `xml:"body"`
+}
+
+// hocrBody is its own element so an empty document still has a body.
+type hocrBody struct {
+	Pages []hocrSpan `xml:"div"`
+}
+
+type hocrHead struct {
+	Title string     `xml:"title"`
+	Meta  []hocrMeta `xml:"meta"`
+}
+
+type hocrMeta struct {
+	HTTPEquiv string `xml:"http-equiv,attr,omitempty"`
+	Name      string `xml:"name,attr,omitempty"`
+	Content   string `xml:"content,attr"`
+}
+
+// hocrSpan is any hOCR element: the page div, line spans and word spans.
+type hocrSpan struct {
+	Class    string     `xml:"class,attr"`
+	ID       string     `xml:"id,attr"`
+	Title    string     `xml:"title,attr"`
+	Text     string     `xml:",chardata"`
+	Children []hocrSpan `xml:"span"`
+}
+
+func hocrBBox(box image.Rectangle) string {
+	return fmt.Sprintf("bbox %d %d %d %d", box.Min.X, box.Min.Y, box.Max.X, box.Max.Y)
+}
+
+// HOCR writes the document as an hOCR 1.2 XHTML file, one ocr_page per page.
+// The document language is the first of its OCR languages, ocr-langs lists
+// them all.
+func HOCR(w io.Writer, doc Document) error {
+	langs := languageTags(doc.Languages)
+	html := hocrHTML{
+		Xmlns: "http://www.w3.org/1999/xhtml",
+		Head: hocrHead{
+			Title: doc.Filename,
+			Meta: []hocrMeta{
+				{HTTPEquiv: "Content-Type", Content: "text/html; charset=utf-8"},
+				{Name: "ocr-system", Content: "ocr-golang-back"},
+				{Name: "ocr-capabilities", Content: "ocr_page ocr_line ocrx_word ocrp_wconf"},
+			},
+		},
+	}
+	if len(langs) > 0 {
+		html.Lang = langs[0]
+		html.Head.Meta = append(html.Head.Meta, hocrMeta{Name: "ocr-langs", Content: strings.Join(langs, " ")})
+	}
+
+	for _, page := range doc.Pages {
+		div := hocrSpan{
+			Class: "ocr_page",
+			ID:    fmt.Sprintf("page_%d", page.Number),
+			Title: fmt.Sprintf("%s; ppageno %d", hocrBBox(image.Rect(0, 0, page.Width, page.Height)), page.Number-1),
+		}
+
+		for l, line := range page.lines() {
+			span := hocrSpan{
+				Class: "ocr_line",
+				ID:    fmt.Sprintf("line_%d_%d", page.Number, l+1),
+				Title: hocrBBox(line.box),
+			}
+			for i, word := range line.words {
+				span.Children = append(span.Children, hocrSpan{
+					Class: "ocrx_word",
+					ID:    fmt.Sprintf("word_%d_%d_%d", page.Number, l+1, i+1),
+					Title: fmt.Sprintf("%s; x_wconf %.0f", hocrBBox(wordBox(word)), word.Confidence),
+					Text:  word.Text,
+				})
+			}
+			div.Children = append(div.Children, span)
+		}
+		html.Body.Pages = append(html.Body.Pages, div)
+	}
+
+	if _, err := io.WriteString(w, xml.Header+hocrDoctype+"\n"); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", " ")
+	if err := encoder.Encode(html); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
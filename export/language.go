@@ -0,0 +1,58 @@
+package export
+
+import (
+	"slices"
+	"strings"
+)
+
+// tesseractLanguages maps Tesseract language codes to BCP 47 tags. Codes
+// missing here are ISO 639-3 codes with no shorter ISO 639-1 code, which are
+// their own BCP 47 tags, or not languages at all.
+var tesseractLanguages = map[string]string{
+	"afr": "af", "amh": "am", "ara": "ar", "asm": "as", "aze": "az",
+	"aze_cyrl": "az-Cyrl", "bel": "be", "ben": "bn", "bod": "bo", "bos": "bs",
+	"bre": "br", "bul": "bg", "cat": "ca", "ces": "cs", "chi_sim": "zh-Hans",
+	"chi_sim_vert": "zh-Hans", "chi_tra": "zh-Hant", "chi_tra_vert": "zh-Hant",
+	"cos": "co", "cym": "cy", "dan": "da", "deu": "de", "deu_latf": "de",
+	"div": "dv", "dzo": "dz", "ell": "el", "eng": "en", "epo": "eo",
+	"est": "et", "eus": "eu", "fao": "fo", "fas": "fa", "fin": "fi",
+	"fra": "fr", "frk": "de", "fry": "fy", "gla": "gd", "gle": "ga",
+	"glg": "gl", "guj": "gu", "hat": "ht", "heb": "he", "hin": "hi",
+	"hrv": "hr", "hun": "hu", "hye": "hy", "iku": "iu", "ind": "id",
+	"isl": "is", "ita": "it", "ita_old": "it", "jav": "jv", "jpn": "ja",
+	"jpn_vert": "ja", "kan": "kn", "kat": "ka", "kat_old": "ka", "kaz": "kk",
+	"khm": "km", "kir": "ky", "kor": "ko", "kor_vert": "ko", "lao": "lo",
+	"lat": "la", "lav": "lv", "lit": "lt", "ltz": "lb", "mal": "ml",
+	"mar": "mr", "mkd": "mk", "mlt": "mt", "mon": "mn", "mri": "mi",
+	"msa": "ms", "mya": "my", "nep": "ne", "nld": "nl", "nor": "no",
+	"oci": "oc", "ori": "or", "pan": "pa", "pol": "pl", "por": "pt",
+	"pus": "ps", "que": "qu", "ron": "ro", "rus": "ru", "san": "sa",
+	"sin": "si", "slk": "sk", "slv": "sl", "snd": "sd", "spa": "es",
+	"spa_old": "es", "sqi": "sq", "srp": "sr", "srp_latn": "sr-Latn",
+	"sun": "su", "swa": "sw", "swe": "sv", "tam": "ta", "tat": "tt",
+	"tel": "te", "tgk": "tg", "tha": "th", "tir": "ti", "ton": "to",
+	"tur": "tr", "uig": "ug", "ukr": "uk", "urd": "ur", "uzb": "uz",
+	"uzb_cyrl": "uz-Cyrl", "vie": "vi", "yid": "yi", "yor": "yo",
+}
+
+// notLanguages are Tesseract models that don't read a language.
+var notLanguages = map[string]bool{"osd": true, "equ": true}
+
+// languageTags returns the BCP 47 tags of Tesseract language codes, in order
+// and without duplicates. Codes that aren't languages are left out.
+func languageTags(codes []string) []string {
+	var tags []string
+	for _, code := range codes {
+		tag, ok := tesseractLanguages[code]
+		if !ok {
+			if notLanguages[code] || len(code) != 3 || strings.Trim(code, "abcdefghijklmnopqrstuvwxyz") != "" {
+				continue
+			}
+			tag = code
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,226 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"slices"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// MinDetectionConfidence is the mean word confidence the best trial language
+// needs for DetectLanguage to trust it.
+const MinDetectionConfidence = 60
+
+// ErrLanguageUndetected is returned by DetectLanguage when no candidate
+// language reads the page well enough.
+var ErrLanguageUndetected = errors.New("could not detect the document language")
+
+// OSD is what orientation and script detection reports about a page.
+type OSD struct {
+	// Rotate is how many degrees, clockwise, the page must be rotated by to
+	// be upright: 0, 90, 180 or 270.
+	Rotate int
+	// Script is the dominant script, in Tesseract's names: "Latin",
+	// "Arabic", "Cyrillic"...
+	Script           string
+	ScriptConfidence float64
+}
+
+// OSDetector is implemented by engines that can detect the orientation and
+// script of a page.
+type OSDetector interface {
+	DetectOSD(ctx context.Context, imagePath string) (*OSD, error)
+}
+
+// Detection is the language a page was detected to be written in.
+type Detection struct {
+	Language   string
+	Script     string
+	Confidence float64
+}
+
+// languageScripts maps the languages we expect to see to the script OSD
+// reports for them. Languages missing here are only tried when no candidate
+// matches the detected script.
+var languageScripts = map[string]string{
+	"eng":     "Latin",
+	"fra":     "Latin",
+	"deu":     "Latin",
+	"spa":     "Latin",
+	"ita":     "Latin",
+	"por":     "Latin",
+	"nld":     "Latin",
+	"pol":     "Latin",
+	"tur":     "Latin",
+	"ara":     "Arabic",
+	"fas":     "Arabic",
+	"urd":     "Arabic",
+	"rus":     "Cyrillic",
+	"ukr":     "Cyrillic",
+	"bul":     "Cyrillic",
+	"ell":     "Greek",
+	"heb":     "Hebrew",
+	"hin":     "Devanagari",
+	"tha":     "Thai",
+	"chi_sim": "Han",
+	"chi_tra": "Han",
+	"jpn":     "Japanese",
+	"kor":     "Hangul",
+}
+
+// DefaultDetectionCandidates are the languages DetectLanguage tries when not
+// given any.
+var DefaultDetectionCandidates = []string{"eng", "fra", "deu", "spa", "ita", "por", "rus", "ara"}
+
+// Language trials run on a sample of the page rather than the whole of it:
+// its middle band, sampleShare of the page height, scaled down to at most
+// maxSampleWidth pixels wide.
+const (
+	sampleShare    = 0.5
+	maxSampleWidth = 1280
+)
+
+// DetectOptions tunes DetectLanguage.
+type DetectOptions struct {
+	// Candidates are the languages tried, those not installed are skipped.
+	// Each costs one recognition of the sample, so keep it short. Empty
+	// means DefaultDetectionCandidates.
+	Candidates []string
+	// TempDir is where the sample is written. Empty means the system temp
+	// dir.
+	TempDir string
+}
+
+// DetectLanguage finds the candidate language that reads imagePath best,
+// trying each on a sample of the page. When engine can detect scripts, only
+// candidates written in the detected script are tried. A trial that fails is
+// skipped, the others still count.
+func DetectLanguage(ctx context.Context, engine Engine, imagePath string, opts DetectOptions) (*Detection, error) {
+	installed, err := engine.Languages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list OCR languages: %w", err)
+	}
+
+	wanted := opts.Candidates
+	if len(wanted) == 0 {
+		wanted = DefaultDetectionCandidates
+	}
+	var candidates []string
+	for _, language := range wanted {
+		if slices.Contains(installed, language) {
+			candidates = append(candidates, language)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: no candidate language is installed", ErrLanguageUndetected)
+	}
+
+	var script string
+	if detector, ok := engine.(OSDetector); ok {
+		osd, err := detector.DetectOSD(ctx, imagePath)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			// Not fatal, every candidate is tried instead.
+		} else {
+			script = osd.Script
+			if inScript := languagesInScript(candidates, script); len(inScript) > 0 {
+				candidates = inScript
+			}
+		}
+	}
+
+	sample, err := writeSample(imagePath, opts.TempDir)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(sample)
+
+	var best *Detection
+	var trialErr error
+	for _, language := range candidates {
+		result, err := engine.Recognize(ctx, sample, Options{Languages: []string{language}})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			trialErr = fmt.Errorf("trial with %s failed: %w", language, err)
+			continue
+		}
+		if len(result.Words) == 0 {
+			continue
+		}
+		if best == nil || result.Confidence > best.Confidence {
+			best = &Detection{Language: language, Confidence: result.Confidence}
+		}
+	}
+
+	if best == nil || best.Confidence < MinDetectionConfidence {
+		if best == nil && trialErr != nil {
+			return nil, fmt.Errorf("%w: %v", ErrLanguageUndetected, trialErr)
+		}
+		return nil, ErrLanguageUndetected
+	}
+
+	best.Script = script
+	if best.Script == "" {
+		best.Script = languageScripts[best.Language]
+	}
+	return best, nil
+}
+
+// writeSample writes the sample of imagePath language trials run on to a
+// temporary PNG in dir, and returns its path. The caller removes it.
+func writeSample(imagePath, dir string) (string, error) {
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return "", err
+	}
+	img, _, err := image.Decode(file)
+	file.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %s: %w", imagePath, err)
+	}
+
+	bounds := img.Bounds()
+	height := max(1, int(float64(bounds.Dy())*sampleShare))
+	top := bounds.Min.Y + (bounds.Dy()-height)/2
+	band := image.Rect(bounds.Min.X, top, bounds.Max.X, top+height)
+
+	width := min(band.Dx(), maxSampleWidth)
+	scaled := max(1, band.Dy()*width/max(1, band.Dx()))
+	sample := image.NewRGBA(image.Rect(0, 0, width, scaled))
+	xdraw.ApproxBiLinear.Scale(sample, sample.Bounds(), img, band, xdraw.Src, nil)
+
+	out, err := os.CreateTemp(dir, "ocr-sample-*.png")
+	if err != nil {
+		return "", err
+	}
+	err = png.Encode(out, sample)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(out.Name())
+		return "", fmt.Errorf("failed to write language sample: %w", err)
+	}
+	return out.Name(), nil
+}
+
+// languagesInScript keeps the languages known to be written in script.
+func languagesInScript(languages []string, script string) []string {
+	var kept []string
+	for _, language := range languages {
+		if languageScripts[language] == script {
+			kept = append(kept, language)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,173 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// trialEngine reads every page with the confidence set for the language it's
+// asked for, failing for those in fail, and records the languages tried and
+// the paths and sizes of the images they were tried on.
+type trialEngine struct {
+	confidence map[string]float64
+	fail       map[string]bool
+	osd        *OSD
+	osdErr     error
+	tried      []string
+	paths      []string
+	sizes      []image.Point
+}
+
+func (e *trialEngine) Languages() ([]string, error) {
+	return []string{"eng", "fra", "ara", "rus"}, nil
+}
+
+func (e *trialEngine) Recognize(ctx context.Context, imagePath string, opts Options) (*Result, error) {
+	language := opts.Languages[0]
+	e.tried = append(e.tried, language)
+	e.paths = append(e.paths, imagePath)
+
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return nil, err
+	}
+	e.sizes = append(e.sizes, image.Pt(config.Width, config.Height))
+
+	if e.fail[language] {
+		return nil, errors.New("failed loading language " + language)
+	}
+
+	confidence, ok := e.confidence[language]
+	if !ok {
+		return &Result{}, nil
+	}
+	words := []Word{{Text: "word", Confidence: confidence}}
+	return &Result{Text: "word", Words: words, Confidence: confidence}, nil
+}
+
+type osdEngine struct{ *trialEngine }
+
+func (e osdEngine) DetectOSD(ctx context.Context, imagePath string) (*OSD, error) {
+	return e.osd, e.osdErr
+}
+
+func TestDetectLanguage(t *testing.T) {
+	engine := &trialEngine{confidence: map[string]float64{"eng": 70, "fra": 85, "rus": 20}}
+
+	detection, err := DetectLanguage(context.Background(), engine, "testdata/page.png", DetectOptions{})
+	require.NoError(t, err)
+	require.Equal(t, &Detection{Language: "fra", Script: "Latin", Confidence: 85}, detection)
+	require.Equal(t, []string{"eng", "fra", "rus", "ara"}, engine.tried)
+}
+
+func TestDetectLanguageCandidates(t *testing.T) {
+	engine := &trialEngine{confidence: map[string]float64{"eng": 70, "fra": 85, "rus": 90}}
+
+	// Candidates that aren't installed are skipped.
+	opts := DetectOptions{Candidates: []string{"deu", "eng", "fra"}}
+	detection, err := DetectLanguage(context.Background(), engine, "testdata/page.png", opts)
+	require.NoError(t, err)
+	require.Equal(t, "fra", detection.Language)
+	require.Equal(t, []string{"eng", "fra"}, engine.tried)
+
+	_, err = DetectLanguage(context.Background(), engine, "testdata/page.png", DetectOptions{Candidates: []string{"deu"}})
+	require.ErrorIs(t, err, ErrLanguageUndetected)
+}
+
+func TestDetectLanguageSample(t *testing.T) {
+	engine := &trialEngine{confidence: map[string]float64{"eng": 70}}
+
+	_, err := DetectLanguage(context.Background(), engine, "testdata/page.png", DetectOptions{Candidates: []string{"eng"}})
+	require.NoError(t, err)
+
+	file, err := os.Open("testdata/page.png")
+	require.NoError(t, err)
+	defer file.Close()
+	page, _, err := image.DecodeConfig(file)
+	require.NoError(t, err)
+
+	require.Len(t, engine.sizes, 1)
+	require.LessOrEqual(t, engine.sizes[0].X, min(page.Width, maxSampleWidth))
+	require.Less(t, engine.sizes[0].Y, page.Height)
+}
+
+func TestDetectLanguageFailedTrial(t *testing.T) {
+	engine := &trialEngine{
+		confidence: map[string]float64{"eng": 95, "fra": 85},
+		fail:       map[string]bool{"eng": true},
+	}
+
+	detection, err := DetectLanguage(context.Background(), engine, "testdata/page.png", DetectOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "fra", detection.Language)
+	require.Equal(t, []string{"eng", "fra", "rus", "ara"}, engine.tried)
+
+	// With every trial failing there is nothing to go on.
+	engine = &trialEngine{fail: map[string]bool{"eng": true, "fra": true, "rus": true, "ara": true}}
+	_, err = DetectLanguage(context.Background(), engine, "testdata/page.png", DetectOptions{})
+	require.ErrorIs(t, err, ErrLanguageUndetected)
+}
+
+func TestDetectLanguageByScript(t *testing.T) {
+	engine := osdEngine{&trialEngine{
+		confidence: map[string]float64{"eng": 90, "ara": 75},
+		osd:        &OSD{Script: "Arabic", ScriptConfidence: 8},
+	}}
+
+	detection, err := DetectLanguage(context.Background(), engine, "testdata/page.png", DetectOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "ara", detection.Language)
+	require.Equal(t, "Arabic", detection.Script)
+	require.Equal(t, []string{"ara"}, engine.tried)
+}
+
+func TestDetectLanguageOSDFailure(t *testing.T) {
+	engine := osdEngine{&trialEngine{
+		confidence: map[string]float64{"rus": 80},
+		osdErr:     errors.New("osd.traineddata not installed"),
+	}}
+
+	detection, err := DetectLanguage(context.Background(), engine, "testdata/page.png", DetectOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "rus", detection.Language)
+	require.Equal(t, "Cyrillic", detection.Script)
+	require.Len(t, engine.tried, 4)
+}
+
+func TestDetectLanguageUndetected(t *testing.T) {
+	engine := &trialEngine{confidence: map[string]float64{"eng": 40}}
+
+	_, err := DetectLanguage(context.Background(), engine, "testdata/page.png", DetectOptions{})
+	require.ErrorIs(t, err, ErrLanguageUndetected)
+
+	_, err = DetectLanguage(context.Background(), &trialEngine{}, "testdata/page.png", DetectOptions{})
+	require.ErrorIs(t, err, ErrLanguageUndetected)
+}
+
+func TestDetectLanguageTempDir(t *testing.T) {
+	engine := &trialEngine{confidence: map[string]float64{"eng": 70}}
+	dir := t.TempDir()
+
+	_, err := DetectLanguage(context.Background(), engine, "testdata/page.png", DetectOptions{TempDir: dir})
+	require.NoError(t, err)
+
+	// Every trial reads the sample in dir, which is removed after.
+	require.NotEmpty(t, engine.paths)
+	for _, path := range engine.paths {
+		require.Equal(t, dir, filepath.Dir(path))
+	}
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
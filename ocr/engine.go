@@ -0,0 +1,52 @@
+// Package ocr defines the engine abstraction the document pipeline runs OCR
+// through, so engines can be swapped per deployment and faked in tests.
+package ocr
+
+import "context"
+
+// Word is a recognized word and its bounding box, in pixels of the source
+// image with the origin at the top-left corner. Line numbers the text line the
+// word belongs to, from 0 in reading order.
+type Word struct {
+	Text       string  `json:"text"`
+	Line       int     `json:"line"`
+	X          int     `json:"x"`
+	Y          int     `json:"y"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Result is the outcome of recognizing a single image.
+type Result struct {
+	Text  string `json:"text"`
+	Words []Word `json:"words"`
+	// Confidence is the mean word confidence, from 0 to 100.
+	Confidence float64 `json:"confidence"`
+}
+
+// Options tunes a single recognition.
+type Options struct {
+	// Languages are the languages to recognize, in the engine's own codes
+	// (Tesseract's "eng", "fra", "ara"...). Empty means the engine default.
+	Languages []string
+}
+
+// Engine recognizes the text in an image file.
+type Engine interface {
+	Recognize(ctx context.Context, imagePath string, opts Options) (*Result, error)
+	// Languages lists the languages the engine has models installed for.
+	Languages() ([]string, error)
+}
+
+// MeanConfidence returns the average confidence of words, or 0 if there are none.
+func MeanConfidence(words []Word) float64 {
+	if len(words) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, word := range words {
+		sum += word.Confidence
+	}
+	return sum / float64(len(words))
+}
@@ -0,0 +1,90 @@
+package ocr
+
+import (
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FakeConfidence is the confidence Fake reports for every word.
+const FakeConfidence = 90
+
+// Fake is a deterministic Engine for tests. It never looks at the pixels:
+// every image is recognized as Text, or as the image's base name when Text is
+// empty, laid out as one line of equally wide words across the top of the
+// image. It claims the Installed languages, English when empty, and ignores
+// the languages it's asked for. Delay makes every recognition take that long,
+// to stand in for a real engine in benchmarks.
+type Fake struct {
+	Text      string
+	Installed []string
+	Delay     time.Duration
+}
+
+func (f *Fake) Languages() ([]string, error) {
+	if len(f.Installed) == 0 {
+		return []string{"eng"}, nil
+	}
+	return f.Installed, nil
+}
+
+func (f *Fake) Recognize(ctx context.Context, imagePath string, opts Options) (*Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if f.Delay > 0 {
+		timer := time.NewTimer(f.Delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", imagePath, err)
+	}
+
+	text := f.Text
+	if text == "" {
+		text = strings.TrimSuffix(filepath.Base(imagePath), filepath.Ext(imagePath))
+	}
+
+	fields := strings.Fields(text)
+	words := make([]Word, len(fields))
+	if len(fields) > 0 {
+		width := config.Width / len(fields)
+		height := min(config.Height, 32)
+		for i, field := range fields {
+			words[i] = Word{
+				Text:       field,
+				X:          i * width,
+				Y:          0,
+				Width:      width,
+				Height:     height,
+				Confidence: FakeConfidence,
+			}
+		}
+	}
+
+	return &Result{
+		Text:       strings.Join(fields, " "),
+		Words:      words,
+		Confidence: MeanConfidence(words),
+	}, nil
+}
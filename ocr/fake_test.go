@@ -0,0 +1,62 @@
+package ocr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeRecognize(t *testing.T) {
+	engine := &Fake{Text: "hello  fake world"}
+
+	result, err := engine.Recognize(context.Background(), "testdata/page.png", Options{})
+	require.NoError(t, err)
+	require.Equal(t, "hello fake world", result.Text)
+	require.Len(t, result.Words, 3)
+	require.Equal(t, float64(FakeConfidence), result.Confidence)
+
+	for i, word := range result.Words {
+		require.Equal(t, i*word.Width, word.X)
+		require.LessOrEqual(t, word.X+word.Width, 200)
+		require.LessOrEqual(t, word.Y+word.Height, 100)
+	}
+
+	again, err := engine.Recognize(context.Background(), "testdata/page.png", Options{})
+	require.NoError(t, err)
+	require.Equal(t, result, again)
+}
+
+func TestFakeRecognizeDefaultText(t *testing.T) {
+	result, err := (&Fake{}).Recognize(context.Background(), "testdata/page.png", Options{})
+	require.NoError(t, err)
+	require.Equal(t, "page", result.Text)
+}
+
+func TestFakeRecognizeCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := (&Fake{}).Recognize(ctx, "testdata/page.png", Options{})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestMeanConfidence(t *testing.T) {
+	require.Zero(t, MeanConfidence(nil))
+	require.Equal(t, 50.0, MeanConfidence([]Word{{Confidence: 40}, {Confidence: 60}}))
+}
+
+func TestFakeRecognizeDelay(t *testing.T) {
+	engine := &Fake{Delay: 20 * time.Millisecond}
+
+	start := time.Now()
+	_, err := engine.Recognize(context.Background(), "testdata/page.png", Options{})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), engine.Delay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err = engine.Recognize(ctx, "testdata/page.png", Options{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
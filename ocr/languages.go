@@ -0,0 +1,50 @@
+package ocr
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ParseLanguages splits a language list as Tesseract writes it, "eng+fra",
+// also accepting commas. Duplicates and blanks are dropped.
+func ParseLanguages(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '+' || r == ',' || r == ' '
+	})
+
+	languages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if !slices.Contains(languages, field) {
+			languages = append(languages, field)
+		}
+	}
+	return languages
+}
+
+// ErrUnsupportedLanguage is returned by ValidateLanguages for languages the
+// engine can't recognize, as opposed to failing to list them.
+var ErrUnsupportedLanguage = errors.New("unsupported OCR language")
+
+// ValidateLanguages checks every language has a model installed for engine.
+func ValidateLanguages(engine Engine, languages []string) error {
+	if len(languages) == 0 {
+		return fmt.Errorf("%w: none given", ErrUnsupportedLanguage)
+	}
+
+	installed, err := engine.Languages()
+	if err != nil {
+		return fmt.Errorf("failed to list OCR languages: %w", err)
+	}
+
+	for _, language := range languages {
+		if !slices.Contains(installed, language) {
+			return fmt.Errorf(
+				"%w %q, installed languages are %s",
+				ErrUnsupportedLanguage, language, strings.Join(installed, ", "),
+			)
+		}
+	}
+	return nil
+}
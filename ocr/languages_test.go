@@ -0,0 +1,29 @@
+package ocr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLanguages(t *testing.T) {
+	require.Equal(t, []string{"eng", "fra"}, ParseLanguages("eng+fra"))
+	require.Equal(t, []string{"ara", "eng"}, ParseLanguages(" ara, eng,ara "))
+	require.Empty(t, ParseLanguages(""))
+	require.Empty(t, ParseLanguages("+"))
+}
+
+func TestValidateLanguages(t *testing.T) {
+	engine := &Fake{Installed: []string{"eng", "fra", "ara"}}
+
+	require.NoError(t, ValidateLanguages(engine, []string{"fra"}))
+	require.NoError(t, ValidateLanguages(engine, []string{"ara", "eng"}))
+
+	err := ValidateLanguages(engine, []string{"eng", "deu"})
+	require.ErrorIs(t, err, ErrUnsupportedLanguage)
+	require.ErrorContains(t, err, `"deu"`)
+	require.ErrorContains(t, err, "eng, fra, ara")
+
+	require.ErrorIs(t, ValidateLanguages(engine, nil), ErrUnsupportedLanguage)
+	require.ErrorIs(t, ValidateLanguages(&Fake{}, []string{"fra"}), ErrUnsupportedLanguage)
+}
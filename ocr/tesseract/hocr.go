@@ -0,0 +1,156 @@
+package tesseract
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/yosa/ocr-golang-back/ocr"
+)
+
+// Classes of the hOCR elements Tesseract writes a line of text as.
+var hocrLineClasses = map[string]bool{
+	"ocr_line":      true,
+	"ocr_caption":   true,
+	"ocr_header":    true,
+	"ocr_textfloat": true,
+}
+
+// parseHOCR reads the words of a page from the hOCR Tesseract writes for it,
+// and rebuilds the page text from them the way Tesseract lays it out: one
+// line of words per text line, a blank line after every paragraph. Asking
+// gosseract for the text and the boxes separately recognizes the page each
+// time, hOCR has both from one recognition.
+func parseHOCR(hocr string) (*ocr.Result, error) {
+	decoder := xml.NewDecoder(strings.NewReader(hocr))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var (
+		words     []ocr.Word
+		text      strings.Builder
+		classes   []string
+		line      = -1
+		lineWords []string
+		word      *ocr.Word
+		wordText  strings.Builder
+	)
+	for {
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse hOCR: %w", err)
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			class := hocrAttr(tok, "class")
+			classes = append(classes, class)
+			switch {
+			case hocrLineClasses[class]:
+				line++
+				lineWords = lineWords[:0]
+			case class == "ocrx_word":
+				box, confidence, err := parseHOCRTitle(hocrAttr(tok, "title"))
+				if err != nil {
+					return nil, err
+				}
+				word = &ocr.Word{
+					Line:       max(line, 0),
+					X:          box[0],
+					Y:          box[1],
+					Width:      box[2] - box[0],
+					Height:     box[3] - box[1],
+					Confidence: confidence,
+				}
+				wordText.Reset()
+			}
+
+		case xml.CharData:
+			if word != nil {
+				wordText.Write(tok)
+			}
+
+		case xml.EndElement:
+			if len(classes) == 0 {
+				continue
+			}
+			class := classes[len(classes)-1]
+			classes = classes[:len(classes)-1]
+			switch {
+			case class == "ocrx_word" && word != nil:
+				word.Text = strings.TrimSpace(wordText.String())
+				if word.Text != "" {
+					words = append(words, *word)
+					lineWords = append(lineWords, word.Text)
+				}
+				word = nil
+			case hocrLineClasses[class]:
+				if len(lineWords) > 0 {
+					text.WriteString(strings.Join(lineWords, " "))
+					text.WriteByte('\n')
+				}
+			case class == "ocr_par":
+				if text.Len() > 0 && !strings.HasSuffix(text.String(), "\n\n") {
+					text.WriteByte('\n')
+				}
+			}
+		}
+	}
+
+	return &ocr.Result{
+		Text:       text.String(),
+		Words:      words,
+		Confidence: ocr.MeanConfidence(words),
+	}, nil
+}
+
+func hocrAttr(elem xml.StartElement, name string) string {
+	for _, attr := range elem.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// parseHOCRTitle reads the box and confidence of a word from its title,
+// "bbox 36 92 96 116; x_wconf 93".
+func parseHOCRTitle(title string) (box [4]int, confidence float64, err error) {
+	hasBox := false
+	for _, property := range strings.Split(title, ";") {
+		fields := strings.Fields(property)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "bbox":
+			if len(fields) != 5 {
+				return box, 0, fmt.Errorf("invalid hOCR box %q", property)
+			}
+			for i, field := range fields[1:] {
+				if box[i], err = strconv.Atoi(field); err != nil {
+					return box, 0, fmt.Errorf("invalid hOCR box %q: %w", property, err)
+				}
+			}
+			hasBox = true
+		case "x_wconf":
+			if len(fields) != 2 {
+				return box, 0, fmt.Errorf("invalid hOCR confidence %q", property)
+			}
+			if confidence, err = strconv.ParseFloat(fields[1], 64); err != nil {
+				return box, 0, fmt.Errorf("invalid hOCR confidence %q: %w", property, err)
+			}
+		}
+	}
+	if !hasBox {
+		return box, 0, fmt.Errorf("hOCR word without a box: %q", title)
+	}
+	return box, confidence, nil
+}
@@ -0,0 +1,64 @@
+package tesseract
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yosa/ocr-golang-back/ocr"
+)
+
+// The hOCR Tesseract 5 writes for a page of two paragraphs, the first of two
+// lines.
+const pageHOCR = `  <div class='ocr_page' id='page_1' title='image "page.png"; bbox 0 0 800 600; ppageno 0'>
+   <div class='ocr_carea' id='block_1_1' title="bbox 36 92 600 200">
+    <p class='ocr_par' id='par_1_1' lang='eng' title="bbox 36 92 600 200">
+     <span class='ocr_line' id='line_1_1' title="bbox 36 92 300 116; baseline 0 -4; x_size 24">
+      <span class='ocrx_word' id='word_1_1' title='bbox 36 92 96 116; x_wconf 93'>Invoice</span>
+      <span class='ocrx_word' id='word_1_2' title='bbox 104 92 140 116; x_wconf 88'>42</span>
+     </span>
+     <span class='ocr_line' id='line_1_2' title="bbox 36 130 300 154">
+      <span class='ocrx_word' id='word_1_3' title='bbox 36 130 120 154; x_wconf 75'>Fish &amp; Chips</span>
+      <span class='ocrx_word' id='word_1_4' title='bbox 130 130 140 154; x_wconf 0'> </span>
+     </span>
+    </p>
+   </div>
+   <div class='ocr_carea' id='block_1_2' title="bbox 36 300 600 330">
+    <p class='ocr_par' id='par_1_2' lang='eng' title="bbox 36 300 600 330">
+     <span class='ocr_header' id='line_1_3' title="bbox 36 300 200 330">
+      <span class='ocrx_word' id='word_1_5' title='bbox 36 300 200 330; x_wconf 90'><strong>Total</strong></span>
+     </span>
+    </p>
+   </div>
+  </div>
+`
+
+func TestParseHOCR(t *testing.T) {
+	result, err := parseHOCR(pageHOCR)
+	require.NoError(t, err)
+
+	require.Equal(t, "Invoice 42\nFish & Chips\n\nTotal\n\n", result.Text)
+	require.Equal(t, []ocr.Word{
+		{Text: "Invoice", Line: 0, X: 36, Y: 92, Width: 60, Height: 24, Confidence: 93},
+		{Text: "42", Line: 0, X: 104, Y: 92, Width: 36, Height: 24, Confidence: 88},
+		{Text: "Fish & Chips", Line: 1, X: 36, Y: 130, Width: 84, Height: 24, Confidence: 75},
+		{Text: "Total", Line: 2, X: 36, Y: 300, Width: 164, Height: 30, Confidence: 90},
+	}, result.Words)
+	require.InDelta(t, 86.5, result.Confidence, 0.001)
+}
+
+func TestParseHOCREmpty(t *testing.T) {
+	result, err := parseHOCR(`<div class='ocr_page' id='page_1' title='bbox 0 0 800 600'></div>`)
+	require.NoError(t, err)
+	require.Empty(t, result.Text)
+	require.Empty(t, result.Words)
+	require.Zero(t, result.Confidence)
+}
+
+func TestParseHOCRInvalidBox(t *testing.T) {
+	_, err := parseHOCR(`<span class='ocrx_word' title='bbox 1 2 three 4'>x</span>`)
+	require.ErrorContains(t, err, "invalid hOCR box")
+
+	_, err = parseHOCR(`<span class='ocrx_word' title='x_wconf 90'>x</span>`)
+	require.ErrorContains(t, err, "without a box")
+}
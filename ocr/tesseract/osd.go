@@ -0,0 +1,77 @@
+package tesseract
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/yosa/ocr-golang-back/ocr"
+)
+
+// DetectOSD runs Tesseract's orientation and script detection, which needs
+// osd.traineddata. gosseract doesn't expose it, so it goes through the
+// tesseract command.
+func (e *Engine) DetectOSD(ctx context.Context, imagePath string) (*ocr.OSD, error) {
+	if err := e.checkInstalled("osd"); err != nil {
+		return nil, err
+	}
+
+	args := []string{imagePath, "stdout", "--psm", "0"}
+	if e.tessdataDir != "" {
+		args = append(args, "--tessdata-dir", e.tessdataDir)
+	}
+
+	cmd := exec.CommandContext(ctx, "tesseract", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("osd failed on %s: %w (stderr: %s)", imagePath, err, stderr.String())
+	}
+	return parseOSD(stdout.String())
+}
+
+// parseOSD reads the report tesseract --psm 0 prints:
+//
+//	Page number: 0
+//	Orientation in degrees: 270
+//	Rotate: 90
+//	Orientation confidence: 5.28
+//	Script: Latin
+//	Script confidence: 2.86
+func parseOSD(report string) (*ocr.OSD, error) {
+	var osd ocr.OSD
+	var found bool
+
+	scanner := bufio.NewScanner(strings.NewReader(report))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		var err error
+		switch strings.TrimSpace(key) {
+		case "Rotate":
+			osd.Rotate, err = strconv.Atoi(value)
+		case "Script":
+			osd.Script, found = value, true
+		case "Script confidence":
+			osd.ScriptConfidence, err = strconv.ParseFloat(value, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid osd line %q: %w", scanner.Text(), err)
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no script in osd report")
+	}
+	return &osd, nil
+}
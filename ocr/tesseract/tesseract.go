@@ -0,0 +1,161 @@
+// Package tesseract implements ocr.Engine with Tesseract through gosseract.
+// It lives apart from package ocr because it needs cgo and libtesseract.
+package tesseract
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/otiai10/gosseract"
+
+	"github.com/yosa/ocr-golang-back/ocr"
+)
+
+// Engine recognizes images with a pool of Tesseract clients, one per
+// concurrent recognition. Clients are created on first use and reused.
+type Engine struct {
+	tessdataDir string
+	languages   []string
+	// clients holds one slot per client, nil until the slot is first used.
+	clients chan *gosseract.Client
+}
+
+// New returns an engine loading its models from tessdataDir and running at
+// most clients recognitions at once, recognizing the given languages unless
+// told otherwise, English by default.
+//
+// gosseract doesn't pass a data path to Tesseract, which finds its models
+// through TESSDATA_PREFIX, so New sets it for the process.
+func New(tessdataDir string, clients int, languages ...string) *Engine {
+	if len(languages) == 0 {
+		languages = []string{"eng"}
+	}
+	if clients <= 0 {
+		clients = 1
+	}
+	if tessdataDir != "" {
+		os.Setenv("TESSDATA_PREFIX", tessdataDir)
+	}
+
+	e := &Engine{
+		tessdataDir: tessdataDir,
+		languages:   languages,
+		clients:     make(chan *gosseract.Client, clients),
+	}
+	for i := 0; i < clients; i++ {
+		e.clients <- nil
+	}
+	return e
+}
+
+// Close frees the clients. Recognitions still running keep theirs, so it
+// should only be called once the engine is no longer in use.
+func (e *Engine) Close() error {
+	for {
+		select {
+		case client := <-e.clients:
+			if client != nil {
+				client.Close()
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// acquire waits for a free client.
+func (e *Engine) acquire(ctx context.Context) (*gosseract.Client, error) {
+	select {
+	case client := <-e.clients:
+		if client == nil {
+			client = gosseract.NewClient()
+		}
+		return client, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (e *Engine) release(client *gosseract.Client) {
+	e.clients <- client
+}
+
+// Languages lists the languages with a traineddata file in the tessdata dir.
+// osd is left out, it detects orientation and script but recognizes nothing.
+func (e *Engine) Languages() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(e.tessdataDir, "*.traineddata"))
+	if err != nil {
+		return nil, err
+	}
+
+	languages := make([]string, 0, len(files))
+	for _, file := range files {
+		language := strings.TrimSuffix(filepath.Base(file), ".traineddata")
+		if language != "osd" {
+			languages = append(languages, language)
+		}
+	}
+	return languages, nil
+}
+
+// checkInstalled fails when the traineddata file of language is missing from
+// the tessdata dir, which Tesseract would only report as a failed recognition.
+func (e *Engine) checkInstalled(language string) error {
+	if e.tessdataDir == "" {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(e.tessdataDir, language+".traineddata")); err != nil {
+		return fmt.Errorf("language %s is not installed: %w", language, err)
+	}
+	return nil
+}
+
+func (e *Engine) Recognize(ctx context.Context, imagePath string, opts ocr.Options) (*ocr.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	client, err := e.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer e.release(client)
+
+	languages := opts.Languages
+	if len(languages) == 0 {
+		languages = e.languages
+	}
+
+	for _, language := range languages {
+		if err := e.checkInstalled(language); err != nil {
+			return nil, err
+		}
+	}
+
+	// Configure Tesseract for better results
+	if err := client.SetLanguage(languages...); err != nil {
+		return nil, fmt.Errorf("failed to set language %s: %w", strings.Join(languages, "+"), err)
+	}
+	if err := client.SetPageSegMode(gosseract.PSM_AUTO); err != nil {
+		return nil, fmt.Errorf("failed to set page segmentation mode for %s: %w", strings.Join(languages, "+"), err)
+	}
+
+	if err := client.SetImage(imagePath); err != nil {
+		return nil, fmt.Errorf("failed to set image %s: %w", imagePath, err)
+	}
+
+	// Text and GetBoundingBoxes each recognize the image again, hOCR has
+	// the words, their boxes and lines from one recognition.
+	hocr, err := client.HOCRText()
+	if err != nil {
+		return nil, fmt.Errorf("ocr error on %s: %w", imagePath, err)
+	}
+	result, err := parseHOCR(hocr)
+	if err != nil {
+		return nil, fmt.Errorf("ocr error on %s: %w", imagePath, err)
+	}
+	return result, nil
+}
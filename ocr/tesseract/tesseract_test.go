@@ -0,0 +1,76 @@
+package tesseract
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yosa/ocr-golang-back/ocr"
+)
+
+func TestLanguages(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"eng.traineddata", "fra.traineddata", "osd.traineddata", "README"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+
+	languages, err := New(dir, 1).Languages()
+	require.NoError(t, err)
+	require.Equal(t, []string{"eng", "fra"}, languages)
+}
+
+func TestMissingLanguage(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "eng.traineddata"), nil, 0o644))
+	engine := New(dir, 1)
+
+	_, err := engine.Recognize(context.Background(), "page.png", ocr.Options{Languages: []string{"eng", "fra"}})
+	require.ErrorContains(t, err, "language fra is not installed")
+
+	_, err = engine.DetectOSD(context.Background(), "page.png")
+	require.ErrorContains(t, err, "language osd is not installed")
+}
+
+func TestParseOSD(t *testing.T) {
+	report := "Page number: 0\n" +
+		"Orientation in degrees: 270\n" +
+		"Rotate: 90\n" +
+		"Orientation confidence: 5.28\n" +
+		"Script: Arabic\n" +
+		"Script confidence: 2.86\n"
+
+	osd, err := parseOSD(report)
+	require.NoError(t, err)
+	require.Equal(t, &ocr.OSD{Rotate: 90, Script: "Arabic", ScriptConfidence: 2.86}, osd)
+
+	_, err = parseOSD("Too few characters. Skipping this page\n")
+	require.Error(t, err)
+
+	_, err = parseOSD("Rotate: sideways\nScript: Latin\n")
+	require.Error(t, err)
+}
+
+func TestClientPool(t *testing.T) {
+	engine := New("", 1)
+	defer engine.Close()
+
+	client, err := engine.acquire(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, client)
+
+	// The only client is taken, acquire waits until ctx gives up.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = engine.acquire(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	engine.release(client)
+	again, err := engine.acquire(context.Background())
+	require.NoError(t, err)
+	require.Same(t, client, again)
+	engine.release(again)
+}
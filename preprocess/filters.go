@@ -0,0 +1,150 @@
+package preprocess
+
+import (
+	"image"
+	"math"
+	"slices"
+)
+
+// Sauvola parameters: the window is about the height of two lines of body
+// text at 150 to 300 DPI, k is the usual value for printed documents.
+const (
+	sauvolaWindow = 31
+	sauvolaK      = 0.2
+	sauvolaR      = 128
+)
+
+// sauvola binarizes img with Sauvola's method: a pixel is black when darker
+// than mean * (1 + k * (stddev / R - 1)) over the window around it. Window
+// sums come from integral images so the cost doesn't grow with the window.
+func sauvola(img *image.Gray, window int, k float64) *image.Gray {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	sum, sumSq := integralImages(img)
+	half := window / 2
+
+	out := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := max(0, y-half), min(h, y+half+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := max(0, x-half), min(w, x+half+1)
+
+			n := float64((x1 - x0) * (y1 - y0))
+			s := sum[y1*(w+1)+x1] - sum[y0*(w+1)+x1] - sum[y1*(w+1)+x0] + sum[y0*(w+1)+x0]
+			sq := sumSq[y1*(w+1)+x1] - sumSq[y0*(w+1)+x1] - sumSq[y1*(w+1)+x0] + sumSq[y0*(w+1)+x0]
+
+			mean := s / n
+			stddev := math.Sqrt(math.Max(0, sq/n-mean*mean))
+			threshold := mean * (1 + k*(stddev/sauvolaR-1))
+
+			if float64(img.Pix[y*img.Stride+x]) > threshold {
+				out.Pix[y*out.Stride+x] = 255
+			}
+		}
+	}
+	return out
+}
+
+// integralImages returns the summed-area tables of img and of its squares,
+// (w+1) x (h+1) with a zero first row and column.
+func integralImages(img *image.Gray) (sum, sumSq []float64) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	sum = make([]float64, (w+1)*(h+1))
+	sumSq = make([]float64, (w+1)*(h+1))
+
+	for y := 0; y < h; y++ {
+		var row, rowSq float64
+		for x := 0; x < w; x++ {
+			v := float64(img.Pix[y*img.Stride+x])
+			row += v
+			rowSq += v * v
+			sum[(y+1)*(w+1)+x+1] = sum[y*(w+1)+x+1] + row
+			sumSq[(y+1)*(w+1)+x+1] = sumSq[y*(w+1)+x+1] + rowSq
+		}
+	}
+	return sum, sumSq
+}
+
+// medianFilter replaces every pixel with the median of its 3x3 neighbourhood,
+// clamped at the edges. It removes isolated specks without blurring strokes.
+func medianFilter(img *image.Gray) *image.Gray {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	out := image.NewGray(image.Rect(0, 0, w, h))
+
+	var window [9]uint8
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := 0
+			for dy := -1; dy <= 1; dy++ {
+				sy := min(max(y+dy, 0), h-1)
+				for dx := -1; dx <= 1; dx++ {
+					sx := min(max(x+dx, 0), w-1)
+					window[i] = img.Pix[sy*img.Stride+sx]
+					i++
+				}
+			}
+			slices.Sort(window[:])
+			out.Pix[y*out.Stride+x] = window[4]
+		}
+	}
+	return out
+}
+
+// Deskew searches angles up to MaxSkew degrees either way, in skewStep
+// increments, on a copy of the page at most skewSampleWidth pixels wide.
+const (
+	MaxSkew         = 5.0
+	skewStep        = 0.1
+	skewSampleWidth = 1000
+	darkThreshold   = 128
+)
+
+// estimateSkew returns the angle text lines are tilted by, in degrees,
+// clockwise in image coordinates. It projects the dark pixels on the normal
+// of every candidate angle: when the angle matches the lines, the projection
+// is made of sharp peaks, which maximizes the sum of squared bin counts.
+func estimateSkew(img *image.Gray) float64 {
+	sample := img
+	if img.Bounds().Dx() > skewSampleWidth {
+		sample = scale(img, float64(skewSampleWidth)/float64(img.Bounds().Dx()))
+	}
+	w, h := sample.Bounds().Dx(), sample.Bounds().Dy()
+
+	var xs, ys []float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if sample.Pix[y*sample.Stride+x] < darkThreshold {
+				xs = append(xs, float64(x))
+				ys = append(ys, float64(y))
+			}
+		}
+	}
+	if len(xs) == 0 {
+		return 0
+	}
+
+	diagonal := int(math.Hypot(float64(w), float64(h))) + 2
+	bins := make([]float64, 2*diagonal)
+
+	best, bestScore := 0.0, -1.0
+	steps := int(math.Round(MaxSkew / skewStep))
+	for i := -steps; i <= steps; i++ {
+		angle := float64(i) * skewStep
+		sin, cos := math.Sincos(angle * math.Pi / 180)
+
+		clear(bins)
+		for j := range xs {
+			r := int(math.Floor(ys[j]*cos-xs[j]*sin)) + diagonal
+			bins[r]++
+		}
+
+		var score float64
+		for _, n := range bins {
+			score += n * n
+		}
+		// Ties go to the smallest correction.
+		if score > bestScore || (score == bestScore && math.Abs(angle) < math.Abs(best)) {
+			best, bestScore = angle, score
+		}
+	}
+	return best
+}
@@ -0,0 +1,188 @@
+// Package preprocess cleans up page images before OCR: grayscale conversion,
+// orientation, DPI normalization, denoising, deskewing and adaptive
+// binarization, all in pure Go.
+//
+// Steps may move pixels around, so Apply also returns the transform back to
+// the source image: word boxes found on the cleaned image are mapped onto the
+// page image the user sees.
+package preprocess
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"slices"
+	"strings"
+)
+
+// Step is a preprocessing step.
+type Step string
+
+const (
+	// Grayscale drops color. Every other step works on gray pixels, so the
+	// result of Apply is always grayscale.
+	Grayscale Step = "grayscale"
+	// Orient rotates the page upright by Options.Rotate.
+	Orient Step = "orient"
+	// Normalize rescales the page from Options.SourceDPI to
+	// Options.TargetDPI.
+	Normalize Step = "normalize"
+	// Denoise removes speckles with a 3x3 median filter.
+	Denoise Step = "denoise"
+	// Deskew straightens text lines tilted by up to MaxSkew degrees.
+	Deskew Step = "deskew"
+	// Binarize turns the page black and white with a threshold following
+	// the local background, so shadows and uneven lighting don't swallow
+	// text.
+	Binarize Step = "binarize"
+)
+
+// Steps lists every step, in the order Apply runs them whatever order they
+// are given in.
+var Steps = []Step{Grayscale, Orient, Normalize, Denoise, Deskew, Binarize}
+
+// DefaultSteps is what uploads get unless they ask otherwise. Normalize and
+// Orient depend on information the caller may not have, they are opt-in.
+var DefaultSteps = []Step{Grayscale, Denoise, Deskew, Binarize}
+
+// DefaultTargetDPI is the resolution Tesseract is most accurate at.
+const DefaultTargetDPI = 300
+
+// ParseSteps parses a list of steps separated by commas or '+'. "none"
+// stands for no step at all.
+func ParseSteps(s string) ([]Step, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '+' || r == ',' || r == ' '
+	})
+
+	steps := make([]Step, 0, len(fields))
+	for _, field := range fields {
+		if field == "none" {
+			continue
+		}
+		step := Step(field)
+		if !slices.Contains(Steps, step) {
+			return nil, fmt.Errorf("unknown preprocessing step %q", field)
+		}
+		if !slices.Contains(steps, step) {
+			steps = append(steps, step)
+		}
+	}
+	return steps, nil
+}
+
+// Options selects and tunes the steps Apply runs.
+type Options struct {
+	Steps []Step
+	// Rotate is how many degrees, clockwise, Orient rotates the page by:
+	// 0, 90, 180 or 270.
+	Rotate int
+	// SourceDPI is the resolution of the input image. Normalize is skipped
+	// when it is unknown (0).
+	SourceDPI float64
+	// TargetDPI is what Normalize rescales to, DefaultTargetDPI when 0.
+	TargetDPI float64
+}
+
+// Result is a preprocessed image and the way back to the source image.
+type Result struct {
+	Image *image.Gray
+	// Skew is the angle Deskew corrected, in degrees.
+	Skew     float64
+	toSource affine
+}
+
+// SourceRect maps a rectangle of the preprocessed image to the source image,
+// as the smallest rectangle holding it.
+func (r *Result) SourceRect(rect image.Rectangle) image.Rectangle {
+	return r.toSource.rect(rect)
+}
+
+// Apply runs the steps of opts over img.
+func Apply(img image.Image, opts Options) (*Result, error) {
+	result := &Result{Image: toGray(img), toSource: identity}
+
+	for _, step := range Steps {
+		if !slices.Contains(opts.Steps, step) {
+			continue
+		}
+
+		switch step {
+		case Orient:
+			if err := result.orient(opts.Rotate); err != nil {
+				return nil, err
+			}
+		case Normalize:
+			result.normalize(opts.SourceDPI, opts.TargetDPI)
+		case Denoise:
+			result.Image = medianFilter(result.Image)
+		case Deskew:
+			result.deskew()
+		case Binarize:
+			result.Image = sauvola(result.Image, sauvolaWindow, sauvolaK)
+		}
+	}
+	return result, nil
+}
+
+func (r *Result) orient(degrees int) error {
+	var rotated *image.Gray
+	var inverse affine
+	w, h := float64(r.Image.Bounds().Dx()), float64(r.Image.Bounds().Dy())
+
+	switch degrees {
+	case 0:
+		return nil
+	case 90:
+		rotated = rotate90(r.Image)
+		inverse = affine{0, 1, 0, -1, 0, h}
+	case 180:
+		rotated = rotate180(r.Image)
+		inverse = affine{-1, 0, w, 0, -1, h}
+	case 270:
+		rotated = rotate270(r.Image)
+		inverse = affine{0, -1, w, 1, 0, 0}
+	default:
+		return fmt.Errorf("can't rotate by %d degrees, only by quarter turns", degrees)
+	}
+
+	r.Image = rotated
+	r.toSource = r.toSource.then(inverse)
+	return nil
+}
+
+func (r *Result) normalize(sourceDPI, targetDPI float64) {
+	if targetDPI <= 0 {
+		targetDPI = DefaultTargetDPI
+	}
+	if sourceDPI <= 0 || sourceDPI == targetDPI {
+		return
+	}
+
+	scaled := scale(r.Image, targetDPI/sourceDPI)
+	sx := float64(r.Image.Bounds().Dx()) / float64(scaled.Bounds().Dx())
+	sy := float64(r.Image.Bounds().Dy()) / float64(scaled.Bounds().Dy())
+
+	r.Image = scaled
+	r.toSource = r.toSource.then(affine{sx, 0, 0, 0, sy, 0})
+}
+
+func (r *Result) deskew() {
+	angle := estimateSkew(r.Image)
+	if angle == 0 {
+		return
+	}
+
+	var inverse affine
+	r.Image, inverse = rotate(r.Image, -angle)
+	r.Skew = angle
+	r.toSource = r.toSource.then(inverse)
+}
+
+// toGray returns img as a gray image with its origin at (0, 0).
+func toGray(img image.Image) *image.Gray {
+	bounds := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(gray, gray.Bounds(), img, bounds.Min, draw.Src)
+	return gray
+}
@@ -0,0 +1,168 @@
+package preprocess
+
+import (
+	"flag"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images in testdata/golden")
+
+// goldenTolerance is the share of pixels allowed to differ from a golden
+// image, room for floating point differences between platforms.
+const goldenTolerance = 0.002
+
+func readImage(t *testing.T, path string) image.Image {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	img, err := png.Decode(file)
+	require.NoError(t, err)
+	return img
+}
+
+func requireGolden(t *testing.T, name string, img *image.Gray) {
+	path := filepath.Join("testdata", "golden", name+".png")
+
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		file, err := os.Create(path)
+		require.NoError(t, err)
+		require.NoError(t, png.Encode(file, img))
+		require.NoError(t, file.Close())
+		return
+	}
+
+	golden := toGray(readImage(t, path))
+	require.Equal(t, golden.Bounds(), img.Bounds(), "size differs from %s", path)
+
+	var diff int
+	for i := range golden.Pix {
+		if golden.Pix[i] != img.Pix[i] {
+			diff++
+		}
+	}
+	require.LessOrEqual(t, float64(diff)/float64(len(golden.Pix)), goldenTolerance,
+		"%d pixels differ from %s, run go test -update if the change is intended", diff, path)
+}
+
+func TestApplyGolden(t *testing.T) {
+	scan := readImage(t, "testdata/scan.png")
+
+	testCases := []struct {
+		name string
+		opts Options
+	}{
+		{"grayscale", Options{Steps: []Step{Grayscale}}},
+		{"binarize", Options{Steps: []Step{Binarize}}},
+		{"denoise", Options{Steps: []Step{Denoise}}},
+		{"deskew", Options{Steps: []Step{Deskew}}},
+		{"orient", Options{Steps: []Step{Orient}, Rotate: 90}},
+		{"normalize", Options{Steps: []Step{Normalize}, SourceDPI: 150, TargetDPI: 200}},
+		{"default", Options{Steps: DefaultSteps}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Apply(scan, tc.opts)
+			require.NoError(t, err)
+			requireGolden(t, tc.name, result.Image)
+		})
+	}
+}
+
+func TestEstimateSkew(t *testing.T) {
+	scan := toGray(readImage(t, "testdata/scan.png"))
+
+	// The fixture is tilted 3 degrees clockwise.
+	require.InDelta(t, 3, estimateSkew(scan), 0.3)
+
+	straight, _ := rotate(scan, -estimateSkew(scan))
+	require.InDelta(t, 0, estimateSkew(straight), 0.3)
+
+	blank := image.NewGray(image.Rect(0, 0, 50, 50))
+	for i := range blank.Pix {
+		blank.Pix[i] = 255
+	}
+	require.Zero(t, estimateSkew(blank))
+}
+
+func TestSourceRect(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 200, 100))
+	word := image.Rect(10, 20, 50, 30)
+
+	testCases := []struct {
+		name string
+		opts Options
+		// where word ends up on the preprocessed image
+		moved image.Rectangle
+	}{
+		{"none", Options{}, word},
+		{"orient 90", Options{Steps: []Step{Orient}, Rotate: 90}, image.Rect(70, 10, 80, 50)},
+		{"orient 180", Options{Steps: []Step{Orient}, Rotate: 180}, image.Rect(150, 70, 190, 80)},
+		{"orient 270", Options{Steps: []Step{Orient}, Rotate: 270}, image.Rect(20, 150, 30, 190)},
+		{"normalize", Options{Steps: []Step{Normalize}, SourceDPI: 150, TargetDPI: 300}, image.Rect(20, 40, 100, 60)},
+		{
+			"orient and normalize",
+			Options{Steps: []Step{Normalize, Orient}, Rotate: 90, SourceDPI: 100, TargetDPI: 300},
+			image.Rect(210, 30, 240, 150),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Apply(img, tc.opts)
+			require.NoError(t, err)
+			require.Equal(t, word, result.SourceRect(tc.moved))
+		})
+	}
+}
+
+func TestRotateRoundTrip(t *testing.T) {
+	img := toGray(readImage(t, "testdata/scan.png"))
+
+	require.Equal(t, img.Pix, rotate270(rotate90(img)).Pix)
+	require.Equal(t, img.Pix, rotate180(rotate180(img)).Pix)
+	require.Equal(t, rotate180(img).Pix, rotate90(rotate90(img)).Pix)
+}
+
+func TestRotateTransform(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 200, 100))
+	_, inverse := rotate(img, 90)
+
+	// Turning clockwise a quarter around the center (100, 50), the point
+	// right of the center ends up below it.
+	x, y := inverse.point(100, 60)
+	require.InDelta(t, 110, x, 1e-9)
+	require.InDelta(t, 50, y, 1e-9)
+
+	_, inverse = rotate(img, 0)
+	for i, v := range inverse {
+		require.InDelta(t, identity[i], v, 1e-9)
+	}
+}
+
+func TestApplyInvalidRotation(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 10, 10))
+	_, err := Apply(img, Options{Steps: []Step{Orient}, Rotate: 45})
+	require.Error(t, err)
+}
+
+func TestParseSteps(t *testing.T) {
+	steps, err := ParseSteps("deskew,binarize+deskew")
+	require.NoError(t, err)
+	require.Equal(t, []Step{Deskew, Binarize}, steps)
+
+	steps, err = ParseSteps("none")
+	require.NoError(t, err)
+	require.Empty(t, steps)
+
+	_, err = ParseSteps("grayscale,sharpen")
+	require.ErrorContains(t, err, `"sharpen"`)
+}
@@ -0,0 +1,132 @@
+package preprocess
+
+import (
+	"image"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// affine maps a point (x, y) to (a*x + b*y + c, d*x + e*y + f).
+type affine [6]float64
+
+var identity = affine{1, 0, 0, 0, 1, 0}
+
+func (m affine) point(x, y float64) (float64, float64) {
+	return m[0]*x + m[1]*y + m[2], m[3]*x + m[4]*y + m[5]
+}
+
+// then returns the transform applying next, then m. Steps record the way back
+// from their output to their input, so a result's transform to the source is
+// built as m.then(step) in step order.
+func (m affine) then(next affine) affine {
+	return affine{
+		m[0]*next[0] + m[1]*next[3],
+		m[0]*next[1] + m[1]*next[4],
+		m[0]*next[2] + m[1]*next[5] + m[2],
+		m[3]*next[0] + m[4]*next[3],
+		m[3]*next[1] + m[4]*next[4],
+		m[3]*next[2] + m[4]*next[5] + m[5],
+	}
+}
+
+// rect maps the corners of r and returns the smallest rectangle holding them.
+func (m affine) rect(r image.Rectangle) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range [][2]int{{r.Min.X, r.Min.Y}, {r.Max.X, r.Min.Y}, {r.Min.X, r.Max.Y}, {r.Max.X, r.Max.Y}} {
+		x, y := m.point(float64(corner[0]), float64(corner[1]))
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+	return image.Rect(
+		int(math.Floor(minX+1e-9)), int(math.Floor(minY+1e-9)),
+		int(math.Ceil(maxX-1e-9)), int(math.Ceil(maxY-1e-9)),
+	)
+}
+
+// rotate90 turns img a quarter clockwise.
+func rotate90(img *image.Gray) *image.Gray {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	out := image.NewGray(image.Rect(0, 0, h, w))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			out.Pix[x*out.Stride+h-1-y] = img.Pix[y*img.Stride+x]
+		}
+	}
+	return out
+}
+
+func rotate180(img *image.Gray) *image.Gray {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	out := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			out.Pix[(h-1-y)*out.Stride+w-1-x] = img.Pix[y*img.Stride+x]
+		}
+	}
+	return out
+}
+
+// rotate270 turns img a quarter counterclockwise.
+func rotate270(img *image.Gray) *image.Gray {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	out := image.NewGray(image.Rect(0, 0, h, w))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			out.Pix[(w-1-x)*out.Stride+y] = img.Pix[y*img.Stride+x]
+		}
+	}
+	return out
+}
+
+// rotate turns img by degrees around its center, clockwise for positive
+// angles in image coordinates, keeping its size. Uncovered corners repeat the
+// nearest edge pixels: a white fill would draw edges on dark backgrounds that
+// binarization turns into black bars.
+// It returns the transform from the rotated image back to img.
+func rotate(img *image.Gray, degrees float64) (*image.Gray, affine) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	cx, cy := float64(w)/2, float64(h)/2
+
+	// A point of the output comes from the input point turned the other way.
+	sin, cos := math.Sincos(-degrees * math.Pi / 180)
+	inverse := affine{
+		cos, -sin, cx - cos*cx + sin*cy,
+		sin, cos, cy - sin*cx - cos*cy,
+	}
+
+	out := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sx, sy := inverse.point(float64(x)+0.5, float64(y)+0.5)
+			out.Pix[y*out.Stride+x] = bilinear(img, sx-0.5, sy-0.5)
+		}
+	}
+	return out, inverse
+}
+
+// bilinear samples img at (x, y), clamped to its edges.
+func bilinear(img *image.Gray, x, y float64) uint8 {
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	at := func(x, y int) float64 {
+		x = min(max(x, 0), img.Rect.Dx()-1)
+		y = min(max(y, 0), img.Rect.Dy()-1)
+		return float64(img.Pix[y*img.Stride+x])
+	}
+
+	top := at(x0, y0)*(1-fx) + at(x0+1, y0)*fx
+	bottom := at(x0, y0+1)*(1-fx) + at(x0+1, y0+1)*fx
+	return uint8(math.Round(top*(1-fy) + bottom*fy))
+}
+
+// scale resizes img by factor.
+func scale(img *image.Gray, factor float64) *image.Gray {
+	w := max(1, int(math.Round(float64(img.Bounds().Dx())*factor)))
+	h := max(1, int(math.Round(float64(img.Bounds().Dy())*factor)))
+	out := image.NewGray(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(out, out.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+	return out
+}
@@ -0,0 +1,255 @@
+package searchablepdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"unicode/utf16"
+)
+
+// Metrics of the glyphless font, in units of 1/1000 em like PDF glyph space.
+const (
+	fontAdvance = 1000 * glyphWidth
+	fontAscent  = 800
+	fontDescent = -200
+)
+
+// maxCIDs is the number of characters a document can have CIDs for, two byte
+// codes with 0 left to .notdef.
+const maxCIDs = 0xffff
+
+// cidTable numbers the characters of a document, in the order they first
+// appear, for the two byte codes of the text layer.
+type cidTable struct {
+	cids  map[rune]uint16
+	runes []rune // runes[i] has CID i+1
+}
+
+func newCIDs(pages []Page) *cidTable {
+	t := &cidTable{cids: make(map[rune]uint16)}
+	for _, page := range pages {
+		for _, word := range page.Words {
+			for _, r := range word.Text {
+				if _, ok := t.cids[r]; !ok && len(t.runes) < maxCIDs {
+					t.runes = append(t.runes, r)
+					t.cids[r] = uint16(len(t.runes))
+				}
+			}
+		}
+	}
+	return t
+}
+
+// encode returns the codes of text, two bytes per character. Characters past
+// maxCIDs get .notdef, they still take up their share of the word box.
+func (t *cidTable) encode(text string) []byte {
+	out := make([]byte, 0, 2*len(text))
+	for _, r := range text {
+		out = binary.BigEndian.AppendUint16(out, t.cids[r])
+	}
+	return out
+}
+
+// writeFont writes the text layer font as objects obj to obj+5: the Type0
+// font, its CIDFont, font descriptor, font program, CID to glyph map and
+// ToUnicode CMap.
+func writeFont(pdf *writer, obj int, cids *cidTable) error {
+	pdf.object(obj, fmt.Sprintf(
+		"<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		fontName, obj+1, obj+5,
+	))
+	pdf.object(obj+1, fmt.Sprintf(
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+			"/FontDescriptor %d 0 R /DW %s /CIDToGIDMap %d 0 R >>",
+		fontName, obj+2, num(fontAdvance), obj+4,
+	))
+	pdf.object(obj+2, fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName /%s /Flags 5 /FontBBox [0 %d %s %d] "+
+			"/ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		fontName, fontDescent, num(fontAdvance), fontAscent,
+		fontAscent, fontDescent, fontAscent, obj+3,
+	))
+
+	program := glyphlessFont()
+	deflated, err := deflate(program)
+	if err != nil {
+		return err
+	}
+	pdf.stream(obj+3, fmt.Sprintf("/Length1 %d /Filter /FlateDecode", len(program)), deflated)
+
+	// Every character is drawn with glyph 1, which draws nothing.
+	gids := make([]byte, 2*(len(cids.runes)+1))
+	for cid := 1; cid <= len(cids.runes); cid++ {
+		binary.BigEndian.PutUint16(gids[2*cid:], 1)
+	}
+	deflated, err = deflate(gids)
+	if err != nil {
+		return err
+	}
+	pdf.stream(obj+4, "/Filter /FlateDecode", deflated)
+
+	deflated, err = deflate(toUnicodeCMap(cids))
+	if err != nil {
+		return err
+	}
+	pdf.stream(obj+5, "/Filter /FlateDecode", deflated)
+	return pdf.err
+}
+
+// toUnicodeCMap maps the CIDs of the document back to their characters.
+func toUnicodeCMap(cids *cidTable) []byte {
+	var cmap bytes.Buffer
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n" +
+		"12 dict begin\n" +
+		"begincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n" +
+		"/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	// A bfchar block holds at most 100 mappings.
+	for start := 0; start < len(cids.runes); start += 100 {
+		block := cids.runes[start:min(start+100, len(cids.runes))]
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", len(block))
+		for i, r := range block {
+			fmt.Fprintf(&cmap, "<%04X> <", start+i+1)
+			for _, unit := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&cmap, "%04X", unit)
+			}
+			cmap.WriteString(">\n")
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+
+	cmap.WriteString("endcmap\n" +
+		"CMapName currentdict /CMap defineresource pop\n" +
+		"end\n" +
+		"end\n")
+	return cmap.Bytes()
+}
+
+// glyphlessFont builds a TrueType font of two glyphs without outlines,
+// .notdef and the one every character is drawn with, fontAdvance wide. It
+// only has the tables PDF readers need of an embedded CIDFont program.
+func glyphlessFont() []byte {
+	const numGlyphs = 2
+
+	be := binary.BigEndian
+	descent := int16(fontDescent)
+
+	head := make([]byte, 54)
+	be.PutUint32(head[0:], 0x00010000)           // version
+	be.PutUint32(head[4:], 0x00010000)           // fontRevision
+	be.PutUint32(head[12:], 0x5f0f3cf5)          // magicNumber
+	be.PutUint16(head[16:], 0x000b)              // flags
+	be.PutUint16(head[18:], 1000)                // unitsPerEm
+	be.PutUint16(head[38:], uint16(descent))     // yMin
+	be.PutUint16(head[40:], uint16(fontAdvance)) // xMax
+	be.PutUint16(head[42:], fontAscent)          // yMax
+	be.PutUint16(head[46:], 3)                   // lowestRecPPEM
+	be.PutUint16(head[48:], 2)                   // fontDirectionHint
+	// indexToLocFormat 0: short loca offsets.
+
+	hhea := make([]byte, 36)
+	be.PutUint32(hhea[0:], 0x00010000)           // version
+	be.PutUint16(hhea[4:], fontAscent)           // ascender
+	be.PutUint16(hhea[6:], uint16(descent))      // descender
+	be.PutUint16(hhea[10:], uint16(fontAdvance)) // advanceWidthMax
+	be.PutUint16(hhea[16:], uint16(fontAdvance)) // xMaxExtent
+	be.PutUint16(hhea[18:], 1)                   // caretSlopeRise
+	be.PutUint16(hhea[34:], 1)                   // numberOfHMetrics
+
+	maxp := make([]byte, 32)
+	be.PutUint32(maxp[0:], 0x00010000) // version
+	be.PutUint16(maxp[4:], numGlyphs)
+	be.PutUint16(maxp[14:], 2) // maxZones
+
+	// One advance and left side bearing for all glyphs, then the left side
+	// bearing of the second.
+	hmtx := make([]byte, 6)
+	be.PutUint16(hmtx[0:], uint16(fontAdvance))
+
+	// Glyphs without outlines take no room in glyf.
+	loca := make([]byte, 2*(numGlyphs+1))
+	glyf := []byte{}
+
+	// A Windows Unicode cmap mapping no character, PDF maps CIDs to glyphs
+	// itself. Format 4 needs the closing segment at 0xFFFF.
+	cmap := make([]byte, 4+8+24)
+	be.PutUint16(cmap[2:], 1)   // numTables
+	be.PutUint16(cmap[4:], 3)   // platformID: Windows
+	be.PutUint16(cmap[6:], 1)   // encodingID: Unicode BMP
+	be.PutUint32(cmap[8:], 12)  // offset
+	be.PutUint16(cmap[12:], 4)  // format
+	be.PutUint16(cmap[14:], 24) // length
+	be.PutUint16(cmap[18:], 2)  // segCountX2
+	be.PutUint16(cmap[20:], 2)  // searchRange
+	be.PutUint16(cmap[26:], 0xffff)
+	be.PutUint16(cmap[30:], 0xffff)
+	be.PutUint16(cmap[32:], 1) // idDelta
+
+	// Names and glyph names are left out.
+	name := make([]byte, 6)
+	be.PutUint16(name[4:], 6) // stringOffset
+	post := make([]byte, 32)
+	be.PutUint32(post[0:], 0x00030000) // version
+
+	tables := []struct {
+		tag  string
+		data []byte
+	}{
+		// In tag order.
+		{"cmap", cmap},
+		{"glyf", glyf},
+		{"head", head},
+		{"hhea", hhea},
+		{"hmtx", hmtx},
+		{"loca", loca},
+		{"maxp", maxp},
+		{"name", name},
+		{"post", post},
+	}
+
+	var font bytes.Buffer
+	binary.Write(&font, be, uint32(0x00010000))
+	entrySelector := bits.Len(uint(len(tables))) - 1
+	searchRange := 16 << entrySelector
+	binary.Write(&font, be, uint16(len(tables)))
+	binary.Write(&font, be, uint16(searchRange))
+	binary.Write(&font, be, uint16(entrySelector))
+	binary.Write(&font, be, uint16(16*len(tables)-searchRange))
+
+	offset := 12 + 16*len(tables)
+	var headOffset int
+	for _, table := range tables {
+		font.WriteString(table.tag)
+		binary.Write(&font, be, tableChecksum(table.data))
+		binary.Write(&font, be, uint32(offset))
+		binary.Write(&font, be, uint32(len(table.data)))
+		if table.tag == "head" {
+			headOffset = offset
+		}
+		offset += (len(table.data) + 3) &^ 3
+	}
+	for _, table := range tables {
+		font.Write(table.data)
+		font.Write(make([]byte, (4-len(table.data)%4)%4))
+	}
+
+	data := font.Bytes()
+	be.PutUint32(data[headOffset+8:], 0xb1b0afba-tableChecksum(data)) // checkSumAdjustment
+	return data
+}
+
+// tableChecksum sums data as big-endian uint32s, zero padded.
+func tableChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
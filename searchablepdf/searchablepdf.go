@@ -0,0 +1,247 @@
+// Package searchablepdf builds PDFs out of scanned page images with an
+// invisible text layer laid over the OCR word boxes, so the text can be
+// searched and selected while the page still looks like the original scan.
+package searchablepdf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/yosa/ocr-golang-back/ocr"
+)
+
+// DefaultDPI is the resolution page images are assumed to be rendered at.
+const DefaultDPI = 150
+
+// JPEGQuality is the quality page images are re-encoded with.
+const JPEGQuality = 85
+
+// The text layer is set in a font without glyph outlines, embedded as a
+// CIDFont so words can be in any script: each character of the document gets
+// a CID, which the ToUnicode CMap maps back to the character for search and
+// copy. All its glyphs are equally wide, so a word's natural width is known
+// without font metrics and is stretched to its box with horizontal scaling.
+const (
+	fontName    = "GlyphLessFont"
+	glyphWidth  = 0.5 // in text space units per point of font size
+	baselineGap = 0.2 // share of the box height below the baseline
+)
+
+// Page is a page image and the words recognized on it, in image pixels.
+type Page struct {
+	ImagePath string
+	Words     []ocr.Word
+}
+
+// Options tunes Generate. The zero value uses DefaultDPI.
+type Options struct {
+	// DPI is the resolution the page images were rendered at; it sets the
+	// physical page size.
+	DPI float64
+}
+
+// Generate writes a searchable PDF with one page per image.
+func Generate(w io.Writer, pages []Page, opts Options) error {
+	if len(pages) == 0 {
+		return fmt.Errorf("no pages to write")
+	}
+	dpi := opts.DPI
+	if dpi <= 0 {
+		dpi = DefaultDPI
+	}
+
+	pdf := newWriter(w)
+	pdf.header()
+
+	// Objects 1 and 2 are the catalog and page tree, 3 to 8 the font, then
+	// each page takes three objects: the page, its content stream and its
+	// image.
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pageObject(i))
+	}
+	pdf.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	pdf.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	cids := newCIDs(pages)
+	if err := writeFont(pdf, 3, cids); err != nil {
+		return err
+	}
+
+	for i, page := range pages {
+		if err := writePage(pdf, pageObject(i), page, cids, dpi); err != nil {
+			return fmt.Errorf("page %d: %w", i+1, err)
+		}
+	}
+
+	return pdf.trailer(1)
+}
+
+func pageObject(i int) int {
+	return 9 + 3*i
+}
+
+func writePage(pdf *writer, obj int, page Page, cids *cidTable, dpi float64) error {
+	file, err := os.Open(page.ImagePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", page.ImagePath, err)
+	}
+
+	var jpg bytes.Buffer
+	if err := jpeg.Encode(&jpg, img, &jpeg.Options{Quality: JPEGQuality}); err != nil {
+		return err
+	}
+
+	bounds := img.Bounds()
+	scale := 72 / dpi
+	width := float64(bounds.Dx()) * scale
+	height := float64(bounds.Dy()) * scale
+
+	contentObj, imageObj := obj+1, obj+2
+	pdf.object(obj, fmt.Sprintf(
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] "+
+			"/Resources << /Font << /F1 3 0 R >> /XObject << /Im1 %d 0 R >> >> /Contents %d 0 R >>",
+		num(width), num(height), imageObj, contentObj,
+	))
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "q %s 0 0 %s 0 0 cm /Im1 Do Q\n", num(width), num(height))
+	writeTextLayer(&content, page.Words, cids, bounds.Min, scale, height)
+
+	deflated, err := deflate(content.Bytes())
+	if err != nil {
+		return err
+	}
+	pdf.stream(contentObj, "/Filter /FlateDecode", deflated)
+
+	colorSpace := "/DeviceRGB"
+	if isGray(img) {
+		colorSpace = "/DeviceGray"
+	}
+	pdf.stream(imageObj, fmt.Sprintf(
+		"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode",
+		bounds.Dx(), bounds.Dy(), colorSpace,
+	), jpg.Bytes())
+
+	return pdf.err
+}
+
+// writeTextLayer draws every word in invisible text (rendering mode 3) over
+// its box. PDF space has its origin at the bottom-left, image space at the
+// top-left, hence the flip against pageHeight.
+func writeTextLayer(content *bytes.Buffer, words []ocr.Word, cids *cidTable, origin image.Point, scale, pageHeight float64) {
+	if len(words) == 0 {
+		return
+	}
+
+	content.WriteString("BT 3 Tr\n")
+	for _, word := range words {
+		text := cids.encode(word.Text)
+		if len(text) == 0 || word.Width <= 0 || word.Height <= 0 {
+			continue
+		}
+
+		size := float64(word.Height) * scale
+		x := float64(word.X-origin.X) * scale
+		y := pageHeight - float64(word.Y-origin.Y+word.Height)*scale + size*baselineGap
+		natural := glyphWidth * size * float64(len(text)/2)
+		stretch := 100 * float64(word.Width) * scale / natural
+
+		fmt.Fprintf(content, "/F1 %s Tf %s Tz 1 0 0 1 %s %s Tm <%X> Tj\n",
+			num(size), num(stretch), num(x), num(y), text)
+	}
+	content.WriteString("ET\n")
+}
+
+func isGray(img image.Image) bool {
+	// Only *image.Gray is encoded as a single-component JPEG.
+	_, ok := img.(*image.Gray)
+	return ok
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var deflated bytes.Buffer
+	zw := zlib.NewWriter(&deflated)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return deflated.Bytes(), nil
+}
+
+// num formats a PDF real number with no exponent and no trailing zeros.
+func num(f float64) string {
+	s := fmt.Sprintf("%.3f", f)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// writer writes numbered PDF objects and keeps the byte offsets the xref table
+// needs. The first write error sticks and is returned by trailer.
+type writer struct {
+	w       *bufio.Writer
+	offset  int64
+	offsets map[int]int64
+	err     error
+}
+
+func newWriter(w io.Writer) *writer {
+	return &writer{w: bufio.NewWriter(w), offsets: make(map[int]int64)}
+}
+
+func (pdf *writer) write(s string) {
+	if pdf.err != nil {
+		return
+	}
+	n, err := pdf.w.WriteString(s)
+	pdf.offset += int64(n)
+	pdf.err = err
+}
+
+func (pdf *writer) header() {
+	// The binary comment tells transfer tools the file isn't plain text.
+	pdf.write("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+}
+
+func (pdf *writer) object(n int, body string) {
+	pdf.offsets[n] = pdf.offset
+	pdf.write(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", n, body))
+}
+
+func (pdf *writer) stream(n int, dict string, data []byte) {
+	pdf.offsets[n] = pdf.offset
+	pdf.write(fmt.Sprintf("%d 0 obj\n<< %s /Length %d >>\nstream\n", n, dict, len(data)))
+	pdf.write(string(data))
+	pdf.write("\nendstream\nendobj\n")
+}
+
+func (pdf *writer) trailer(root int) error {
+	size := len(pdf.offsets) + 1
+	xref := pdf.offset
+
+	pdf.write(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", size))
+	for n := 1; n < size; n++ {
+		pdf.write(fmt.Sprintf("%010d 00000 n \n", pdf.offsets[n]))
+	}
+	pdf.write(fmt.Sprintf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, root, xref))
+
+	if pdf.err != nil {
+		return pdf.err
+	}
+	return pdf.w.Flush()
+}
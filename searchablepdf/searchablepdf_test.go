@@ -0,0 +1,190 @@
+package searchablepdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/require"
+	xfont "golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/yosa/ocr-golang-back/ocr"
+)
+
+func recognize(t *testing.T, engine ocr.Engine, imagePaths ...string) []Page {
+	pages := make([]Page, len(imagePaths))
+	for i, imagePath := range imagePaths {
+		result, err := engine.Recognize(context.Background(), imagePath, ocr.Options{})
+		require.NoError(t, err)
+		pages[i] = Page{ImagePath: imagePath, Words: result.Words}
+	}
+	return pages
+}
+
+// requireValidXref checks every xref entry points at the object it numbers.
+func requireValidXref(t *testing.T, pdf []byte) {
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(pdf)
+	require.NotNil(t, m, "missing startxref")
+	xref, err := strconv.Atoi(string(m[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(pdf[xref:], []byte("xref\n")))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	require.NotEmpty(t, entries)
+	for i, entry := range entries {
+		offset, err := strconv.Atoi(string(entry[1]))
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(pdf[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), "object %d", i+1)
+	}
+}
+
+// flateStreams inflates the compressed streams without other parameters: the
+// page content streams, CID to glyph map and ToUnicode CMap.
+func flateStreams(t *testing.T, pdf []byte) []string {
+	re := regexp.MustCompile(`(?s)<< /Filter /FlateDecode /Length (\d+) >>\nstream\n`)
+	var streams []string
+	for _, loc := range re.FindAllSubmatchIndex(pdf, -1) {
+		length, err := strconv.Atoi(string(pdf[loc[2]:loc[3]]))
+		require.NoError(t, err)
+
+		r, err := zlib.NewReader(bytes.NewReader(pdf[loc[1] : loc[1]+length]))
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		streams = append(streams, string(content))
+	}
+	return streams
+}
+
+// contentStreams inflates the page content streams, in page order.
+func contentStreams(t *testing.T, pdf []byte) []string {
+	var streams []string
+	for _, stream := range flateStreams(t, pdf) {
+		if strings.HasPrefix(stream, "q ") {
+			streams = append(streams, stream)
+		}
+	}
+	return streams
+}
+
+// textLayer decodes the text a reader extracts from a content stream: the
+// words' codes mapped through the ToUnicode CMap.
+func textLayer(t *testing.T, pdf []byte, content string) []string {
+	var cmap string
+	for _, stream := range flateStreams(t, pdf) {
+		if strings.Contains(stream, "begincmap") {
+			cmap = stream
+		}
+	}
+	require.NotEmpty(t, cmap, "missing ToUnicode CMap")
+
+	chars := make(map[string]string)
+	for _, m := range regexp.MustCompile(`<([0-9A-F]{4})> <([0-9A-F]+)>\n`).FindAllStringSubmatch(cmap, -1) {
+		units, err := hex.DecodeString(m[2])
+		require.NoError(t, err)
+		utf := make([]uint16, len(units)/2)
+		for i := range utf {
+			utf[i] = binary.BigEndian.Uint16(units[2*i:])
+		}
+		chars[m[1]] = string(utf16.Decode(utf))
+	}
+
+	var words []string
+	for _, m := range regexp.MustCompile(`<([0-9A-F]*)> Tj`).FindAllStringSubmatch(content, -1) {
+		var word strings.Builder
+		for i := 0; i < len(m[1]); i += 4 {
+			char, ok := chars[m[1][i:i+4]]
+			require.True(t, ok, "code %s has no character", m[1][i:i+4])
+			word.WriteString(char)
+		}
+		words = append(words, word.String())
+	}
+	return words
+}
+
+func TestGenerate(t *testing.T) {
+	engine := &ocr.Fake{Text: "Invoice (total) 42€"}
+	pages := recognize(t, engine, "testdata/page-1.png", "testdata/page-2.png")
+
+	var buf bytes.Buffer
+	require.NoError(t, Generate(&buf, pages, Options{}))
+	pdf := buf.Bytes()
+
+	require.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	requireValidXref(t, pdf)
+	require.Contains(t, string(pdf), "/Count 2")
+	// 200x100 and 120x160 pixels at 150 DPI
+	require.Contains(t, string(pdf), "/MediaBox [0 0 96 48]")
+	require.Contains(t, string(pdf), "/MediaBox [0 0 57.6 76.8]")
+	require.Contains(t, string(pdf), "/Width 200 /Height 100 /ColorSpace /DeviceGray")
+
+	streams := contentStreams(t, pdf)
+	require.Len(t, streams, 2)
+	for _, content := range streams {
+		require.Contains(t, content, "/Im1 Do")
+		require.Contains(t, content, "BT 3 Tr\n")
+		require.Equal(t, []string{"Invoice", "(total)", "42€"}, textLayer(t, pdf, content))
+	}
+
+	// The first word of page 1 is 66x32 pixels at the top-left corner.
+	require.Contains(t, streams[0], "/F1 15.36 Tf 58.929 Tz 1 0 0 1 0 35.712 Tm <0001000200030004000500060007> Tj")
+}
+
+func TestGenerateScripts(t *testing.T) {
+	// Arabic, Chinese, Greek and a character outside the BMP.
+	engine := &ocr.Fake{Text: "فاتورة 发票 τιμολόγιο 𝐀"}
+	pages := recognize(t, engine, "testdata/page-1.png")
+
+	var buf bytes.Buffer
+	require.NoError(t, Generate(&buf, pages, Options{}))
+	pdf := buf.Bytes()
+	requireValidXref(t, pdf)
+	require.Contains(t, string(pdf), "/Subtype /Type0")
+	require.Contains(t, string(pdf), "/Encoding /Identity-H")
+
+	streams := contentStreams(t, pdf)
+	require.Len(t, streams, 1)
+	require.Equal(t, []string{"فاتورة", "发票", "τιμολόγιο", "𝐀"}, textLayer(t, pdf, streams[0]))
+}
+
+func TestGlyphlessFont(t *testing.T) {
+	program := glyphlessFont()
+	// The checksum adjustment makes the whole font sum to the magic value.
+	require.Equal(t, uint32(0xb1b0afba), tableChecksum(program))
+
+	font, err := sfnt.Parse(program)
+	require.NoError(t, err)
+	require.Equal(t, 2, font.NumGlyphs())
+
+	var b sfnt.Buffer
+	advance, err := font.GlyphAdvance(&b, 1, fixed.I(1000), xfont.HintingNone)
+	require.NoError(t, err)
+	require.Equal(t, fixed.I(fontAdvance), advance)
+	segments, err := font.LoadGlyph(&b, 1, fixed.I(1000), nil)
+	require.NoError(t, err)
+	require.Empty(t, segments)
+}
+
+func TestGenerateDPI(t *testing.T) {
+	pages := recognize(t, &ocr.Fake{}, "testdata/page-1.png")
+
+	var buf bytes.Buffer
+	require.NoError(t, Generate(&buf, pages, Options{DPI: 300}))
+	require.Contains(t, buf.String(), "/MediaBox [0 0 48 24]")
+}
+
+func TestGenerateErrors(t *testing.T) {
+	require.Error(t, Generate(io.Discard, nil, Options{}))
+	require.Error(t, Generate(io.Discard, []Page{{ImagePath: "testdata/missing.png"}}, Options{}))
+}
@@ -0,0 +1,34 @@
+// Package storage keeps the files documents are made of, such as uploaded
+// originals, out of the database, on local disk or in an S3-compatible
+// object store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned when there is no blob under a key.
+var ErrNotFound = errors.New("blob not found")
+
+// ErrInvalidKey is returned for keys that aren't slash-separated relative
+// paths, such as "originals/doc.pdf".
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Blob stores blobs under slash-separated keys.
+type Blob interface {
+	// Put stores the size bytes read from r under key, replacing any blob
+	// already there. size is -1 when unknown.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob under key for reading. The caller closes it.
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete removes the blob under key. Deleting a missing blob is not an
+	// error.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL anyone can download the blob from until
+	// expiry passes. Backends that can't serve blobs themselves return an
+	// error wrapping errors.ErrUnsupported.
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testBlob runs the behavior every backend shares.
+func testBlob(t *testing.T, blob Blob) {
+	ctx := context.Background()
+	content := "%PDF-1.4 original"
+
+	err := blob.Put(ctx, "originals/doc.pdf", strings.NewReader(content), int64(len(content)), "application/pdf")
+	require.NoError(t, err)
+
+	r, err := blob.Get(ctx, "originals/doc.pdf")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, content, string(data))
+
+	// Blobs can be read from anywhere, e.g. to serve ranges.
+	_, err = r.Seek(9, io.SeekStart)
+	require.NoError(t, err)
+	data, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "original", string(data))
+	require.NoError(t, r.Close())
+
+	// Putting again replaces the blob.
+	err = blob.Put(ctx, "originals/doc.pdf", strings.NewReader("new"), 3, "application/pdf")
+	require.NoError(t, err)
+	r, err = blob.Get(ctx, "originals/doc.pdf")
+	require.NoError(t, err)
+	data, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(data))
+	require.NoError(t, r.Close())
+
+	require.NoError(t, blob.Delete(ctx, "originals/doc.pdf"))
+	_, err = blob.Get(ctx, "originals/doc.pdf")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// Deleting twice is fine.
+	require.NoError(t, blob.Delete(ctx, "originals/doc.pdf"))
+}
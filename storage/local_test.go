@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal(t *testing.T) {
+	blob, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+	testBlob(t, blob)
+}
+
+func TestLocalKeys(t *testing.T) {
+	dir := t.TempDir()
+	blob, err := NewLocal(dir)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, blob.Put(ctx, "a/b/c.txt", strings.NewReader("abc"), 3, "text/plain"))
+	data, err := os.ReadFile(filepath.Join(dir, "a", "b", "c.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "abc", string(data))
+
+	for _, key := range []string{"", ".", "/etc/passwd", "../escape", "a/../../escape"} {
+		err := blob.Put(ctx, key, strings.NewReader("x"), 1, "text/plain")
+		require.ErrorIs(t, err, ErrInvalidKey, key)
+		_, err = blob.Get(ctx, key)
+		require.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
+
+func TestLocalShortPut(t *testing.T) {
+	dir := t.TempDir()
+	blob, err := NewLocal(dir)
+	require.NoError(t, err)
+
+	err = blob.Put(context.Background(), "short.txt", strings.NewReader("ab"), 3, "text/plain")
+	require.Error(t, err)
+
+	// Neither the blob nor the temporary file is left behind.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestLocalSignedURL(t *testing.T) {
+	blob, err := NewLocal(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = blob.SignedURL(context.Background(), "doc.pdf", time.Minute)
+	require.True(t, errors.Is(err, errors.ErrUnsupported))
+}
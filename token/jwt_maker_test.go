@@ -32,3 +32,28 @@ func TestJWTMaker(t *testing.T) {
 	require.WithinDuration(t, issuedAt, payload.IssuedAt.Time, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiresAt.Time, time.Second)
 }
+
+func TestJWTMakerPurpose(t *testing.T) {
+	maker, err := NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	username := util.RandomUsername()
+
+	linkToken, _, err := maker.CreatePurposeToken(username, PurposeShareLink, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyPurposeToken(linkToken, PurposeShareLink)
+	require.NoError(t, err)
+	require.Equal(t, PurposeShareLink, payload.Purpose)
+	require.Equal(t, username, payload.Username)
+
+	// A share link doesn't sign its maker in, nor does an access token open
+	// a share link.
+	_, err = maker.VerifyToken(linkToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	accessToken, _, err := maker.CreateToken(username, time.Minute)
+	require.NoError(t, err)
+	_, err = maker.VerifyPurposeToken(accessToken, PurposeShareLink)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
@@ -14,14 +14,34 @@ var (
 	ErrExpiredToken = errors.New("Token Is Expired")
 )
 
+// Purpose scopes a token to what it was made for. A token is only accepted
+// for its purpose, so that tokens handed out to others can't sign anyone in.
+type Purpose string
+
+const (
+	// PurposeAccess is the purpose of access and refresh tokens. It is empty
+	// like in the tokens made before purposes.
+	PurposeAccess Purpose = ""
+	// PurposeShareLink is the purpose of the tokens of public share links.
+	PurposeShareLink Purpose = "share_link"
+	// PurposeLive is the purpose of the short-lived tokens opening live
+	// result sockets, which browsers can't send an Authorization header on.
+	PurposeLive Purpose = "live"
+)
+
 type Maker interface {
 	CreateToken(username string, duration time.Duration) (string, *Payload, error)
+	// VerifyToken only accepts access tokens.
 	VerifyToken(token string) (*Payload, error)
+	CreatePurposeToken(username string, purpose Purpose, duration time.Duration) (string, *Payload, error)
+	// VerifyPurposeToken only accepts tokens made for purpose.
+	VerifyPurposeToken(token string, purpose Purpose) (*Payload, error)
 }
 
 type Payload struct {
 	ID       uuid.UUID `json:"id"`
 	Username string    `json:"username"`
+	Purpose  Purpose   `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -44,6 +64,15 @@ func NewPayload(username string, duration time.Duration) (*Payload, error) {
 	return &payload, nil
 }
 
+// checkPurpose fails with ErrInvalidToken for tokens made for another
+// purpose than purpose.
+func (payload *Payload) checkPurpose(purpose Purpose) error {
+	if payload.Purpose != purpose {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
 type JWTMaker struct {
 	secretKey string
 }
@@ -56,10 +85,15 @@ func NewJWTMaker(secretKey string) (Maker, error) {
 }
 
 func (maker *JWTMaker) CreateToken(username string, duration time.Duration) (string, *Payload, error) {
+	return maker.CreatePurposeToken(username, PurposeAccess, duration)
+}
+
+func (maker *JWTMaker) CreatePurposeToken(username string, purpose Purpose, duration time.Duration) (string, *Payload, error) {
 	payload, err := NewPayload(username, duration)
 	if err != nil {
 		return "", payload, fmt.Errorf("Invalid Payload : %w", err)
 	}
+	payload.Purpose = purpose
 
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
 	token, err := jwtToken.SignedString([]byte(maker.secretKey))
@@ -67,6 +101,10 @@ func (maker *JWTMaker) CreateToken(username string, duration time.Duration) (str
 }
 
 func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
+	return maker.VerifyPurposeToken(token, PurposeAccess)
+}
+
+func (maker *JWTMaker) VerifyPurposeToken(token string, purpose Purpose) (*Payload, error) {
 	keyFunc := func(token *jwt.Token) (interface{}, error) {
 		_, ok := token.Method.(*jwt.SigningMethodHMAC)
 		if !ok {
@@ -91,5 +129,8 @@ func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
 	if !ok {
 		return nil, ErrInvalidToken
 	}
+	if err := payload.checkPurpose(purpose); err != nil {
+		return nil, err
+	}
 	return payload, nil
 }
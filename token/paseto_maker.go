@@ -27,15 +27,24 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 }
 
 func (maker *PasetoMaker) CreateToken(username string, duration time.Duration) (string, *Payload, error) {
+	return maker.CreatePurposeToken(username, PurposeAccess, duration)
+}
+
+func (maker *PasetoMaker) CreatePurposeToken(username string, purpose Purpose, duration time.Duration) (string, *Payload, error) {
 	payload, err := NewPayload(username, duration)
 	if err != nil {
 		return "", payload, fmt.Errorf("Error in Creation Paseto Token : %w", err)
 	}
+	payload.Purpose = purpose
 
 	token, err := maker.paseto.Encrypt(maker.symmetricKey, payload, nil)
 	return token, payload, err
 }
 func (maker *PasetoMaker) VerifyToken(token string) (*Payload, error) {
+	return maker.VerifyPurposeToken(token, PurposeAccess)
+}
+
+func (maker *PasetoMaker) VerifyPurposeToken(token string, purpose Purpose) (*Payload, error) {
 	payload := &Payload{}
 
 	err := maker.paseto.Decrypt(token, maker.symmetricKey, payload, nil)
@@ -46,5 +55,8 @@ func (maker *PasetoMaker) VerifyToken(token string) (*Payload, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := payload.checkPurpose(purpose); err != nil {
+		return nil, err
+	}
 	return payload, nil
 }
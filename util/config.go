@@ -13,6 +13,24 @@ type Config struct {
 	ServerAddress        string        `mapstructure:"SERVER_ADDRESS"`
 	DBSource             string        `mapstructure:"DB_SOURCE"`
 	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	OCRWorkers           int           `mapstructure:"OCR_WORKERS"`
+	OCRPageWorkers       int           `mapstructure:"OCR_PAGE_WORKERS"`
+	OCRMaxAttempts       int32         `mapstructure:"OCR_MAX_ATTEMPTS"`
+	OCRJobTimeout        time.Duration `mapstructure:"OCR_JOB_TIMEOUT"`
+	OCRPollInterval      time.Duration `mapstructure:"OCR_POLL_INTERVAL"`
+	OCRDetectLanguages   string        `mapstructure:"OCR_DETECT_LANGUAGES"`
+	TessdataDir          string        `mapstructure:"TESSDATA_DIR"`
+	StorageBackend       string        `mapstructure:"STORAGE_BACKEND"`
+	StorageDir           string        `mapstructure:"STORAGE_DIR"`
+	ScratchDir           string        `mapstructure:"SCRATCH_DIR"`
+	MaxUploadSize        int64         `mapstructure:"MAX_UPLOAD_SIZE"`
+	LiveAllowedOrigins   string        `mapstructure:"LIVE_ALLOWED_ORIGINS"`
+	S3Endpoint           string        `mapstructure:"S3_ENDPOINT"`
+	S3Region             string        `mapstructure:"S3_REGION"`
+	S3Bucket             string        `mapstructure:"S3_BUCKET"`
+	S3AccessKeyID        string        `mapstructure:"S3_ACCESS_KEY_ID"`
+	S3SecretAccessKey    string        `mapstructure:"S3_SECRET_ACCESS_KEY"`
+	S3UseSSL             bool          `mapstructure:"S3_USE_SSL"`
 }
 
 func LoadConfig(path string) (Config, error) {
@@ -22,6 +40,26 @@ func LoadConfig(path string) (Config, error) {
 	viper.SetConfigFile(".env")
 	viper.SetConfigType("env")
 
+	viper.SetDefault("OCR_WORKERS", 2)
+	viper.SetDefault("OCR_PAGE_WORKERS", 4)
+	viper.SetDefault("OCR_MAX_ATTEMPTS", 3)
+	viper.SetDefault("OCR_JOB_TIMEOUT", "30m")
+	viper.SetDefault("OCR_POLL_INTERVAL", "2s")
+	// Languages tried when detecting one, as "eng+fra". Empty means the
+	// engine's default set.
+	viper.SetDefault("OCR_DETECT_LANGUAGES", "")
+	viper.SetDefault("TESSDATA_DIR", "/usr/share/tesseract-ocr/5/tessdata")
+	viper.SetDefault("STORAGE_BACKEND", "local")
+	viper.SetDefault("STORAGE_DIR", "storage")
+	// Empty means the system temp dir.
+	viper.SetDefault("SCRATCH_DIR", "")
+	viper.SetDefault("MAX_UPLOAD_SIZE", 100<<20)
+	// Browser origins besides the server's own that may open live result
+	// sockets, as "https://app.example.com,https://admin.example.com".
+	viper.SetDefault("LIVE_ALLOWED_ORIGINS", "")
+	viper.SetDefault("S3_REGION", "us-east-1")
+	viper.SetDefault("S3_USE_SSL", true)
+
 	viper.AutomaticEnv()
 
 	err := viper.ReadInConfig()